@@ -17,6 +17,7 @@ import (
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,10 +33,14 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/brush/internal/agent/hyper"
 	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/budget"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/hooks"
 	"github.com/charmbracelet/brush/internal/message"
 	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
 	"github.com/charmbracelet/brush/internal/session"
 	"github.com/charmbracelet/brush/internal/stringext"
 	"github.com/charmbracelet/x/exp/charmtone"
@@ -70,6 +75,15 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+
+	// stopHookActive is set when the call continues a turn a Stop hook
+	// blocked.
+	stopHookActive bool
+	// model overrides the large model for this call.
+	model *Model
+	// resume continues the session from its messages instead of adding
+	// the prompt, e.g. to retry a failed turn with a fallback model.
+	resume bool
 }
 
 type SessionAgent interface {
@@ -86,6 +100,7 @@ type SessionAgent interface {
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
 	Model() Model
+	SmallModel() Model
 }
 
 type Model struct {
@@ -104,11 +119,18 @@ type sessionAgent struct {
 	isSubAgent           bool
 	sessions             session.Service
 	messages             message.Service
+	history              history.Service
 	disableAutoSummarize bool
 	isYolo               bool
+	hooks                *hooks.Runner
+	budgets              *budget.Checker
+	retries              *pubsub.Broker[RetryEvent]
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
+	// sessionContext holds the context added by SessionStart hooks, keyed by
+	// session ID. Sessions present in the map have been started.
+	sessionContext *csync.Map[string, string]
 }
 
 type SessionAgentOptions struct {
@@ -121,7 +143,12 @@ type SessionAgentOptions struct {
 	IsYolo               bool
 	Sessions             session.Service
 	Messages             message.Service
+	History              history.Service
 	Tools                []fantasy.AgentTool
+	Hooks                *hooks.Runner
+	Budgets              *budget.Checker
+	// Retries receives the retries of provider requests.
+	Retries *pubsub.Broker[RetryEvent]
 }
 
 func NewSessionAgent(
@@ -135,11 +162,16 @@ func NewSessionAgent(
 		isSubAgent:           opts.IsSubAgent,
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
+		history:              opts.History,
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                csync.NewSliceFrom(opts.Tools),
 		isYolo:               opts.IsYolo,
+		hooks:                opts.Hooks,
+		budgets:              opts.Budgets,
+		retries:              opts.Retries,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
+		sessionContext:       csync.NewMap[string, string](),
 	}
 }
 
@@ -162,9 +194,23 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, nil
 	}
 
+	// Add the session to the context.
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
+
+	// Mark the session busy right away, so prompts sent while the hooks run
+	// are queued.
+	genCtx, cancel := context.WithCancel(ctx)
+	a.activeRequests.Set(call.SessionID, cancel)
+
+	defer cancel()
+	defer a.activeRequests.Del(call.SessionID)
+
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
 	agentTools := a.tools.Copy()
 	largeModel := a.largeModel.Get()
+	if call.model != nil {
+		largeModel = *call.model
+	}
 	systemPrompt := a.systemPrompt.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 
@@ -189,6 +235,17 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session messages: %w", err)
 	}
+	if call.resume {
+		msgs, err = a.dropFailedAssistant(ctx, msgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hookContext, err := a.runPromptHooks(genCtx, call)
+	if err != nil {
+		return nil, err
+	}
 
 	var wg sync.WaitGroup
 	// Generate title if first message.
@@ -200,30 +257,61 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	}
 	defer wg.Wait()
 
-	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
-	if err != nil {
-		return nil, err
+	// Add the user message to the session, with the hook context so it's
+	// sent again on the next turns.
+	if !call.resume {
+		_, err = a.createUserMessage(ctx, call, hookContext)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Add the session to the context.
-	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
-
-	genCtx, cancel := context.WithCancel(ctx)
-	a.activeRequests.Set(call.SessionID, cancel)
-
-	defer cancel()
-	defer a.activeRequests.Del(call.SessionID)
-
 	history, files := a.preparePrompt(msgs, call.Attachments...)
 
+	prompt := message.PromptWithHookContext(message.PromptWithTextAttachments(call.Prompt, call.Attachments), hookContext)
+
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
 
 	var currentAssistant *message.Message
+	// assistantStream buffers the streamed updates of currentAssistant.
+	var assistantStream *message.Stream
+	defer func() {
+		if assistantStream != nil {
+			if err := assistantStream.Close(); err != nil {
+				slog.Error("Failed to write assistant message", "error", err)
+			}
+		}
+	}()
 	var shouldSummarize bool
+	// budgetErr and stepLimitReached are set when a budget stopped the turn.
+	var budgetErr error
+	var stepLimitReached bool
+	// Retries are done by the retry model following the provider's policy,
+	// not by fantasy, so they can be recorded and shown while waiting.
+	genCtx = withRetryNotifier(genCtx, func(event RetryEvent) {
+		event.SessionID = call.SessionID
+		if currentAssistant != nil {
+			event.MessageID = currentAssistant.ID
+			currentAssistant.Retries++
+			if err := assistantStream.Flush(genCtx, *currentAssistant); err != nil {
+				slog.Error("Failed to record retry", "error", err)
+			}
+		}
+		slog.Warn("Retrying provider request",
+			"provider", event.Provider,
+			"model", event.Model,
+			"attempt", event.Attempt,
+			"max_attempts", event.MaxAttempts,
+			"delay", event.Delay,
+			"error", event.Error,
+		)
+		if a.retries != nil {
+			a.retries.Publish(pubsub.CreatedEvent, event)
+		}
+	})
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
+		Prompt:           prompt,
 		Files:            files,
 		Messages:         history,
 		ProviderOptions:  call.ProviderOptions,
@@ -233,8 +321,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		PresencePenalty:  call.PresencePenalty,
 		TopK:             call.TopK,
 		FrequencyPenalty: call.FrequencyPenalty,
+		MaxRetries:       new(int),
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = options.Messages
+			if call.resume {
+				// The history already ends where the turn left off, so drop
+				// the prompt fantasy adds after it.
+				promptIndex := len(history)
+				if systemPrompt != "" {
+					promptIndex++
+				}
+				prepared.Messages = slices.Delete(slices.Clone(prepared.Messages), promptIndex, promptIndex+1)
+			}
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
@@ -242,7 +340,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
 			a.messageQueue.Del(call.SessionID)
 			for _, queued := range queuedCalls {
-				userMessage, createErr := a.createUserMessage(callContext, queued)
+				userMessage, createErr := a.createUserMessage(callContext, queued, "")
 				if createErr != nil {
 					return callContext, prepared, createErr
 				}
@@ -284,16 +382,22 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
 			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, largeModel.CatwalkCfg.SupportsImages)
 			callContext = context.WithValue(callContext, tools.ModelNameContextKey, largeModel.CatwalkCfg.Name)
+			if assistantStream != nil {
+				if err = assistantStream.Close(); err != nil {
+					return callContext, prepared, err
+				}
+			}
 			currentAssistant = &assistantMsg
+			assistantStream = a.messages.Stream(ctx, assistantMsg)
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
 			currentAssistant.AppendReasoningContent(reasoning.Text)
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Update(genCtx, *currentAssistant)
 		},
 		OnReasoningDelta: func(id string, text string) error {
 			currentAssistant.AppendReasoningContent(text)
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Update(genCtx, *currentAssistant)
 		},
 		OnReasoningEnd: func(id string, reasoning fantasy.ReasoningContent) error {
 			// handle anthropic signature
@@ -313,7 +417,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				}
 			}
 			currentAssistant.FinishThinking()
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Flush(genCtx, *currentAssistant)
 		},
 		OnTextDelta: func(id string, text string) error {
 			// Strip leading newline from initial text content. This is is
@@ -324,7 +428,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			}
 
 			currentAssistant.AppendContent(text)
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Update(genCtx, *currentAssistant)
 		},
 		OnToolInputStart: func(id string, toolName string) error {
 			toolCall := message.ToolCall{
@@ -334,10 +438,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				Finished:         false,
 			}
 			currentAssistant.AddToolCall(toolCall)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
-			// TODO: implement
+			return assistantStream.Flush(genCtx, *currentAssistant)
 		},
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			toolCall := message.ToolCall{
@@ -348,7 +449,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				Finished:         true,
 			}
 			currentAssistant.AddToolCall(toolCall)
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Flush(genCtx, *currentAssistant)
 		},
 		OnToolResult: func(result fantasy.ToolResultContent) error {
 			toolResult := a.convertToToolResult(result)
@@ -377,18 +478,39 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				sessionLock.Unlock()
 				return getSessionErr
 			}
-			a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			spent := a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
 			_, sessionErr := a.sessions.Save(genCtx, updatedSession)
 			if sessionErr == nil {
 				currentSession = updatedSession
+				sessionErr = a.sessions.RecordUsage(genCtx, call.SessionID, spent)
 			}
 			sessionLock.Unlock()
 			if sessionErr != nil {
 				return sessionErr
 			}
-			return a.messages.Update(genCtx, *currentAssistant)
+			return assistantStream.Flush(genCtx, *currentAssistant)
 		},
 		StopWhen: []fantasy.StopCondition{
+			func(steps []fantasy.StepResult) bool {
+				if !a.budgets.Enabled() || !wantsNextStep(steps) {
+					return false
+				}
+				sessionLock.Lock()
+				sess := currentSession
+				sessionLock.Unlock()
+				statuses, checkErr := a.budgets.Check(genCtx, sess)
+				if checkErr != nil {
+					slog.Error("Failed to check budgets", "error", checkErr)
+					return false
+				}
+				budgetErr = budget.Exceeded(statuses)
+				return budgetErr != nil
+			},
+			func(steps []fantasy.StepResult) bool {
+				maxSteps := a.budgets.MaxStepsPerTurn()
+				stepLimitReached = maxSteps > 0 && len(steps) >= maxSteps && wantsNextStep(steps)
+				return stepLimitReached
+			},
 			func(_ []fantasy.StepResult) bool {
 				cw := int64(largeModel.CatwalkCfg.ContextWindow)
 				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
@@ -429,7 +551,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				tc.Finished = true
 				tc.Input = "{}"
 				currentAssistant.AddToolCall(tc)
-				updateErr := a.messages.Update(ctx, *currentAssistant)
+				updateErr := assistantStream.Flush(ctx, *currentAssistant)
 				if updateErr != nil {
 					return nil, updateErr
 				}
@@ -505,13 +627,27 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		}
 		// Note: we use the parent context here because the genCtx has been
 		// cancelled.
-		updateErr := a.messages.Update(ctx, *currentAssistant)
+		updateErr := assistantStream.Flush(ctx, *currentAssistant)
 		if updateErr != nil {
 			return nil, updateErr
 		}
 		return nil, err
 	}
 
+	if budgetErr != nil || stepLimitReached {
+		if budgetErr != nil {
+			currentAssistant.AddFinish(message.FinishReasonError, "Budget exceeded", budgetErr.Error())
+		} else {
+			currentAssistant.AddFinish(message.FinishReasonError, "Step limit reached", fmt.Sprintf("The turn stopped after %d steps. Send a message to continue.", len(result.Steps)))
+		}
+		if updateErr := assistantStream.Flush(ctx, *currentAssistant); updateErr != nil {
+			return nil, updateErr
+		}
+		// Queued prompts would start another turn.
+		a.messageQueue.Del(call.SessionID)
+		return result, budgetErr
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
 		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
@@ -533,6 +669,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	a.activeRequests.Del(call.SessionID)
 	cancel()
 
+	if !shouldSummarize {
+		a.runStopHooks(ctx, call, result)
+	}
+
 	queuedMessages, ok := a.messageQueue.Get(call.SessionID)
 	if !ok || len(queuedMessages) == 0 {
 		return result, err
@@ -543,6 +683,74 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	return a.Run(ctx, firstQueuedMessage)
 }
 
+// wantsNextStep reports whether the agent would go on after the last step,
+// so stopping cuts the turn short.
+func wantsNextStep(steps []fantasy.StepResult) bool {
+	return len(steps) > 0 && steps[len(steps)-1].FinishReason == fantasy.FinishReasonToolCalls
+}
+
+// runPromptHooks runs the SessionStart hooks the first time a session is used
+// and the UserPromptSubmit hooks for every prompt. It returns the context the
+// hooks added for the model.
+func (a *sessionAgent) runPromptHooks(ctx context.Context, call SessionAgentCall) (string, error) {
+	if a.isSubAgent || call.resume {
+		return "", nil
+	}
+
+	sessionContext, started := a.sessionContext.Get(call.SessionID)
+	if !started {
+		result := a.hooks.Run(ctx, hooks.Input{
+			Event:     config.HookSessionStart,
+			SessionID: call.SessionID,
+			Prompt:    call.Prompt,
+		})
+		sessionContext = result.Context
+		a.sessionContext.Set(call.SessionID, sessionContext)
+	}
+
+	result := a.hooks.Run(ctx, hooks.Input{
+		Event:     config.HookUserPromptSubmit,
+		SessionID: call.SessionID,
+		Prompt:    call.Prompt,
+	})
+	if result.Blocked {
+		return "", &hooks.BlockedError{Event: config.HookUserPromptSubmit, Reason: result.Reason}
+	}
+	return appendContext(sessionContext, result.Context), nil
+}
+
+// runStopHooks runs the Stop hooks once the agent finished its turn. If a hook
+// blocks, the agent keeps going with the hook's reason as the next prompt.
+func (a *sessionAgent) runStopHooks(ctx context.Context, call SessionAgentCall, result *fantasy.AgentResult) {
+	if a.isSubAgent || !a.hooks.Has(config.HookStop) {
+		return
+	}
+
+	var response string
+	if result != nil {
+		response = result.Response.Content.Text()
+	}
+	stop := a.hooks.Run(ctx, hooks.Input{
+		Event:          config.HookStop,
+		SessionID:      call.SessionID,
+		Response:       response,
+		StopHookActive: call.stopHookActive,
+	})
+	if !stop.Blocked {
+		return
+	}
+
+	blocked := &hooks.BlockedError{Event: config.HookStop, Reason: stop.Reason}
+	existing, _ := a.messageQueue.Get(call.SessionID)
+	a.messageQueue.Set(call.SessionID, append(existing, SessionAgentCall{
+		SessionID:       call.SessionID,
+		Prompt:          blocked.Error(),
+		ProviderOptions: call.ProviderOptions,
+		MaxOutputTokens: call.MaxOutputTokens,
+		stopHookActive:  true,
+	}))
+}
+
 func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
 	if a.IsSessionBusy(sessionID) {
 		return ErrSessionBusy
@@ -584,6 +792,8 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	if err != nil {
 		return err
 	}
+	summaryStream := a.messages.Stream(ctx, summaryMessage)
+	defer summaryStream.Close()
 
 	summaryPromptText := buildSummaryPrompt(currentSession.Todos)
 
@@ -591,6 +801,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		Prompt:          summaryPromptText,
 		Messages:        aiMsgs,
 		ProviderOptions: opts,
+		MaxRetries:      new(int),
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = options.Messages
 			if systemPromptPrefix != "" {
@@ -600,7 +811,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		},
 		OnReasoningDelta: func(id string, text string) error {
 			summaryMessage.AppendReasoningContent(text)
-			return a.messages.Update(genCtx, summaryMessage)
+			return summaryStream.Update(genCtx, summaryMessage)
 		},
 		OnReasoningEnd: func(id string, reasoning fantasy.ReasoningContent) error {
 			// Handle anthropic signature.
@@ -610,17 +821,19 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 				}
 			}
 			summaryMessage.FinishThinking()
-			return a.messages.Update(genCtx, summaryMessage)
+			return summaryStream.Flush(genCtx, summaryMessage)
 		},
 		OnTextDelta: func(id, text string) error {
 			summaryMessage.AppendContent(text)
-			return a.messages.Update(genCtx, summaryMessage)
+			return summaryStream.Update(genCtx, summaryMessage)
 		},
 	})
 	if err != nil {
 		isCancelErr := errors.Is(err, context.Canceled)
 		if isCancelErr {
 			// User cancelled summarize we need to remove the summary message.
+			// Stop the stream first so no buffered update outlives it.
+			_ = summaryStream.Close()
 			deleteErr := a.messages.Delete(ctx, summaryMessage.ID)
 			return deleteErr
 		}
@@ -628,7 +841,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	}
 
 	summaryMessage.AddFinish(message.FinishReasonEndTurn, "", "")
-	err = a.messages.Update(genCtx, summaryMessage)
+	err = summaryStream.Flush(genCtx, summaryMessage)
 	if err != nil {
 		return err
 	}
@@ -645,15 +858,17 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	spent := a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
 	currentSession.SummaryMessageID = summaryMessage.ID
 	currentSession.CompletionTokens = usage.OutputTokens
 	currentSession.PromptTokens = 0
-	_, err = a.sessions.Save(genCtx, currentSession)
-	return err
+	if _, err = a.sessions.Save(genCtx, currentSession); err != nil {
+		return err
+	}
+	return a.sessions.RecordUsage(genCtx, currentSession.ID, spent)
 }
 
 func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
@@ -670,8 +885,8 @@ func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
 	}
 }
 
-func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentCall) (message.Message, error) {
-	parts := []message.ContentPart{message.TextContent{Text: call.Prompt}}
+func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentCall, hookContext string) (message.Message, error) {
+	parts := []message.ContentPart{message.TextContent{Text: call.Prompt, HookContext: hookContext}}
 	var attachmentParts []message.ContentPart
 	for _, attachment := range call.Attachments {
 		attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
@@ -684,6 +899,12 @@ func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentC
 	if err != nil {
 		return message.Message{}, fmt.Errorf("failed to create user message: %w", err)
 	}
+	// Checkpoint the session's files so they can be rewound to this message.
+	if a.history != nil && !a.isSubAgent {
+		if _, err := a.history.CreateCheckpoint(ctx, call.SessionID, msg.ID); err != nil {
+			slog.Error("Failed to create checkpoint", "session_id", call.SessionID, "message_id", msg.ID, "error", err)
+		}
+	}
 	return msg, nil
 }
 
@@ -770,7 +991,8 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 	}
 
 	streamCall := fantasy.AgentStreamCall{
-		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", userPrompt),
+		Prompt:     fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", userPrompt),
+		MaxRetries: new(int),
 		PrepareStep: func(callCtx context.Context, opts fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = opts.Messages
 			if systemPromptPrefix != "" {
@@ -869,6 +1091,23 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 	}
 }
 
+// dropFailedAssistant deletes the last message if it's an assistant message
+// that failed without calling any tools, so a resumed turn starts over from
+// the request that failed.
+func (a *sessionAgent) dropFailedAssistant(ctx context.Context, msgs []message.Message) ([]message.Message, error) {
+	if len(msgs) == 0 {
+		return msgs, nil
+	}
+	last := msgs[len(msgs)-1]
+	if last.Role != message.Assistant || last.FinishReason() != message.FinishReasonError || len(last.ToolCalls()) > 0 {
+		return msgs, nil
+	}
+	if err := a.messages.Delete(ctx, last.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete failed message: %w", err)
+	}
+	return msgs[:len(msgs)-1], nil
+}
+
 func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float64 {
 	openrouterMetadata, ok := metadata[openrouter.Name]
 	if !ok {
@@ -882,23 +1121,31 @@ func (a *sessionAgent) openrouterCost(metadata fantasy.ProviderMetadata) *float6
 	return &opts.Usage.Cost
 }
 
-func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session, usage fantasy.Usage, overrideCost *float64) {
-	modelConfig := model.CatwalkCfg
-	cost := modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
-		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
+// updateSessionUsage adds the cost of the usage to the session and sets its
+// tokens to the size of the context. It returns what was spent, to be
+// recorded for the budgets.
+func (a *sessionAgent) updateSessionUsage(model Model, sess *session.Session, usage fantasy.Usage, overrideCost *float64) session.Usage {
+	cost := usageCost(model, usage)
 
-	a.eventTokensUsed(session.ID, model, usage, cost)
+	a.eventTokensUsed(sess.ID, model, usage, cost)
 
 	if overrideCost != nil {
-		session.Cost += *overrideCost
-	} else {
-		session.Cost += cost
+		cost = *overrideCost
 	}
+	sess.Cost += cost
+
+	sess.CompletionTokens = usage.OutputTokens
+	sess.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+	return session.Usage{Cost: cost, Tokens: sess.PromptTokens + sess.CompletionTokens}
+}
 
-	session.CompletionTokens = usage.OutputTokens
-	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+// usageCost returns what the usage costs with the model.
+func usageCost(model Model, usage fantasy.Usage) float64 {
+	modelConfig := model.CatwalkCfg
+	return modelConfig.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+		modelConfig.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
+		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
+		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -1002,6 +1249,10 @@ func (a *sessionAgent) Model() Model {
 	return a.largeModel.Get()
 }
 
+func (a *sessionAgent) SmallModel() Model {
+	return a.smallModel.Get()
+}
+
 // convertToToolResult converts a fantasy tool result to a message tool result.
 func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) message.ToolResult {
 	baseResult := message.ToolResult{
@@ -7,12 +7,16 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"charm.land/fantasy"
 	"charm.land/x/vcr"
 	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/hooks"
 	"github.com/charmbracelet/brush/internal/message"
 	"github.com/charmbracelet/brush/internal/session"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -618,6 +622,50 @@ func TestCoderAgent(t *testing.T) {
 	}
 }
 
+func TestRunPromptHooks(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	runner, err := hooks.NewRunner(env.workingDir, config.Hooks{
+		config.HookUserPromptSubmit: {{Command: "sleep 0.5; echo remember the milk"}},
+	})
+	require.NoError(t, err)
+	large := &flakyModel{}
+	agent := NewSessionAgent(SessionAgentOptions{
+		LargeModel: Model{Model: large, CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000}},
+		SmallModel: Model{Model: &flakyModel{}, CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000}},
+		Sessions:   env.sessions,
+		Messages:   env.messages,
+		History:    env.history,
+		Hooks:      runner,
+	})
+	sess, err := env.sessions.Create(t.Context(), "Test")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "first", MaxOutputTokens: 100})
+		done <- err
+	}()
+	// The session is busy while the hooks run, so another prompt is queued.
+	require.Eventually(t, func() bool { return agent.IsSessionBusy(sess.ID) }, 400*time.Millisecond, 10*time.Millisecond)
+	require.NoError(t, <-done)
+
+	// The hook context of the first prompt is sent again with the next one.
+	_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "second", MaxOutputTokens: 100})
+	require.NoError(t, err)
+	var texts []string
+	for _, msg := range large.prompt {
+		for _, part := range msg.Content {
+			if text, ok := part.(fantasy.TextPart); ok && msg.Role == fantasy.MessageRoleUser {
+				texts = append(texts, text.Text)
+			}
+		}
+	}
+	require.Contains(t, texts, "first\n<hook_context>\nremember the milk\n</hook_context>\n")
+	require.Contains(t, texts, "second\n<hook_context>\nremember the milk\n</hook_context>\n")
+}
+
 func makeTestTodos(n int) []session.Todo {
 	todos := make([]session.Todo, n)
 	for i := range n {
@@ -1,15 +1,21 @@
 package agent
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 
 	"charm.land/fantasy"
 
 	"github.com/charmbracelet/brush/internal/agent/prompt"
 	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/budget"
 	"github.com/charmbracelet/brush/internal/config"
 )
 
@@ -18,6 +24,7 @@ var agentToolDescription []byte
 
 type AgentParams struct {
 	Prompt string `json:"prompt" description:"The task for the agent to perform"`
+	Agent  string `json:"agent,omitempty" description:"The name of the agent to delegate the task to, defaults to task"`
 }
 
 const (
@@ -25,26 +32,22 @@ const (
 )
 
 func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error) {
-	agentCfg, ok := c.cfg.Agents[config.AgentTask]
-	if !ok {
-		return nil, errors.New("task agent not configured")
-	}
-	prompt, err := taskPrompt(c.cfg.Options.TemplatesDir, prompt.WithWorkingDir(c.cfg.WorkingDir()))
-	if err != nil {
-		return nil, err
-	}
-
-	agent, err := c.buildAgent(ctx, prompt, agentCfg, true)
+	agents, err := c.buildSubAgents(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return fantasy.NewParallelAgentTool(
 		AgentToolName,
-		string(agentToolDescription),
+		c.agentToolDescription(agents),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Prompt == "" {
 				return fantasy.NewTextErrorResponse("prompt is required"), nil
 			}
+			name := cmp.Or(params.Agent, config.AgentTask)
+			agent, ok := agents[name]
+			if !ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown agent %q, available agents: %s", name, strings.Join(slices.Sorted(maps.Keys(agents)), ", "))), nil
+			}
 
 			sessionID := tools.GetSessionFromContext(ctx)
 			if sessionID == "" {
@@ -82,6 +85,12 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  model.ModelCfg.PresencePenalty,
 			})
+			// Tell the agent which budget stopped the sub-agent, as its own
+			// turn stops on it too.
+			var exceeded *budget.ExceededError
+			if errors.As(err, &exceeded) {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
 			if err != nil {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
 			}
@@ -103,3 +112,60 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
 		}), nil
 }
+
+// buildSubAgents builds the agents the agent tool can delegate to: the task
+// agent and the custom agents, keyed by their ID. Custom agents that fail to
+// build are left out.
+func (c *coordinator) buildSubAgents(ctx context.Context) (map[string]SessionAgent, error) {
+	if _, ok := c.cfg.Agents[config.AgentTask]; !ok {
+		return nil, errors.New("task agent not configured")
+	}
+	agents := make(map[string]SessionAgent, len(c.cfg.Agents))
+	for id, agentCfg := range c.cfg.Agents {
+		if id == config.AgentCoder {
+			continue
+		}
+		opts := []prompt.Option{prompt.WithWorkingDir(c.cfg.WorkingDir())}
+		var p *prompt.Prompt
+		var err error
+		if id == config.AgentTask {
+			p, err = taskPrompt(c.cfg.Options.TemplatesDir, opts...)
+		} else {
+			opts = append(opts, prompt.WithContextPaths(agentCfg.ContextPaths))
+			p, err = agentPrompt(c.cfg.Options.TemplatesDir, agentCfg, opts...)
+		}
+		var agent SessionAgent
+		if err == nil {
+			agent, err = c.buildAgent(ctx, p, agentCfg, true)
+		}
+		if err != nil {
+			if id == config.AgentTask {
+				return nil, fmt.Errorf("building agent %s: %w", id, err)
+			}
+			slog.Error("Failed to build agent, leaving it out", "agent", id, "error", err)
+			continue
+		}
+		agents[id] = agent
+	}
+	return agents, nil
+}
+
+// agentToolDescription returns the description of the agent tool, listing
+// the custom agents it can delegate to.
+func (c *coordinator) agentToolDescription(agents map[string]SessionAgent) string {
+	var sb strings.Builder
+	sb.Write(agentToolDescription)
+	ids := slices.Sorted(maps.Keys(agents))
+	ids = slices.DeleteFunc(ids, func(id string) bool {
+		return id == config.AgentTask
+	})
+	if len(ids) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n<agents>\nSet `agent` to one of these names to delegate the task to a specialized agent instead of the default task agent:\n")
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "- %s: %s\n", id, c.cfg.Agents[id].Description)
+	}
+	sb.WriteString("</agents>\n")
+	return sb.String()
+}
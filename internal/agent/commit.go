@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/git"
+)
+
+//go:embed templates/commit.md
+var commitPrompt []byte
+
+// defaultBranchPrefix is the prefix of the scratch branches of the branch
+// auto-commit mode.
+const defaultBranchPrefix = "brush/"
+
+// DraftCommitMessage implements Coordinator.
+func (c *coordinator) DraftCommitMessage(ctx context.Context, diff, intent string) (string, error) {
+	_, small, err := c.buildAgentModels(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	if intent != "" {
+		fmt.Fprintf(&prompt, "The user asked for:\n\n%s\n\n", intent)
+	}
+	fmt.Fprintf(&prompt, "The diff:\n\n%s", diff)
+
+	maxOutputTokens := int64(300)
+	if small.CatwalkCfg.CanReason {
+		maxOutputTokens = small.CatwalkCfg.DefaultMaxTokens
+	}
+	resp, err := small.Model.Generate(ctx, fantasy.Call{
+		Prompt: fantasy.Prompt{
+			fantasy.NewSystemMessage(string(commitPrompt) + "\n /no_think"),
+			fantasy.NewUserMessage(prompt.String()),
+		},
+		MaxOutputTokens: &maxOutputTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to draft commit message: %w", err)
+	}
+
+	message := thinkTagRegex.ReplaceAllString(resp.Content.Text(), "")
+	message = strings.Trim(strings.TrimSpace(message), "`")
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "", errors.New("failed to draft commit message: empty response")
+	}
+	return message + commitTrailers(c.cfg.Options.Attribution, c.currentAgent.Model().CatwalkCfg.Name), nil
+}
+
+// commitTrailers returns the attribution added to the end of commit
+// messages, in the style of the commits the bash tool makes.
+func commitTrailers(attribution *config.Attribution, modelName string) string {
+	if attribution == nil {
+		return ""
+	}
+	var trailers strings.Builder
+	if attribution.GeneratedWith {
+		trailers.WriteString("\n\n💘 Generated with Crush")
+	}
+	switch attribution.TrailerStyle {
+	case config.TrailerStyleAssistedBy:
+		fmt.Fprintf(&trailers, "\n\nAssisted-by: %s via Crush <crush@charm.land>", modelName)
+	case config.TrailerStyleCoAuthoredBy:
+		trailers.WriteString("\n\nCo-Authored-By: Crush <crush@charm.land>")
+	}
+	return trailers.String()
+}
+
+// autoCommitEnabled reports whether the changes of every turn are committed.
+func (c *coordinator) autoCommitEnabled() bool {
+	return c.cfg.Options.AutoCommit != nil && c.cfg.Options.AutoCommit.Enabled
+}
+
+// snapshotSession snapshots the work tree for the session's auto-commits.
+func (c *coordinator) snapshotSession(ctx context.Context, sessionID string) (*git.Repo, *git.Snapshot, error) {
+	autoCommit := c.cfg.Options.AutoCommit
+	dataDir := c.cfg.Options.DataDirectory
+	mode := cmp.Or(autoCommit.Mode, config.AutoCommitShadow)
+	index := filepath.Join(dataDir, "autocommit", fmt.Sprintf("%s-%s.index", mode, sessionID))
+
+	switch mode {
+	case config.AutoCommitBranch:
+		repo, err := git.Open(ctx, c.cfg.WorkingDir())
+		if err != nil {
+			return nil, nil, err
+		}
+		prefix := autoCommit.BranchPrefix
+		if prefix == "" {
+			prefix = defaultBranchPrefix
+		}
+		snapshot, err := repo.Snapshot(ctx, "refs/heads/"+prefix+sessionID, "HEAD", index)
+		return repo, snapshot, err
+	case config.AutoCommitShadow:
+		var exclude []string
+		if rel, err := filepath.Rel(c.cfg.WorkingDir(), dataDir); err == nil && !strings.HasPrefix(rel, "..") {
+			exclude = append(exclude, "/"+filepath.ToSlash(rel)+"/")
+		}
+		repo, err := git.OpenShadow(ctx, c.cfg.WorkingDir(), filepath.Join(dataDir, "autocommit", "shadow.git"), exclude...)
+		if err != nil {
+			return nil, nil, err
+		}
+		snapshot, err := repo.Snapshot(ctx, "refs/heads/"+sessionID, "", index)
+		return repo, snapshot, err
+	default:
+		return nil, nil, fmt.Errorf("invalid auto-commit mode %q", mode)
+	}
+}
+
+// autoCommitBefore commits the changes made since the last turn of the
+// session, or the initial state of the work tree, so the commit of the turn
+// only has the agent's changes.
+func (c *coordinator) autoCommitBefore(ctx context.Context, sessionID string) {
+	c.autoCommitMu.Lock()
+	defer c.autoCommitMu.Unlock()
+
+	repo, snapshot, err := c.snapshotSession(ctx, sessionID)
+	if err != nil {
+		slog.Warn("Failed to snapshot the work tree for auto-commit", "error", err)
+		return
+	}
+	if !repo.Changed(ctx, snapshot) {
+		return
+	}
+	message := "Changes made outside of the session"
+	if snapshot.New {
+		message = "Start of session " + sessionID
+	}
+	if _, err := repo.Commit(ctx, snapshot, message); err != nil {
+		slog.Warn("Failed to auto-commit", "error", err)
+	}
+}
+
+// autoCommitAfter commits the changes made during the turn with a message
+// made from the prompt, then has the small model draft a better one in the
+// background and rewords the commit with it.
+func (c *coordinator) autoCommitAfter(ctx context.Context, sessionID, prompt string) {
+	repo, ref, commit, diff := c.commitTurn(ctx, sessionID, prompt)
+	if commit == "" || diff == "" {
+		return
+	}
+	go c.rewordAutoCommit(ctx, repo, ref, commit, diff, prompt)
+}
+
+// commitTurn commits the changes made during the turn with the fallback
+// message. It returns the commit, if any, and the diff of the changes.
+func (c *coordinator) commitTurn(ctx context.Context, sessionID, prompt string) (repo *git.Repo, ref, commit, diff string) {
+	c.autoCommitMu.Lock()
+	defer c.autoCommitMu.Unlock()
+
+	repo, snapshot, err := c.snapshotSession(ctx, sessionID)
+	if err != nil {
+		slog.Warn("Failed to snapshot the work tree for auto-commit", "error", err)
+		return nil, "", "", ""
+	}
+	if !repo.Changed(ctx, snapshot) {
+		return nil, "", "", ""
+	}
+	if diff, err = repo.Diff(ctx, snapshot); err != nil {
+		slog.Warn("Failed to diff the changes of the turn", "error", err)
+	}
+
+	commit, err = repo.Commit(ctx, snapshot, fallbackCommitMessage(prompt))
+	if err != nil {
+		slog.Warn("Failed to auto-commit", "error", err)
+		return nil, "", "", ""
+	}
+	slog.Info("Auto-committed the changes of the turn", "session_id", sessionID, "ref", snapshot.Ref, "commit", commit)
+	return repo, snapshot.Ref, commit, diff
+}
+
+// rewordAutoCommit replaces the message of an auto-commit with one drafted
+// by the small model, unless another commit was made on top of it since.
+func (c *coordinator) rewordAutoCommit(ctx context.Context, repo *git.Repo, ref, commit, diff, prompt string) {
+	message, err := c.DraftCommitMessage(ctx, diff, prompt)
+	if err != nil {
+		slog.Warn("Failed to draft auto-commit message", "error", err)
+		return
+	}
+
+	c.autoCommitMu.Lock()
+	defer c.autoCommitMu.Unlock()
+	reworded, err := repo.Reword(ctx, ref, commit, message)
+	if err != nil {
+		slog.Debug("Kept the auto-commit message", "ref", ref, "commit", commit, "error", err)
+		return
+	}
+	slog.Debug("Reworded auto-commit", "ref", ref, "commit", reworded)
+}
+
+// fallbackCommitMessage returns a commit message made from the first line
+// of the prompt, for when the model can't draft one.
+func fallbackCommitMessage(prompt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if line == "" {
+		return "Changes made by the agent"
+	}
+	const maxLen = 72
+	if len(line) > maxLen {
+		line = strings.TrimSpace(line[:maxLen-3]) + "..."
+	}
+	return line
+}
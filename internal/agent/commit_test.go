@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitTrailers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		attribution *config.Attribution
+		want        string
+	}{
+		{"nil", nil, ""},
+		{"none", &config.Attribution{TrailerStyle: config.TrailerStyleNone}, ""},
+		{
+			"assisted-by",
+			&config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy},
+			"\n\nAssisted-by: Claude Sonnet via Crush <crush@charm.land>",
+		},
+		{
+			"co-authored-by with generated with",
+			&config.Attribution{TrailerStyle: config.TrailerStyleCoAuthoredBy, GeneratedWith: true},
+			"\n\n💘 Generated with Crush\n\nCo-Authored-By: Crush <crush@charm.land>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, commitTrailers(tt.attribution, "Claude Sonnet"))
+		})
+	}
+}
+
+func TestFallbackCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Changes made by the agent", fallbackCommitMessage("  "))
+	require.Equal(t, "Fix the login form", fallbackCommitMessage("Fix the login form\n\nIt crashes."))
+
+	long := fallbackCommitMessage(strings.Repeat("word ", 30))
+	require.LessOrEqual(t, len(long), 72)
+	require.True(t, strings.HasSuffix(long, "..."))
+}
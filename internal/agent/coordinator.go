@@ -14,20 +14,25 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/brush/internal/agent/hyper"
 	"github.com/charmbracelet/brush/internal/agent/prompt"
 	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/budget"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/csync"
 	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/hooks"
 	"github.com/charmbracelet/brush/internal/log"
 	"github.com/charmbracelet/brush/internal/lsp"
 	"github.com/charmbracelet/brush/internal/message"
 	"github.com/charmbracelet/brush/internal/oauth/copilot"
 	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
 	"github.com/charmbracelet/brush/internal/session"
 	"golang.org/x/sync/errgroup"
 
@@ -56,6 +61,13 @@ type Coordinator interface {
 	Summarize(context.Context, string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	Sample(ctx context.Context, mcpName string, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error)
+	// DraftCommitMessage drafts a commit message for the diff with the small
+	// model, followed by the configured attribution trailers.
+	DraftCommitMessage(ctx context.Context, diff, intent string) (string, error)
+	// SubscribeRetries returns a channel for the retries of provider
+	// requests.
+	SubscribeRetries(ctx context.Context) <-chan pubsub.Event[RetryEvent]
 }
 
 type coordinator struct {
@@ -65,11 +77,17 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	hooks       *hooks.Runner
+	budgets     *budget.Checker
+	retries     *pubsub.Broker[RetryEvent]
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
 
 	readyWg errgroup.Group
+
+	// autoCommitMu serializes the auto-commits of concurrent sessions.
+	autoCommitMu sync.Mutex
 }
 
 func NewCoordinator(
@@ -89,8 +107,16 @@ func NewCoordinator(
 		history:     history,
 		lspClients:  lspClients,
 		agents:      make(map[string]SessionAgent),
+		budgets:     budget.NewChecker(cfg, sessions),
+		retries:     pubsub.NewBroker[RetryEvent](),
 	}
 
+	hookRunner, err := hooks.NewRunner(cfg.WorkingDir(), cfg.Hooks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hooks: %w", err)
+	}
+	c.hooks = hookRunner
+
 	agentCfg, ok := cfg.Agents[config.AgentCoder]
 	if !ok {
 		return nil, errors.New("coder agent not configured")
@@ -117,26 +143,89 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, err
 	}
 
+	if err := c.checkBudgets(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
 	// refresh models before each run
 	if err := c.UpdateModels(ctx); err != nil {
 		return nil, fmt.Errorf("failed to update models: %w", err)
 	}
 
+	// Queued prompts run within the turn in progress, which commits them.
+	if c.autoCommitEnabled() && !c.IsSessionBusy(sessionID) {
+		c.autoCommitBefore(ctx, sessionID)
+		defer c.autoCommitAfter(context.WithoutCancel(ctx), sessionID, prompt)
+	}
+
 	model := c.currentAgent.Model()
-	maxTokens := model.CatwalkCfg.DefaultMaxTokens
+	fallbacks := model.ModelCfg.Fallbacks
+	call := SessionAgentCall{
+		SessionID:   sessionID,
+		Prompt:      prompt,
+		Attachments: attachments,
+	}
+	result, err := c.runModel(ctx, model, call)
+
+	// Continue the turn with the next fallback model while the provider
+	// can't answer, e.g. it's overloaded, out of quota or rejects the key.
+	for _, fallbackCfg := range fallbacks {
+		if !isFallbackError(err) || ctx.Err() != nil {
+			break
+		}
+		fallback, buildErr := c.buildModel(ctx, fallbackCfg, false)
+		if buildErr != nil {
+			slog.Warn("Skipping fallback model", "provider", fallbackCfg.Provider, "model", fallbackCfg.Model, "error", buildErr)
+			continue
+		}
+		slog.Warn("Model failed, switching to fallback",
+			"from", model.ModelCfg.Provider+"/"+model.ModelCfg.Model,
+			"to", fallbackCfg.Provider+"/"+fallbackCfg.Model,
+			"error", err,
+		)
+		model = fallback
+		call.model = &fallback
+		call.resume = true
+		call.Attachments = nil
+		result, err = c.runModel(ctx, fallback, call)
+	}
+	return result, err
+}
+
+// checkBudgets returns a *budget.ExceededError when a budget of the session
+// is used up, so no turn starts.
+func (c *coordinator) checkBudgets(ctx context.Context, sessionID string) error {
+	if !c.budgets.Enabled() {
+		return nil
+	}
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	statuses, err := c.budgets.Check(ctx, sess)
+	if err != nil {
+		return err
+	}
+	return budget.Exceeded(statuses)
+}
+
+// runModel runs the call with the given large model. If the provider rejects
+// the credentials, they are refreshed and the call is tried once more.
+func (c *coordinator) runModel(ctx context.Context, model Model, call SessionAgentCall) (*fantasy.AgentResult, error) {
+	call.MaxOutputTokens = model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
-		maxTokens = model.ModelCfg.MaxTokens
+		call.MaxOutputTokens = model.ModelCfg.MaxTokens
 	}
 
-	if !model.CatwalkCfg.SupportsImages && attachments != nil {
+	if !model.CatwalkCfg.SupportsImages && call.Attachments != nil {
 		// filter out image attachments
-		filteredAttachments := make([]message.Attachment, 0, len(attachments))
-		for _, att := range attachments {
+		filteredAttachments := make([]message.Attachment, 0, len(call.Attachments))
+		for _, att := range call.Attachments {
 			if att.IsText() {
 				filteredAttachments = append(filteredAttachments, att)
 			}
 		}
-		attachments = filteredAttachments
+		call.Attachments = filteredAttachments
 	}
 
 	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
@@ -144,7 +233,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, errors.New("model provider not configured")
 	}
 
-	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	call.ProviderOptions, call.Temperature, call.TopP, call.TopK, call.FrequencyPenalty, call.PresencePenalty = mergeCallOptions(model, providerCfg)
 
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
 		slog.Info("Token needs to be refreshed", "provider", providerCfg.ID)
@@ -154,18 +243,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
-			SessionID:        sessionID,
-			Prompt:           prompt,
-			Attachments:      attachments,
-			MaxOutputTokens:  maxTokens,
-			ProviderOptions:  mergedOptions,
-			Temperature:      temp,
-			TopP:             topP,
-			TopK:             topK,
-			FrequencyPenalty: freqPenalty,
-			PresencePenalty:  presPenalty,
-		})
+		return c.currentAgent.Run(ctx, call)
 	}
 	result, originalErr := run()
 
@@ -326,19 +404,29 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	if err != nil {
 		return nil, err
 	}
+	// Agents can use the small model or a specific one instead of the large
+	// model.
+	if modelCfg, ok := c.cfg.AgentModel(agent); ok && (modelCfg.Provider != large.ModelCfg.Provider || modelCfg.Model != large.ModelCfg.Model) {
+		large, err = c.buildModel(ctx, modelCfg, isSubAgent)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
-		large,
-		small,
-		largeProviderCfg.SystemPromptPrefix,
-		"",
-		isSubAgent,
-		c.cfg.Options.DisableAutoSummarize,
-		c.permissions.SkipRequests(),
-		c.sessions,
-		c.messages,
-		nil,
+		LargeModel:           large,
+		SmallModel:           small,
+		SystemPromptPrefix:   largeProviderCfg.SystemPromptPrefix,
+		IsSubAgent:           isSubAgent,
+		DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+		IsYolo:               c.permissions.SkipRequests(),
+		Sessions:             c.sessions,
+		Messages:             c.messages,
+		History:              c.history,
+		Hooks:                c.hooks,
+		Budgets:              c.budgets,
+		Retries:              c.retries,
 	})
 
 	c.readyWg.Go(func() error {
@@ -389,7 +477,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	}
 
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, c.cfg.Tools.Bash, modelName),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
 		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
@@ -406,7 +494,19 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients), tools.NewLSPRestartTool(c.lspClients))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspClients),
+			tools.NewReferencesTool(c.lspClients),
+			tools.NewLSPRestartTool(c.lspClients),
+			tools.NewDefinitionTool(c.lspClients, c.cfg.WorkingDir()),
+			tools.NewImplementationTool(c.lspClients, c.cfg.WorkingDir()),
+			tools.NewHoverTool(c.lspClients, c.cfg.WorkingDir()),
+			tools.NewDocumentSymbolsTool(c.lspClients, c.cfg.WorkingDir()),
+			tools.NewWorkspaceSymbolsTool(c.lspClients),
+			tools.NewCallHierarchyTool(c.lspClients, c.cfg.WorkingDir()),
+			tools.NewRenameTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+			tools.NewCodeActionTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		)
 	}
 
 	var filteredTools []fantasy.AgentTool
@@ -416,6 +516,20 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	// Agents can read the resources of the MCPs they are allowed to use.
+	if agent.AllowedMCP == nil || len(agent.AllowedMCP) > 0 {
+		var allowed func(string) bool
+		if agent.AllowedMCP != nil {
+			allowed = func(server string) bool {
+				_, ok := agent.AllowedMCP[server]
+				return ok
+			}
+		}
+		if tools.HasMCPResources(allowed) {
+			filteredTools = append(filteredTools, tools.NewReadMCPResourceTool(allowed))
+		}
+	}
+
 	for _, tool := range tools.GetMCPTools(c.permissions, c.cfg.WorkingDir()) {
 		if agent.AllowedMCP == nil {
 			// No MCP restrictions
@@ -441,9 +555,55 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
+	for i, tool := range filteredTools {
+		filteredTools[i] = withHooks(withPolicyDenials(tool), c.hooks)
+	}
 	return filteredTools, nil
 }
 
+// buildModel builds a single model from its selection, e.g. a fallback of the
+// large model.
+func (c *coordinator) buildModel(ctx context.Context, modelCfg config.SelectedModel, isSubAgent bool) (Model, error) {
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+	if !ok {
+		return Model{}, fmt.Errorf("provider %q not configured", modelCfg.Provider)
+	}
+	if providerCfg.Disable {
+		return Model{}, fmt.Errorf("provider %q is disabled", modelCfg.Provider)
+	}
+
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+			break
+		}
+	}
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("model %q not found in provider %q", modelCfg.Model, modelCfg.Provider)
+	}
+
+	provider, err := c.buildProvider(providerCfg, modelCfg, isSubAgent)
+	if err != nil {
+		return Model{}, err
+	}
+
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
+	}
+	languageModel, err := provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return Model{}, err
+	}
+
+	return Model{
+		Model:      newRetryModel(languageModel, providerCfg.Retry),
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+	}, nil
+}
+
 // TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
 func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Model, Model, error) {
 	largeModelCfg, ok := c.cfg.Models[config.SelectedModelTypeLarge]
@@ -517,6 +677,9 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, err
 	}
 
+	largeModel = newRetryModel(largeModel, largeProviderCfg.Retry)
+	smallModel = newRetryModel(smallModel, smallProviderCfg.Retry)
+
 	return Model{
 			Model:      largeModel,
 			CatwalkCfg: *largeCatwalkModel,
@@ -790,6 +953,10 @@ func (c *coordinator) ClearQueue(sessionID string) {
 	c.currentAgent.ClearQueue(sessionID)
 }
 
+func (c *coordinator) SubscribeRetries(ctx context.Context) <-chan pubsub.Event[RetryEvent] {
+	return c.retries.Subscribe(ctx)
+}
+
 func (c *coordinator) IsBusy() bool {
 	return c.currentAgent.IsBusy()
 }
@@ -844,6 +1011,31 @@ func (c *coordinator) isUnauthorized(err error) bool {
 	return errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusUnauthorized
 }
 
+// isFallbackError reports whether err means the provider can't answer right
+// now, so a fallback model should take over.
+func isFallbackError(err error) bool {
+	if errors.Is(err, hyper.ErrNoCredits) {
+		return true
+	}
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	switch providerErr.StatusCode {
+	case http.StatusUnauthorized,
+		http.StatusPaymentRequired,
+		http.StatusForbidden,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		529: // Anthropic's "overloaded"
+		return true
+	}
+	return false
+}
+
 func (c *coordinator) refreshOAuth2Token(ctx context.Context, providerCfg config.ProviderConfig) error {
 	if err := c.cfg.RefreshOAuthToken(ctx, providerCfg.ID); err != nil {
 		slog.Error("Failed to refresh OAuth token after 401 error", "provider", providerCfg.ID, "error", err)
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/agent/hyper"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsFallbackError(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{&fantasy.ProviderError{StatusCode: 529}, true},
+		{&fantasy.ProviderError{StatusCode: 429}, true},
+		{&fantasy.ProviderError{StatusCode: 401}, true},
+		{fmt.Errorf("step: %w", &fantasy.RetryError{Errors: []error{&fantasy.ProviderError{StatusCode: 503}}}), true},
+		{hyper.ErrNoCredits, true},
+		{&fantasy.ProviderError{StatusCode: 400}, false},
+		{context.Canceled, false},
+		{errors.New("boom"), false},
+		{nil, false},
+	} {
+		require.Equal(t, tt.want, isFallbackError(tt.err), "%v", tt.err)
+	}
+}
+
+func TestRunResumeWithFallback(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	overloaded := &flakyModel{failures: 1, err: &fantasy.ProviderError{Message: "overloaded", StatusCode: 529}}
+	agent := testSessionAgent(env, overloaded, &flakyModel{}, "You are a test.")
+	sess, err := env.sessions.Create(t.Context(), "Test")
+	require.NoError(t, err)
+
+	call := SessionAgentCall{SessionID: sess.ID, Prompt: "Say hello", MaxOutputTokens: 100}
+	_, err = agent.Run(t.Context(), call)
+	require.True(t, isFallbackError(err), "%v", err)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.FinishReasonError, msgs[1].FinishReason())
+
+	fallback := &flakyModel{}
+	call.model = &Model{
+		Model:      fallback,
+		CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000},
+		ModelCfg:   config.SelectedModel{Provider: "test", Model: "fallback"},
+	}
+	call.resume = true
+	_, err = agent.Run(t.Context(), call)
+	require.NoError(t, err)
+
+	// The failed answer is replaced and the prompt isn't sent twice.
+	msgs, err = env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.User, msgs[0].Role)
+	require.Equal(t, "fallback", msgs[1].Model)
+	require.Equal(t, "hello world", msgs[1].Content().Text)
+
+	var prompts int
+	for _, msg := range fallback.prompt {
+		for _, part := range msg.Content {
+			if text, ok := part.(fantasy.TextPart); ok && text.Text == call.Prompt {
+				prompts++
+			}
+		}
+	}
+	require.Equal(t, 1, prompts)
+	require.Equal(t, fantasy.MessageRoleUser, fallback.prompt[len(fallback.prompt)-1].Role)
+}
+
+func TestAgentPromptInvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	_, err := agentPrompt("", config.Agent{ID: "broken", Prompt: "Review {{ .WorkingDir"})
+	require.ErrorContains(t, err, "parsing template")
+
+	p, err := agentPrompt("", config.Agent{ID: "reviewer", Prompt: "Review {{ .WorkingDir }}."})
+	require.NoError(t, err)
+	require.Equal(t, "reviewer", p.Name())
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/hooks"
+)
+
+// hookTool runs the PreToolUse and PostToolUse hooks around a tool call.
+type hookTool struct {
+	fantasy.AgentTool
+	hooks *hooks.Runner
+}
+
+func withHooks(tool fantasy.AgentTool, runner *hooks.Runner) fantasy.AgentTool {
+	if !runner.Has(config.HookPreToolUse) && !runner.Has(config.HookPostToolUse) {
+		return tool
+	}
+	return &hookTool{AgentTool: tool, hooks: runner}
+}
+
+func (t *hookTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	sessionID := tools.GetSessionFromContext(ctx)
+
+	pre := t.hooks.Run(ctx, hooks.Input{
+		Event:     config.HookPreToolUse,
+		SessionID: sessionID,
+		ToolCall:  hookToolCall(call),
+	})
+	if pre.Blocked {
+		blocked := &hooks.BlockedError{Event: config.HookPreToolUse, Reason: pre.Reason}
+		return fantasy.NewTextErrorResponse(blocked.Error()), nil
+	}
+	if pre.Input != "" {
+		call.Input = pre.Input
+	}
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil {
+		return resp, err
+	}
+
+	post := t.hooks.Run(ctx, hooks.Input{
+		Event:     config.HookPostToolUse,
+		SessionID: sessionID,
+		ToolCall:  hookToolCall(call),
+		ToolResponse: &hooks.ToolResponse{
+			Content: resp.Content,
+			IsError: resp.IsError,
+		},
+	})
+	if post.Blocked {
+		blocked := &hooks.BlockedError{Event: config.HookPostToolUse, Reason: post.Reason}
+		resp.Content = appendContext(resp.Content, blocked.Error())
+		resp.IsError = true
+	}
+	resp.Content = appendContext(resp.Content, pre.Context, post.Context)
+	return resp, nil
+}
+
+func hookToolCall(call fantasy.ToolCall) *hooks.ToolCall {
+	input := json.RawMessage(call.Input)
+	if !json.Valid(input) {
+		input = json.RawMessage("{}")
+	}
+	return &hooks.ToolCall{
+		ID:    call.ID,
+		Name:  call.Name,
+		Input: input,
+	}
+}
+
+// appendContext appends the non-empty extra strings to content, separated by
+// blank lines.
+func appendContext(content string, extra ...string) string {
+	for _, e := range extra {
+		if e == "" {
+			continue
+		}
+		if content != "" {
+			content += "\n\n"
+		}
+		content += e
+	}
+	return content
+}
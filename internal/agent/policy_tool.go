@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/permission"
+)
+
+// policyTool turns permission policy denials into tool errors so the model
+// gets the rule's reason back and can keep going, instead of the whole run
+// being stopped like a user denial does.
+type policyTool struct {
+	fantasy.AgentTool
+}
+
+func withPolicyDenials(tool fantasy.AgentTool) fantasy.AgentTool {
+	return &policyTool{AgentTool: tool}
+}
+
+func (t *policyTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.AgentTool.Run(ctx, call)
+	var denied *permission.DeniedError
+	if errors.As(err, &denied) {
+		return fantasy.NewTextErrorResponse(denied.Error()), nil
+	}
+	return resp, err
+}
@@ -19,11 +19,12 @@ import (
 
 // Prompt represents a template-based prompt generator.
 type Prompt struct {
-	name       string
-	template   string
-	now        func() time.Time
-	platform   string
-	workingDir string
+	name         string
+	template     *template.Template
+	now          func() time.Time
+	platform     string
+	workingDir   string
+	contextPaths []string
 }
 
 type PromptDat struct {
@@ -64,10 +65,24 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithContextPaths overrides the context paths of the config, e.g. with the
+// ones of an agent.
+func WithContextPaths(paths []string) Option {
+	return func(p *Prompt) {
+		p.contextPaths = paths
+	}
+}
+
+// NewPrompt returns a prompt for the template, or an error if the template
+// doesn't parse.
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
+	t, err := template.New(name).Parse(promptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
 	p := &Prompt{
 		name:     name,
-		template: promptTemplate,
+		template: t,
 		now:      time.Now,
 	}
 	for _, opt := range opts {
@@ -77,16 +92,12 @@ func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 }
 
 func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.Config) (string, error) {
-	t, err := template.New(p.name).Parse(p.template)
-	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
-	}
 	var sb strings.Builder
 	d, err := p.promptData(ctx, provider, model, cfg)
 	if err != nil {
 		return "", err
 	}
-	if err := t.Execute(&sb, d); err != nil {
+	if err := p.template.Execute(&sb, d); err != nil {
 		return "", fmt.Errorf("executing template: %w", err)
 	}
 
@@ -154,7 +165,11 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 
 	files := map[string][]ContextFile{}
 
-	for _, pth := range cfg.Options.ContextPaths {
+	contextPaths := cfg.Options.ContextPaths
+	if p.contextPaths != nil {
+		contextPaths = p.contextPaths
+	}
+	for _, pth := range contextPaths {
 		expanded := expandPath(pth, cfg)
 		pathKey := strings.ToLower(expanded)
 		if _, ok := files[pathKey]; ok {
@@ -20,6 +20,9 @@ var taskPromptTmpl []byte
 //go:embed templates/initialize.md.tpl
 var initializePromptTmpl []byte
 
+//go:embed templates/agent_env.md.tpl
+var agentEnvTmpl []byte
+
 // loadTemplate tries to load a template from the custom templates directory.
 // If not found, it returns the embedded template as a fallback.
 func loadTemplate(customDir, templateName string, embedded []byte) ([]byte, error) {
@@ -67,6 +70,15 @@ func taskPrompt(customDir string, opts ...prompt.Option) (*prompt.Prompt, error)
 	return systemPrompt, nil
 }
 
+// agentPrompt returns the prompt of a custom agent: its own template followed
+// by the environment and its context files, or the task prompt if it has none.
+func agentPrompt(customDir string, agent config.Agent, opts ...prompt.Option) (*prompt.Prompt, error) {
+	if agent.Prompt == "" {
+		return taskPrompt(customDir, opts...)
+	}
+	return prompt.NewPrompt(agent.ID, agent.Prompt+"\n\n"+string(agentEnvTmpl), opts...)
+}
+
 func InitializePrompt(cfg config.Config, customDir string) (string, error) {
 	tmpl, err := loadTemplate(customDir, "initialize.md.tpl", initializePromptTmpl)
 	if err != nil {
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/config"
+)
+
+// Defaults used when a provider doesn't configure its retry policy.
+const (
+	defaultRetryMaxAttempts   = 4
+	defaultRetryInitialDelay  = 2 * time.Second
+	defaultRetryMaxDelay      = 60 * time.Second
+	defaultRetryBackoffFactor = 2.0
+)
+
+// defaultRetryStatusCodes are the HTTP status codes retried by default:
+// timeouts, conflicts, rate limits, server errors and Anthropic's
+// "overloaded" 529.
+var defaultRetryStatusCodes = []int{408, 409, 429, 500, 502, 503, 504, 529}
+
+// RetryEvent is published when a provider request failed and is about to be
+// retried.
+type RetryEvent struct {
+	SessionID   string
+	MessageID   string
+	Provider    string
+	Model       string
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+	StatusCode  int
+	Error       string
+}
+
+type retryNotifierKey struct{}
+
+// withRetryNotifier returns a context that makes retry models call fn before
+// waiting to retry a request.
+func withRetryNotifier(ctx context.Context, fn func(RetryEvent)) context.Context {
+	return context.WithValue(ctx, retryNotifierKey{}, fn)
+}
+
+func notifyRetry(ctx context.Context, event RetryEvent) {
+	if fn, ok := ctx.Value(retryNotifierKey{}).(func(RetryEvent)); ok {
+		fn(event)
+	}
+}
+
+// retryPolicy is a provider's [config.RetryConfig] with defaults applied.
+type retryPolicy struct {
+	maxAttempts   int
+	initialDelay  time.Duration
+	maxDelay      time.Duration
+	backoffFactor float64
+	statusCodes   []int
+}
+
+func newRetryPolicy(cfg *config.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		maxAttempts:   defaultRetryMaxAttempts,
+		initialDelay:  defaultRetryInitialDelay,
+		maxDelay:      defaultRetryMaxDelay,
+		backoffFactor: defaultRetryBackoffFactor,
+		statusCodes:   defaultRetryStatusCodes,
+	}
+	if cfg == nil {
+		return p
+	}
+	if cfg.MaxAttempts > 0 {
+		p.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialDelay > 0 {
+		p.initialDelay = time.Duration(cfg.InitialDelay * float64(time.Second))
+	}
+	if cfg.MaxDelay > 0 {
+		p.maxDelay = time.Duration(cfg.MaxDelay * float64(time.Second))
+	}
+	if cfg.BackoffFactor >= 1 {
+		p.backoffFactor = cfg.BackoffFactor
+	}
+	if len(cfg.StatusCodes) > 0 {
+		p.statusCodes = cfg.StatusCodes
+	}
+	return p
+}
+
+// retryable reports whether err should be retried, returning the provider
+// error if so.
+func (p retryPolicy) retryable(err error) (*fantasy.ProviderError, bool) {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return nil, false
+	}
+	return providerErr, slices.Contains(p.statusCodes, providerErr.StatusCode)
+}
+
+// delay returns how long to wait before the given retry (1-based). The
+// provider's retry-after headers win if they are within the maximum delay.
+func (p retryPolicy) delay(err *fantasy.ProviderError, retry int) time.Duration {
+	if d := retryAfter(err.ResponseHeaders); d > 0 && d <= p.maxDelay {
+		return d
+	}
+	d := float64(p.initialDelay) * math.Pow(p.backoffFactor, float64(retry-1))
+	return time.Duration(min(d, float64(p.maxDelay)))
+}
+
+func retryAfter(headers map[string]string) time.Duration {
+	if ms, err := strconv.ParseFloat(headers["retry-after-ms"], 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value, ok := headers["retry-after"]
+	if !ok {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := time.Parse(time.RFC1123, value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// retryModel retries streaming requests that fail before producing any
+// output, following the provider's retry policy. Failures after output was
+// streamed are returned as is, since the output can't be taken back.
+type retryModel struct {
+	fantasy.LanguageModel
+	policy retryPolicy
+}
+
+func newRetryModel(model fantasy.LanguageModel, cfg *config.RetryConfig) fantasy.LanguageModel {
+	return &retryModel{LanguageModel: model, policy: newRetryPolicy(cfg)}
+}
+
+func (m *retryModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := m.LanguageModel.Generate(ctx, call)
+		if err == nil {
+			return resp, nil
+		}
+		if waitErr := m.wait(ctx, err, attempt); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+func (m *retryModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	for attempt := 1; ; attempt++ {
+		stream, err := m.LanguageModel.Stream(ctx, call)
+		if err == nil {
+			var started fantasy.StreamResponse
+			started, err = startStream(stream)
+			if err == nil {
+				return started, nil
+			}
+		}
+		if waitErr := m.wait(ctx, err, attempt); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+// wait waits before the next attempt. It returns err if it shouldn't be
+// retried, or the context error if the context is done while waiting.
+func (m *retryModel) wait(ctx context.Context, err error, attempt int) error {
+	providerErr, ok := m.policy.retryable(err)
+	if !ok || attempt >= m.policy.maxAttempts {
+		return err
+	}
+	delay := m.policy.delay(providerErr, attempt)
+	notifyRetry(ctx, RetryEvent{
+		Provider:    m.Provider(),
+		Model:       m.Model(),
+		Attempt:     attempt + 1,
+		MaxAttempts: m.policy.maxAttempts,
+		Delay:       delay,
+		StatusCode:  providerErr.StatusCode,
+		Error:       providerErr.Error(),
+	})
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startStream reads the stream up to its first part that isn't a warning.
+// If that part is an error the stream is discarded and the error returned,
+// otherwise the returned stream replays what was read and continues.
+func startStream(stream fantasy.StreamResponse) (fantasy.StreamResponse, error) {
+	next, stop := iter.Pull(iter.Seq[fantasy.StreamPart](stream))
+	var head []fantasy.StreamPart
+	for {
+		part, ok := next()
+		if !ok {
+			break
+		}
+		if part.Type == fantasy.StreamPartTypeError && part.Error != nil {
+			stop()
+			return nil, part.Error
+		}
+		head = append(head, part)
+		if part.Type != fantasy.StreamPartTypeWarnings {
+			break
+		}
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		defer stop()
+		for _, part := range head {
+			if !yield(part) {
+				return
+			}
+		}
+		for {
+			part, ok := next()
+			if !ok || !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyModel fails its first `failures` streams with err as the
+// first stream part, the way providers report HTTP errors.
+type flakyModel struct {
+	fantasy.LanguageModel
+	failures int
+	err      error
+	calls    int
+	// prompt is the prompt of the last call.
+	prompt fantasy.Prompt
+}
+
+func (m *flakyModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls++
+	m.prompt = call.Prompt
+	fail := m.calls <= m.failures
+	return func(yield func(fantasy.StreamPart) bool) {
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeWarnings}) {
+			return
+		}
+		if fail {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: m.err})
+			return
+		}
+		for _, text := range []string{"hello", " world"} {
+			if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, Delta: text}) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (m *flakyModel) Provider() string { return "test" }
+func (m *flakyModel) Model() string    { return "flaky" }
+
+func streamText(t *testing.T, stream fantasy.StreamResponse) string {
+	t.Helper()
+	var text string
+	for part := range stream {
+		text += part.Delta
+	}
+	return text
+}
+
+func TestRetryModel(t *testing.T) {
+	t.Parallel()
+
+	overloaded := &fantasy.ProviderError{Message: "overloaded", StatusCode: 529}
+	cfg := &config.RetryConfig{MaxAttempts: 3, InitialDelay: 0.001}
+
+	t.Run("retries until success", func(t *testing.T) {
+		t.Parallel()
+		inner := &flakyModel{failures: 2, err: overloaded}
+		var events []RetryEvent
+		ctx := withRetryNotifier(t.Context(), func(e RetryEvent) { events = append(events, e) })
+
+		stream, err := newRetryModel(inner, cfg).Stream(ctx, fantasy.Call{})
+		require.NoError(t, err)
+		require.Equal(t, "hello world", streamText(t, stream))
+		require.Equal(t, 3, inner.calls)
+		require.Len(t, events, 2)
+		require.Equal(t, 2, events[0].Attempt)
+		require.Equal(t, 3, events[1].Attempt)
+		require.Equal(t, 3, events[1].MaxAttempts)
+		require.Equal(t, 529, events[1].StatusCode)
+		require.Equal(t, "flaky", events[1].Model)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		inner := &flakyModel{failures: 5, err: overloaded}
+		_, err := newRetryModel(inner, cfg).Stream(t.Context(), fantasy.Call{})
+		require.ErrorIs(t, err, overloaded)
+		require.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("does not retry other status codes", func(t *testing.T) {
+		t.Parallel()
+		unauthorized := &fantasy.ProviderError{Message: "unauthorized", StatusCode: 401}
+		inner := &flakyModel{failures: 1, err: unauthorized}
+		_, err := newRetryModel(inner, cfg).Stream(t.Context(), fantasy.Call{})
+		require.ErrorIs(t, err, unauthorized)
+		require.Equal(t, 1, inner.calls)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		t.Parallel()
+		inner := &flakyModel{failures: 1, err: errors.New("boom")}
+		_, err := newRetryModel(inner, cfg).Stream(t.Context(), fantasy.Call{})
+		require.EqualError(t, err, "boom")
+		require.Equal(t, 1, inner.calls)
+	})
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	p := newRetryPolicy(&config.RetryConfig{InitialDelay: 1, MaxDelay: 5, BackoffFactor: 3})
+	err := &fantasy.ProviderError{StatusCode: 429}
+	require.Equal(t, time.Second, p.delay(err, 1))
+	require.Equal(t, 3*time.Second, p.delay(err, 2))
+	require.Equal(t, 5*time.Second, p.delay(err, 3))
+
+	err.ResponseHeaders = map[string]string{"retry-after": "2"}
+	require.Equal(t, 2*time.Second, p.delay(err, 3))
+
+	// Longer than the maximum delay: fall back to the backoff.
+	err.ResponseHeaders = map[string]string{"retry-after": "30"}
+	require.Equal(t, time.Second, p.delay(err, 1))
+
+	defaults := newRetryPolicy(nil)
+	require.Equal(t, defaultRetryMaxAttempts, defaults.maxAttempts)
+	_, ok := defaults.retryable(&fantasy.ProviderError{StatusCode: 529})
+	require.True(t, ok)
+	_, ok = defaults.retryable(context.Canceled)
+	require.False(t, ok)
+}
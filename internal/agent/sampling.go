@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/session"
+)
+
+// Sample answers a sampling request of an MCP server with the small model,
+// once the user allows it.
+func (c *coordinator) Sample(ctx context.Context, mcpName string, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	prompt, transcript, err := samplingPrompt(params)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := tools.GetSessionFromContext(ctx)
+	granted, err := c.permissions.Request(ctx, permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        c.cfg.WorkingDir(),
+		ToolName:    "sampling",
+		Action:      "sample",
+		Description: fmt.Sprintf("%s wants to use the model", mcpName),
+		Params:      transcript,
+		MCPName:     mcpName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !granted {
+		return nil, permission.ErrorPermissionDenied
+	}
+
+	small := c.currentAgent.SmallModel()
+	call := fantasy.Call{Prompt: prompt}
+	if params.MaxTokens > 0 {
+		call.MaxOutputTokens = &params.MaxTokens
+	}
+	if params.Temperature > 0 {
+		call.Temperature = &params.Temperature
+	}
+	resp, err := small.Model.Generate(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	// Requests made outside a tool call have no session, but still count
+	// towards the daily and project budgets.
+	usage := session.Usage{
+		Cost:   usageCost(small, resp.Usage),
+		Tokens: resp.Usage.InputTokens + resp.Usage.CacheCreationTokens + resp.Usage.OutputTokens,
+	}
+	if err := c.sessions.RecordUsage(ctx, sessionID, usage); err != nil {
+		slog.Error("Failed to record sampling usage", "mcp", mcpName, "error", err)
+	}
+
+	stopReason := "endTurn"
+	if resp.FinishReason == fantasy.FinishReasonLength {
+		stopReason = "maxTokens"
+	}
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: resp.Content.Text()},
+		Model:      small.CatwalkCfg.ID,
+		Role:       "assistant",
+		StopReason: stopReason,
+	}, nil
+}
+
+// samplingPrompt converts the messages of a sampling request to a prompt, and
+// to a transcript to show the user.
+func samplingPrompt(params *mcp.CreateMessageParams) (fantasy.Prompt, string, error) {
+	var prompt fantasy.Prompt
+	var transcript strings.Builder
+	if params.SystemPrompt != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(params.SystemPrompt))
+		fmt.Fprintf(&transcript, "system: %s\n\n", params.SystemPrompt)
+	}
+	for _, msg := range params.Messages {
+		var part fantasy.MessagePart
+		switch content := msg.Content.(type) {
+		case *mcp.TextContent:
+			part = fantasy.TextPart{Text: content.Text}
+			fmt.Fprintf(&transcript, "%s: %s\n\n", msg.Role, content.Text)
+		case *mcp.ImageContent:
+			part = fantasy.FilePart{Data: content.Data, MediaType: content.MIMEType}
+			fmt.Fprintf(&transcript, "%s: [%s image]\n\n", msg.Role, content.MIMEType)
+		default:
+			return nil, "", fmt.Errorf("unsupported sampling content %T", msg.Content)
+		}
+		role := fantasy.MessageRoleUser
+		if msg.Role == "assistant" {
+			role = fantasy.MessageRoleAssistant
+		}
+		prompt = append(prompt, fantasy.Message{Role: role, Content: []fantasy.MessagePart{part}})
+	}
+	return prompt, strings.TrimSpace(transcript.String()), nil
+}
@@ -61,89 +61,19 @@ var bashDescriptionTpl = template.Must(
 
 type bashDescriptionData struct {
 	BannedCommands  string
+	AllowedCommands string
+	AskCommands     string
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
 }
 
-var bannedCommands = []string{
-	// Network/Download tools
-	"alias",
-	"aria2c",
-	"axel",
-	"chrome",
-	"curl",
-	"curlie",
-	"firefox",
-	"http-prompt",
-	"httpie",
-	"links",
-	"lynx",
-	"nc",
-	"safari",
-	"scp",
-	"ssh",
-	"telnet",
-	"w3m",
-	"wget",
-	"xh",
-
-	// System administration
-	"doas",
-	"su",
-	"sudo",
-
-	// Package managers
-	"apk",
-	"apt",
-	"apt-cache",
-	"apt-get",
-	"dnf",
-	"dpkg",
-	"emerge",
-	"home-manager",
-	"makepkg",
-	"opkg",
-	"pacman",
-	"paru",
-	"pkg",
-	"pkg_add",
-	"pkg_delete",
-	"portage",
-	"rpm",
-	"yay",
-	"yum",
-	"zypper",
-
-	// System modification
-	"at",
-	"batch",
-	"chkconfig",
-	"crontab",
-	"fdisk",
-	"mkfs",
-	"mount",
-	"parted",
-	"service",
-	"systemctl",
-	"umount",
-
-	// Network configuration
-	"firewall-cmd",
-	"ifconfig",
-	"ip",
-	"iptables",
-	"netstat",
-	"pfctl",
-	"route",
-	"ufw",
-}
-
-func bashDescription(attribution *config.Attribution, modelName string) string {
-	bannedCommandsStr := strings.Join(bannedCommands, ", ")
+func bashDescription(attribution *config.Attribution, commands config.ToolBash, modelName string) string {
 	var out bytes.Buffer
 	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
-		BannedCommands:  bannedCommandsStr,
+		BannedCommands:  strings.Join(commands.DeniedCommands(), ", "),
+		AllowedCommands: strings.Join(commands.AllowedCommands(), ", "),
+		AskCommands:     strings.Join(commands.AskCommands(), ", "),
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
@@ -154,14 +84,51 @@ func bashDescription(attribution *config.Attribution, modelName string) string {
 	return out.String()
 }
 
-func blockFuncs() []shell.BlockFunc {
-	return []shell.BlockFunc{}
+// commandList is a list of command patterns, like "git push --force".
+type commandList struct {
+	patterns []string
+	funcs    []shell.BlockFunc
+}
+
+// newCommandList returns the list of the patterns. A pattern doesn't match
+// commands that match one of its exceptions.
+func newCommandList(patterns []string, exceptions map[string][]string) commandList {
+	l := commandList{patterns: patterns}
+	for _, pattern := range patterns {
+		fn := shell.CommandPattern(pattern)
+		if except := exceptions[pattern]; len(except) > 0 {
+			fn = exceptFor(fn, except)
+		}
+		l.funcs = append(l.funcs, fn)
+	}
+	return l
+}
+
+func exceptFor(fn shell.BlockFunc, except []string) shell.BlockFunc {
+	excepted := newCommandList(except, nil)
+	return func(args []string) bool {
+		return fn(args) && excepted.match(args) == ""
+	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+// match returns the first pattern matching the command's arguments, or an
+// empty string.
+func (l commandList) match(args []string) string {
+	for i, fn := range l.funcs {
+		if fn(args) {
+			return l.patterns[i]
+		}
+	}
+	return ""
+}
+
+func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, commands config.ToolBash, modelName string) fantasy.AgentTool {
+	denied := newCommandList(commands.DeniedCommands(), commands.DeniedExceptions())
+	allowed := newCommandList(commands.AllowedCommands(), nil)
+	ask := newCommandList(commands.AskCommands(), nil)
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, commands, modelName)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
@@ -170,40 +137,51 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Determine working directory
 			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
 
-			isSafeReadOnly := false
-			cmdLower := strings.ToLower(params.Command)
-
-			for _, safe := range safeCommands {
-				if strings.HasPrefix(cmdLower, safe) {
-					if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
-						isSafeReadOnly = true
-						break
-					}
+			// Refuse denied commands before asking for permission, and only
+			// run commands without asking if every part of them is allowed.
+			parts, literal, err := shell.SimpleCommands(params.Command)
+			isSafeReadOnly := err == nil && literal && len(parts) > 0
+			alwaysAsk := false
+			for _, args := range parts {
+				if pattern := denied.match(args); pattern != "" {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("command is not allowed: %q matches the denied command %q", strings.Join(args, " "), pattern)), nil
+				}
+				if ask.match(args) != "" {
+					alwaysAsk = true
 				}
+				if allowed.match(args) == "" {
+					isSafeReadOnly = false
+				}
+			}
+			if alwaysAsk {
+				isSafeReadOnly = false
 			}
 
 			sessionID := GetSessionFromContext(ctx)
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
-			if !isSafeReadOnly {
-				p, err := permissions.Request(ctx,
-					permission.CreatePermissionRequest{
-						SessionID:   sessionID,
-						Path:        execWorkingDir,
-						ToolCallID:  call.ID,
-						ToolName:    BashToolName,
-						Action:      "execute",
-						Description: fmt.Sprintf("Execute command: %s", params.Command),
-						Params:      BashPermissionsParams(params),
-					},
-				)
-				if err != nil {
-					return fantasy.ToolResponse{}, err
-				}
-				if !p {
-					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
-				}
+			// Safe commands still go through the policy, whose rules may
+			// deny them.
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        execWorkingDir,
+					Command:     params.Command,
+					ToolCallID:  call.ID,
+					ToolName:    BashToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Execute command: %s", params.Command),
+					Params:      BashPermissionsParams(params),
+					AlwaysAsk:   alwaysAsk,
+					Preapproved: isSafeReadOnly,
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
 			// If explicitly requested as background, start immediately with detached context
@@ -212,7 +190,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.Start(context.Background(), sessionID, execWorkingDir, denied.funcs, params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -232,6 +210,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					}
 
 					stdout = formatOutput(stdout, stderr, execErr)
+					stdout = sandboxHint(stdout, bgShell.Shell.Sandbox(), execErr)
 
 					metadata := BashResponseMetadata{
 						StartTime:        startTime.UnixMilli(),
@@ -267,7 +246,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.Start(context.Background(), sessionID, execWorkingDir, denied.funcs, params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -313,6 +292,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				}
 
 				stdout = formatOutput(stdout, stderr, execErr)
+				stdout = sandboxHint(stdout, bgShell.Shell.Sandbox(), execErr)
 
 				metadata := BashResponseMetadata{
 					StartTime:        startTime.UnixMilli(),
@@ -381,6 +361,18 @@ func formatOutput(stdout, stderr string, execErr error) string {
 	return stdout
 }
 
+// sandboxHint adds a note about the sandbox to the output of a failed command
+// when it looks like the sandbox is what made it fail.
+func sandboxHint(output string, sb *shell.Sandbox, execErr error) string {
+	if execErr == nil {
+		return output
+	}
+	if hint := sb.Hint(output); hint != "" {
+		return output + "\n\n" + hint
+	}
+	return output
+}
+
 func truncateOutput(content string) string {
 	if len(content) <= MaxOutputLength {
 		return content
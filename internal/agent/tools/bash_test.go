@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashTool_ConfiguredCommands(t *testing.T) {
+	t.Parallel()
+
+	permissions := permission.NewPermissionService(t.TempDir(), false, nil, nil, nil)
+	permissions.AutoApproveSession("s1")
+	permissions.SetNonInteractive(true)
+	commands := config.ToolBash{Ask: []string{"ssh deploy"}}
+	tool := NewBashTool(permissions, t.TempDir(), &config.Attribution{}, commands, "model")
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "s1")
+
+	// Asking for "ssh deploy" doesn't lift the built-in denial of ssh.
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "1", Name: BashToolName, Input: `{"command": "ssh other"}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, `matches the denied command "ssh"`)
+
+	// The asked command isn't denied, but can't be approved without anyone
+	// to ask.
+	_, err = tool.Run(ctx, fantasy.ToolCall{ID: "2", Name: BashToolName, Input: `{"command": "cd /tmp && ssh deploy"}`})
+	var denied *permission.DeniedError
+	require.ErrorAs(t, err, &denied)
+}
+
+func TestBashTool_SafeCommandsGoThroughThePolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	policy, err := permission.NewPolicy(dir, []config.PermissionRule{
+		{Decision: config.PermissionDeny, Tool: BashToolName, CommandPrefix: []string{"git log"}, Reason: "no history"},
+	})
+	require.NoError(t, err)
+	permissions := permission.NewPermissionService(dir, false, nil, policy, nil)
+	tool := NewBashTool(permissions, dir, &config.Attribution{}, config.ToolBash{}, "model")
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "s1")
+
+	// "git log" is a safe command, but the policy denies it.
+	_, err = tool.Run(ctx, fantasy.ToolCall{ID: "1", Name: BashToolName, Input: `{"command": "git log"}`})
+	var denied *permission.DeniedError
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, "no history", denied.Reason)
+
+	// "ls" is a safe command, but not with a variable set for it.
+	events := permissions.Subscribe(t.Context())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := tool.Run(ctx, fantasy.ToolCall{ID: "2", Name: BashToolName, Input: `{"command": "LD_PRELOAD=/tmp/x.so ls"}`})
+		errs <- err
+	}()
+	event := <-events
+	require.Equal(t, "2", event.Payload.ToolCallID)
+	permissions.Deny(event.Payload)
+	require.ErrorIs(t, <-errs, permission.ErrorPermissionDenied)
+}
@@ -92,7 +92,7 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return response, nil
 			}
 
-			notifyLSPs(ctx, lspClients, params.FilePath)
+			notifyLSPsAfterEdit(ctx, lspClients, files, params.FilePath)
 
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
 			text += getDiagnostics(params.FilePath, lspClients)
@@ -131,6 +131,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
@@ -155,7 +156,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	}
 
 	// File can't be in the history so we create a new file history
-	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
+	_, err = edit.files.CreateNew(edit.ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -252,6 +253,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
@@ -384,6 +386,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
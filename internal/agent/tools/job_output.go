@@ -4,7 +4,9 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/brush/internal/shell"
@@ -12,13 +14,23 @@ import (
 
 const (
 	JobOutputToolName = "job_output"
+
+	// DefaultJobWaitTimeout is how long job_output waits by default when
+	// asked to wait for a pattern or for the job to exit.
+	DefaultJobWaitTimeout = 60 * time.Second
+	// MaxJobWaitTimeout is the longest job_output waits.
+	MaxJobWaitTimeout = 10 * time.Minute
 )
 
 //go:embed job_output.md
 var jobOutputDescription []byte
 
 type JobOutputParams struct {
-	ShellID string `json:"shell_id" description:"The ID of the background shell to retrieve output from"`
+	ShellID     string `json:"shell_id" description:"The ID of the background shell to retrieve output from"`
+	Offset      int64  `json:"offset,omitempty" description:"Only return output written after this byte offset, as returned in next_offset by a previous call"`
+	WaitFor     string `json:"wait_for,omitempty" description:"A regular expression to wait for in the output after the offset before returning"`
+	WaitForExit bool   `json:"wait_for_exit,omitempty" description:"Wait for the job to exit before returning"`
+	Timeout     int    `json:"timeout,omitempty" description:"How long to wait in seconds (default 60, max 600)"`
 }
 
 type JobOutputResponseMetadata struct {
@@ -27,6 +39,9 @@ type JobOutputResponseMetadata struct {
 	Description      string `json:"description"`
 	Done             bool   `json:"done"`
 	WorkingDirectory string `json:"working_directory"`
+	NextOffset       int64  `json:"next_offset"`
+	Matched          bool   `json:"matched,omitempty"`
+	TimedOut         bool   `json:"timed_out,omitempty"`
 }
 
 func NewJobOutputTool() fantasy.AgentTool {
@@ -37,6 +52,17 @@ func NewJobOutputTool() fantasy.AgentTool {
 			if params.ShellID == "" {
 				return fantasy.NewTextErrorResponse("missing shell_id"), nil
 			}
+			if params.Offset < 0 {
+				return fantasy.NewTextErrorResponse("offset must not be negative"), nil
+			}
+			var pattern *regexp.Regexp
+			if params.WaitFor != "" {
+				var err error
+				pattern, err = regexp.Compile(params.WaitFor)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid wait_for pattern: %s", err)), nil
+				}
+			}
 
 			bgManager := shell.GetBackgroundShellManager()
 			bgShell, ok := bgManager.Get(params.ShellID)
@@ -44,28 +70,39 @@ func NewJobOutputTool() fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("background shell not found: %s", params.ShellID)), nil
 			}
 
-			stdout, stderr, done, err := bgShell.GetOutput()
+			matched, timedOut, err := waitForJob(ctx, bgShell, params, pattern)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			output, start := bgShell.ReadOutput(params.Offset, MaxOutputLength)
+			nextOffset := start + int64(len(output))
+			done := bgShell.IsDone()
 
 			var outputParts []string
-			if stdout != "" {
-				outputParts = append(outputParts, stdout)
+			if start > params.Offset {
+				outputParts = append(outputParts, fmt.Sprintf("[%d bytes of earlier output were dropped]", start-params.Offset))
 			}
-			if stderr != "" {
-				outputParts = append(outputParts, stderr)
+			if output != "" {
+				outputParts = append(outputParts, output)
+			}
+			if size := bgShell.OutputSize(); nextOffset < size {
+				outputParts = append(outputParts, fmt.Sprintf("[%d more bytes; call again with offset %d]", size-nextOffset, nextOffset))
 			}
 
 			status := "running"
 			if done {
 				status = "completed"
-				if err != nil {
-					exitCode := shell.ExitCode(err)
-					if exitCode != 0 {
-						outputParts = append(outputParts, fmt.Sprintf("Exit code %d", exitCode))
-					}
+				if exitCode := bgShell.Info().ExitCode; exitCode != 0 {
+					outputParts = append(outputParts, fmt.Sprintf("Exit code %d", exitCode))
 				}
 			}
-
-			output := strings.Join(outputParts, "\n")
+			switch {
+			case matched:
+				status += fmt.Sprintf(", output matched %q", params.WaitFor)
+			case timedOut:
+				status += ", timed out waiting"
+			}
 
 			metadata := JobOutputResponseMetadata{
 				ShellID:          params.ShellID,
@@ -73,13 +110,63 @@ func NewJobOutputTool() fantasy.AgentTool {
 				Description:      bgShell.Description,
 				Done:             done,
 				WorkingDirectory: bgShell.WorkingDir,
+				NextOffset:       nextOffset,
+				Matched:          matched,
+				TimedOut:         timedOut,
 			}
 
+			output = strings.Join(outputParts, "\n")
 			if output == "" {
 				output = BashNoOutput
 			}
 
-			result := fmt.Sprintf("Status: %s\n\n%s", status, output)
+			result := fmt.Sprintf("Status: %s\nNext offset: %d\n\n%s", status, nextOffset, output)
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
 		})
 }
+
+// waitForJob waits until the output of the job after the offset matches the
+// pattern, the job exits or the timeout expires, depending on the params. It
+// returns right away if there's nothing to wait for.
+func waitForJob(ctx context.Context, bgShell *shell.BackgroundShell, params JobOutputParams, pattern *regexp.Regexp) (matched, timedOut bool, err error) {
+	if pattern == nil && !params.WaitForExit {
+		return false, false, nil
+	}
+	timeout := DefaultJobWaitTimeout
+	if params.Timeout > 0 {
+		timeout = min(time.Duration(params.Timeout)*time.Second, MaxJobWaitTimeout)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Matches can span writes, so keep matching from the offset, but don't
+	// hold on to more than the bash tool would return.
+	const maxMatchWindow = 4 * MaxOutputLength
+	offset := params.Offset
+	for {
+		changed := bgShell.OutputChanged()
+		if pattern != nil {
+			window, start := bgShell.ReadOutput(offset, maxMatchWindow)
+			if pattern.MatchString(window) {
+				return true, false, nil
+			}
+			if len(window) == maxMatchWindow {
+				offset = start + maxMatchWindow/2
+				continue
+			}
+		}
+		select {
+		case <-bgShell.Done():
+			if pattern != nil {
+				window, _ := bgShell.ReadOutput(offset, maxMatchWindow)
+				return pattern.MatchString(window), false, nil
+			}
+			return false, false, nil
+		case <-changed:
+		case <-timer.C:
+			return false, true, nil
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/filepathext"
+	"github.com/charmbracelet/brush/internal/filetracker"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/lsp"
+	"github.com/charmbracelet/brush/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// LSPPositionParams locates a symbol for the LSP tools that work on a
+// position.
+type LSPPositionParams struct {
+	FilePath string `json:"file_path" description:"The path to the file containing the symbol"`
+	Line     int    `json:"line" description:"The line number where the symbol appears (1-based)"`
+	Symbol   string `json:"symbol" description:"The symbol name as written on that line (e.g., MyFunction, pkg.Func)"`
+}
+
+// lspClientFor returns the first LSP client that handles the file.
+func lspClientFor(lspClients *csync.Map[string, *lsp.Client], path string) *lsp.Client {
+	for client := range lspClients.Seq() {
+		if client.HandlesFile(path) {
+			return client
+		}
+	}
+	return nil
+}
+
+// resolvePosition returns the path of the file of the params, the client
+// that handles it and the column of the symbol.
+func resolvePosition(lspClients *csync.Map[string, *lsp.Client], workingDir string, params LSPPositionParams) (*lsp.Client, string, int, error) {
+	if params.FilePath == "" {
+		return nil, "", 0, errors.New("file_path is required")
+	}
+	if params.Symbol == "" {
+		return nil, "", 0, errors.New("symbol is required")
+	}
+	path := filepathext.SmartJoin(workingDir, params.FilePath)
+	client := lspClientFor(lspClients, path)
+	if client == nil {
+		return nil, "", 0, fmt.Errorf("no LSP client handles %s", path)
+	}
+	column, err := symbolColumn(path, params.Line, params.Symbol)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return client, path, column, nil
+}
+
+// symbolColumn returns the 1-based column of the symbol on the 1-based line
+// of the file. For qualified symbols it's the column of the last part.
+func symbolColumn(path string, line int, symbol string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return 0, fmt.Errorf("line %d is out of range, the file has %d lines", line, len(lines))
+	}
+	idx := strings.Index(lines[line-1], symbol)
+	if idx == -1 {
+		return 0, fmt.Errorf("symbol '%s' not found on line %d", symbol, line)
+	}
+	return idx + getSymbolOffset(symbol) + 1, nil
+}
+
+// formatLocations lists locations with the source line at each of them.
+func formatLocations(locations []protocol.Location) string {
+	var output strings.Builder
+	lines := make(map[string][]string)
+	for _, loc := range cleanupLocations(locations) {
+		path, err := loc.URI.Path()
+		if err != nil {
+			slog.Error("Failed to convert location URI to path", "uri", loc.URI, "error", err)
+			continue
+		}
+		line := int(loc.Range.Start.Line)
+		fmt.Fprintf(&output, "%s:%d:%d", path, line+1, loc.Range.Start.Character+1)
+
+		fileLines, ok := lines[path]
+		if !ok {
+			if content, err := os.ReadFile(path); err == nil {
+				fileLines = strings.Split(string(content), "\n")
+			}
+			lines[path] = fileLines
+		}
+		if line < len(fileLines) {
+			fmt.Fprintf(&output, ": %s", strings.TrimSpace(fileLines[line]))
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
+
+// symbolKind returns the name of a symbol kind.
+func symbolKind(kind protocol.SymbolKind) string {
+	if name, ok := protocol.TableKindMap[kind]; ok {
+		return name
+	}
+	return "Symbol"
+}
+
+// notifyLSPsAfterEdit notifies the LSP clients of a file edited by a tool,
+// then organizes its imports and formats it with the clients configured to do
+// so. The resulting version of the file is recorded in the history.
+func notifyLSPsAfterEdit(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], files history.Service, path string) {
+	notifyLSPs(ctx, lspClients, path)
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for client := range lspClients.Seq() {
+		if !client.HandlesFile(path) {
+			continue
+		}
+		cfg := client.Config()
+		if cfg.OrganizeImportsOnEdit {
+			if err := organizeImports(ctx, client, path); err != nil {
+				slog.Warn("Failed to organize imports", "lsp", client.GetName(), "file", path, "error", err)
+			}
+		}
+		if cfg.FormatOnEdit {
+			if err := formatFile(ctx, client, path); err != nil {
+				slog.Warn("Failed to format file", "lsp", client.GetName(), "file", path, "error", err)
+			}
+		}
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil || bytes.Equal(before, after) {
+		return
+	}
+	if sessionID := GetSessionFromContext(ctx); sessionID != "" {
+		if err := recordFileHistory(ctx, files, sessionID, path, string(before), string(after)); err != nil {
+			slog.Error("Error creating file history version", "file", path, "error", err)
+		}
+	}
+	filetracker.RecordWrite(path)
+	filetracker.RecordRead(path)
+	notifyLSPs(ctx, lspClients, path)
+}
+
+// organizeImports applies the first organize imports code action of the
+// client to the file.
+func organizeImports(ctx context.Context, client *lsp.Client, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	actions, err := client.CodeActions(ctx, path, wholeFileRange(string(content)), nil, protocol.SourceOrganizeImports)
+	if err != nil || len(actions) == 0 {
+		return err
+	}
+	if err := client.ApplyCodeAction(ctx, actions[0], nil); err != nil {
+		return err
+	}
+	return client.NotifyChange(ctx, path)
+}
+
+// formatFile applies the formatting edits of the client to the file.
+func formatFile(ctx context.Context, client *lsp.Client, path string) error {
+	edits, err := client.Format(ctx, path)
+	if err != nil || len(edits) == 0 {
+		return err
+	}
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(path): edits,
+		},
+	}
+	if err := util.ApplyWorkspaceEdit(edit); err != nil {
+		return err
+	}
+	return client.NotifyChange(ctx, path)
+}
+
+// wholeFileRange returns the range that covers all of the content.
+func wholeFileRange(content string) protocol.Range {
+	return protocol.Range{
+		End: protocol.Position{Line: uint32(strings.Count(content, "\n") + 1)},
+	}
+}
+
+// recordFileHistory stores the content of a file before and after a change,
+// like the edit tools do.
+func recordFileHistory(ctx context.Context, files history.Service, sessionID, path, oldContent, newContent string) error {
+	file, err := files.GetByPathAndSession(ctx, path, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, path, oldContent); err != nil {
+			return err
+		}
+	} else if file.Content != oldContent {
+		// The file was changed outside of the tools; store an intermediate
+		// version.
+		if _, err := files.CreateVersion(ctx, sessionID, path, oldContent); err != nil {
+			return err
+		}
+	}
+	_, err = files.CreateVersion(ctx, sessionID, path, newContent)
+	return err
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/lsp"
+)
+
+type CallHierarchyParams struct {
+	FilePath  string `json:"file_path" description:"The path to the file containing the function or method"`
+	Line      int    `json:"line" description:"The line number where the function or method appears (1-based)"`
+	Symbol    string `json:"symbol" description:"The function or method name as written on that line"`
+	Direction string `json:"direction,omitempty" description:"incoming for the callers (default), outgoing for the callees"`
+}
+
+const CallHierarchyToolName = "lsp_call_hierarchy"
+
+//go:embed lsp_call_hierarchy.md
+var callHierarchyDescription []byte
+
+func NewCallHierarchyTool(lspClients *csync.Map[string, *lsp.Client], workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CallHierarchyToolName,
+		string(callHierarchyDescription),
+		func(ctx context.Context, params CallHierarchyParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			client, path, column, err := resolvePosition(lspClients, workingDir, LSPPositionParams{
+				FilePath: params.FilePath,
+				Line:     params.Line,
+				Symbol:   params.Symbol,
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			var calls []lsp.CallHierarchyCall
+			switch params.Direction {
+			case "", "incoming":
+				params.Direction = "incoming"
+				calls, err = client.IncomingCalls(ctx, path, params.Line, column)
+			case "outgoing":
+				calls, err = client.OutgoingCalls(ctx, path, params.Line, column)
+			default:
+				return fantasy.NewTextErrorResponse("direction must be incoming or outgoing"), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to get %s calls: %s", params.Direction, err)), nil
+			}
+			if len(calls) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No %s calls found for '%s'", params.Direction, params.Symbol)), nil
+			}
+
+			var output strings.Builder
+			if params.Direction == "incoming" {
+				fmt.Fprintf(&output, "%s is called by %d function(s):\n\n", params.Symbol, len(calls))
+			} else {
+				fmt.Fprintf(&output, "%s calls %d function(s):\n\n", params.Symbol, len(calls))
+			}
+			for _, c := range calls {
+				itemPath, err := c.Item.URI.Path()
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(&output, "%s %s - %s:%d\n", symbolKind(c.Item.Kind), c.Item.Name, itemPath, c.Item.SelectionRange.Start.Line+1)
+				if params.Direction == "incoming" {
+					lines := make([]string, 0, len(c.Ranges))
+					for _, r := range c.Ranges {
+						lines = append(lines, fmt.Sprint(r.Start.Line+1))
+					}
+					fmt.Fprintf(&output, "  calls on line(s) %s\n", strings.Join(lines, ", "))
+				}
+			}
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
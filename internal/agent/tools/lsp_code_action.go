@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/filepathext"
+	"github.com/charmbracelet/brush/internal/filetracker"
+	"github.com/charmbracelet/brush/internal/fsext"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/lsp"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionParams struct {
+	FilePath string `json:"file_path" description:"The path to the file"`
+	Line     int    `json:"line" description:"The first line of the range to get code actions for (1-based)"`
+	EndLine  int    `json:"end_line,omitempty" description:"The last line of the range (1-based, defaults to line)"`
+	Kind     string `json:"kind,omitempty" description:"The kind of code actions to get (defaults to quickfix)"`
+	Title    string `json:"title,omitempty" description:"The title of the code action to apply (leave empty to list the available actions)"`
+}
+
+type CodeActionPermissionsParams struct {
+	FilePath string   `json:"file_path"`
+	Title    string   `json:"title"`
+	Files    []string `json:"files"`
+	// Command is the command the server runs for the action, if any.
+	Command string `json:"command,omitempty"`
+}
+
+const CodeActionToolName = "lsp_code_action"
+
+//go:embed lsp_code_action.md
+var codeActionDescription []byte
+
+func NewCodeActionTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionToolName,
+		string(codeActionDescription),
+		func(ctx context.Context, params CodeActionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			path := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := lspClientFor(lspClients, path)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP server handles %s", path)), nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read file: %s", err)), nil
+			}
+			rng, err := lineRange(string(content), params.Line, cmp.Or(params.EndLine, params.Line))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			diagnostics := rangeDiagnostics(client.GetFileDiagnostics(protocol.URIFromPath(path)), rng)
+			kind := protocol.CodeActionKind(cmp.Or(params.Kind, string(protocol.QuickFix)))
+			actions, err := client.CodeActions(ctx, path, rng, diagnostics, kind)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to get code actions: %s", err)), nil
+			}
+
+			if params.Title == "" {
+				return fantasy.NewTextResponse(formatCodeActions(actions, diagnostics)), nil
+			}
+
+			idx := slices.IndexFunc(actions, func(action protocol.CodeAction) bool {
+				return action.Title == params.Title
+			})
+			if idx < 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no code action titled '%s' for the range\n\n%s", params.Title, formatCodeActions(actions, diagnostics))), nil
+			}
+			action := actions[idx]
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying a code action")
+			}
+
+			// Ask for every file the edit touches, which may only be known
+			// once the action is resolved.
+			action, err = client.ResolveCodeAction(ctx, action)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to resolve code action: %s", err)), nil
+			}
+			paths := []string{path}
+			if action.Edit != nil {
+				paths = append(paths, workspaceEditPaths(*action.Edit)...)
+				slices.Sort(paths)
+				paths = slices.Compact(paths)
+			}
+			if outside := outsideWorkingDir(paths, workingDir); len(outside) > 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Code action '%s' would change files outside the working directory: %s", action.Title, strings.Join(outside, ", "))), nil
+			}
+			var command string
+			if action.Command != nil {
+				command = action.Command.Command
+			}
+
+			granted, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(path, workingDir),
+					FilePath:    path,
+					FilePaths:   paths,
+					ToolCallID:  call.ID,
+					ToolName:    CodeActionToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("Apply code action: %s", action.Title),
+					Params: CodeActionPermissionsParams{
+						FilePath: path,
+						Title:    action.Title,
+						Files:    paths,
+						Command:  command,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !granted {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			// The command of the action may have the server apply more
+			// edits, to files that need approval too. The content of every
+			// file is kept from before its first edit for the history.
+			var mu sync.Mutex
+			oldContents := make(map[string]string, len(paths))
+			remember := func(paths []string) error {
+				for _, p := range paths {
+					if _, ok := oldContents[p]; ok {
+						continue
+					}
+					content, err := os.ReadFile(p)
+					if err != nil && !errors.Is(err, os.ErrNotExist) {
+						return fmt.Errorf("failed to read file: %w", err)
+					}
+					oldContents[p] = string(content)
+				}
+				return nil
+			}
+			if err := remember(paths); err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			before := func(edit protocol.WorkspaceEdit) error {
+				mu.Lock()
+				defer mu.Unlock()
+				var extra []string
+				for _, p := range workspaceEditPaths(edit) {
+					if _, ok := oldContents[p]; !ok {
+						extra = append(extra, p)
+					}
+				}
+				if len(extra) == 0 {
+					return nil
+				}
+				if outside := outsideWorkingDir(extra, workingDir); len(outside) > 0 {
+					return fmt.Errorf("the edit changes files outside the working directory: %s", strings.Join(outside, ", "))
+				}
+				granted, err := permissions.Request(ctx,
+					permission.CreatePermissionRequest{
+						SessionID:   sessionID,
+						Path:        fsext.PathOrPrefix(path, workingDir),
+						FilePath:    path,
+						FilePaths:   extra,
+						ToolCallID:  call.ID,
+						ToolName:    CodeActionToolName,
+						Action:      "write",
+						Description: fmt.Sprintf("Code action '%s' also edits %d more file(s)", action.Title, len(extra)),
+						Params: CodeActionPermissionsParams{
+							FilePath: path,
+							Title:    action.Title,
+							Files:    extra,
+							Command:  command,
+						},
+					},
+				)
+				if err != nil {
+					return err
+				}
+				if !granted {
+					return permission.ErrorPermissionDenied
+				}
+				return remember(extra)
+			}
+
+			applyErr := client.ApplyCodeAction(ctx, action, before)
+
+			mu.Lock()
+			defer mu.Unlock()
+			edited := slices.Sorted(maps.Keys(oldContents))
+			var changed []string
+			for _, p := range edited {
+				newContent, _ := os.ReadFile(p)
+				if string(newContent) == oldContents[p] {
+					continue
+				}
+				changed = append(changed, p)
+				if err := recordFileHistory(ctx, files, sessionID, p, oldContents[p], string(newContent)); err != nil {
+					slog.Error("Error creating file history version", "file", p, "error", err)
+				}
+				filetracker.RecordWrite(p)
+				filetracker.RecordRead(p)
+				notifyLSPs(ctx, lspClients, p)
+			}
+			if applyErr != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply code action: %s\n\nChanged files: %s", applyErr, strings.Join(changed, ", "))), nil
+			}
+
+			var output strings.Builder
+			fmt.Fprintf(&output, "<result>\nApplied '%s', changing %d file(s):\n", action.Title, len(changed))
+			for _, p := range changed {
+				fmt.Fprintf(&output, "- %s\n", p)
+			}
+			output.WriteString("</result>\n")
+			output.WriteString(getDiagnostics(path, lspClients))
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
+
+// lineRange returns the range that covers the lines, which are 1-based and
+// inclusive.
+func lineRange(content string, start, end int) (protocol.Range, error) {
+	lines := strings.Split(content, "\n")
+	if start < 1 || start > len(lines) {
+		return protocol.Range{}, fmt.Errorf("line %d is out of range, the file has %d lines", start, len(lines))
+	}
+	if end < start || end > len(lines) {
+		return protocol.Range{}, fmt.Errorf("end_line %d is out of range, it must be between %d and %d", end, start, len(lines))
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(start - 1)},
+		End:   protocol.Position{Line: uint32(end - 1), Character: uint32(len(lines[end-1]))},
+	}, nil
+}
+
+// rangeDiagnostics returns the diagnostics that overlap the lines of the
+// range.
+func rangeDiagnostics(diagnostics []protocol.Diagnostic, rng protocol.Range) []protocol.Diagnostic {
+	var result []protocol.Diagnostic
+	for _, diag := range diagnostics {
+		if diag.Range.End.Line >= rng.Start.Line && diag.Range.Start.Line <= rng.End.Line {
+			result = append(result, diag)
+		}
+	}
+	return result
+}
+
+// formatCodeActions lists the code actions and the diagnostics of the range.
+func formatCodeActions(actions []protocol.CodeAction, diagnostics []protocol.Diagnostic) string {
+	var output strings.Builder
+	if len(diagnostics) > 0 {
+		output.WriteString("Diagnostics in range:\n")
+		for _, diag := range diagnostics {
+			fmt.Fprintf(&output, "- L%d: %s\n", diag.Range.Start.Line+1, diag.Message)
+		}
+		output.WriteString("\n")
+	}
+	if len(actions) == 0 {
+		output.WriteString("No code actions available")
+		return output.String()
+	}
+	fmt.Fprintf(&output, "%d code action(s) available:\n", len(actions))
+	for _, action := range actions {
+		fmt.Fprintf(&output, "- %s", action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&output, " (%s)", action.Kind)
+		}
+		if action.IsPreferred {
+			output.WriteString(" [preferred]")
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\nApply one by calling this tool again with its title.")
+	return output.String()
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/lsp"
+)
+
+const DefinitionToolName = "lsp_definition"
+
+//go:embed lsp_definition.md
+var definitionDescription []byte
+
+func NewDefinitionTool(lspClients *csync.Map[string, *lsp.Client], workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DefinitionToolName,
+		string(definitionDescription),
+		func(ctx context.Context, params LSPPositionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			client, path, column, err := resolvePosition(lspClients, workingDir, params)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			locations, err := client.Definition(ctx, path, params.Line, column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to find definition: %s", err)), nil
+			}
+			if len(locations) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No definition found for symbol '%s'", params.Symbol)), nil
+			}
+			return fantasy.NewTextResponse(formatLocations(locations)), nil
+		})
+}
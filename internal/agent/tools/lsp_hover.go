@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/lsp"
+)
+
+const HoverToolName = "lsp_hover"
+
+//go:embed lsp_hover.md
+var hoverDescription []byte
+
+func NewHoverTool(lspClients *csync.Map[string, *lsp.Client], workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		HoverToolName,
+		string(hoverDescription),
+		func(ctx context.Context, params LSPPositionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			client, path, column, err := resolvePosition(lspClients, workingDir, params)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			text, err := client.Hover(ctx, path, params.Line, column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to get hover information: %s", err)), nil
+			}
+			if text == "" {
+				return fantasy.NewTextResponse(fmt.Sprintf("No hover information for symbol '%s'", params.Symbol)), nil
+			}
+			return fantasy.NewTextResponse(text), nil
+		})
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/lsp"
+)
+
+const ImplementationToolName = "lsp_implementation"
+
+//go:embed lsp_implementation.md
+var implementationDescription []byte
+
+func NewImplementationTool(lspClients *csync.Map[string, *lsp.Client], workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ImplementationToolName,
+		string(implementationDescription),
+		func(ctx context.Context, params LSPPositionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			client, path, column, err := resolvePosition(lspClients, workingDir, params)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			locations, err := client.Implementation(ctx, path, params.Line, column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to find implementations: %s", err)), nil
+			}
+			if len(locations) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No implementations found for symbol '%s'", params.Symbol)), nil
+			}
+			return fantasy.NewTextResponse(formatLocations(locations)), nil
+		})
+}
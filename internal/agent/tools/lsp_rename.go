@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/filetracker"
+	"github.com/charmbracelet/brush/internal/fsext"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/lsp"
+	"github.com/charmbracelet/brush/internal/lsp/util"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path" description:"The path to the file containing the symbol"`
+	Line     int    `json:"line" description:"The line number where the symbol appears (1-based)"`
+	Symbol   string `json:"symbol" description:"The symbol name as written on that line"`
+	NewName  string `json:"new_name" description:"The new name of the symbol"`
+}
+
+type RenamePermissionsParams struct {
+	FilePath string   `json:"file_path"`
+	Symbol   string   `json:"symbol"`
+	NewName  string   `json:"new_name"`
+	Files    []string `json:"files"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed lsp_rename.md
+var renameDescription []byte
+
+func NewRenameTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		string(renameDescription),
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.NewName == "" {
+				return fantasy.NewTextErrorResponse("new_name is required"), nil
+			}
+			client, path, column, err := resolvePosition(lspClients, workingDir, LSPPositionParams{
+				FilePath: params.FilePath,
+				Line:     params.Line,
+				Symbol:   params.Symbol,
+			})
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for renaming a symbol")
+			}
+
+			edit, err := client.Rename(ctx, path, params.Line, column, params.NewName)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to rename symbol: %s", err)), nil
+			}
+			paths := workspaceEditPaths(*edit)
+			if len(paths) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("Renaming '%s' changes no files", params.Symbol)), nil
+			}
+			if outside := outsideWorkingDir(paths, workingDir); len(outside) > 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Renaming '%s' would change files outside the working directory: %s", params.Symbol, strings.Join(outside, ", "))), nil
+			}
+
+			oldContents := make(map[string]string, len(paths))
+			for _, p := range paths {
+				content, err := os.ReadFile(p)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+				}
+				oldContents[p] = string(content)
+			}
+
+			granted, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(path, workingDir),
+					FilePath:    path,
+					FilePaths:   paths,
+					ToolCallID:  call.ID,
+					ToolName:    RenameToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("Rename %s to %s in %d file(s)", params.Symbol, params.NewName, len(paths)),
+					Params: RenamePermissionsParams{
+						FilePath: path,
+						Symbol:   params.Symbol,
+						NewName:  params.NewName,
+						Files:    paths,
+					},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !granted {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			if err := util.ApplyWorkspaceEdit(*edit); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to apply rename: %s", err)), nil
+			}
+
+			for _, p := range paths {
+				newContent, _ := os.ReadFile(p)
+				if err := recordFileHistory(ctx, files, sessionID, p, oldContents[p], string(newContent)); err != nil {
+					slog.Error("Error creating file history version", "file", p, "error", err)
+				}
+				filetracker.RecordWrite(p)
+				filetracker.RecordRead(p)
+				notifyLSPs(ctx, lspClients, p)
+			}
+
+			var output strings.Builder
+			fmt.Fprintf(&output, "<result>\nRenamed %s to %s in %d file(s):\n", params.Symbol, params.NewName, len(paths))
+			for _, p := range paths {
+				fmt.Fprintf(&output, "- %s\n", p)
+			}
+			output.WriteString("</result>\n")
+			output.WriteString(getDiagnostics(path, lspClients))
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
+
+// outsideWorkingDir returns the paths that aren't in the working directory.
+func outsideWorkingDir(paths []string, workingDir string) []string {
+	var outside []string
+	for _, p := range paths {
+		if !fsext.HasPrefix(p, workingDir) {
+			outside = append(outside, p)
+		}
+	}
+	return outside
+}
+
+// workspaceEditPaths returns the sorted paths of the files a workspace edit
+// changes, creates, renames or deletes.
+func workspaceEditPaths(edit protocol.WorkspaceEdit) []string {
+	var uris []protocol.DocumentURI
+	for uri := range edit.Changes {
+		uris = append(uris, uri)
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.TextDocumentEdit != nil:
+			uris = append(uris, change.TextDocumentEdit.TextDocument.URI)
+		case change.CreateFile != nil:
+			uris = append(uris, change.CreateFile.URI)
+		case change.DeleteFile != nil:
+			uris = append(uris, change.DeleteFile.URI)
+		case change.RenameFile != nil:
+			uris = append(uris, change.RenameFile.OldURI, change.RenameFile.NewURI)
+		}
+	}
+
+	paths := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		path, err := uri.Path()
+		if err != nil {
+			slog.Error("Failed to convert edit URI to path", "uri", uri, "error", err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/filepathext"
+	"github.com/charmbracelet/brush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type DocumentSymbolsParams struct {
+	FilePath string `json:"file_path" description:"The path to the file to list the symbols of"`
+}
+
+const DocumentSymbolsToolName = "lsp_document_symbols"
+
+//go:embed lsp_symbols.md
+var documentSymbolsDescription []byte
+
+func NewDocumentSymbolsTool(lspClients *csync.Map[string, *lsp.Client], workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DocumentSymbolsToolName,
+		string(documentSymbolsDescription),
+		func(ctx context.Context, params DocumentSymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			path := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := lspClientFor(lspClients, path)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", path)), nil
+			}
+			symbols, err := client.DocumentSymbols(ctx, path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to list symbols: %s", err)), nil
+			}
+			if len(symbols) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No symbols found in %s", path)), nil
+			}
+			var output strings.Builder
+			writeDocumentSymbols(&output, symbols, 0)
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
+
+// writeDocumentSymbols writes the symbols as an indented tree.
+func writeDocumentSymbols(output *strings.Builder, symbols []protocol.DocumentSymbol, depth int) {
+	for _, symbol := range symbols {
+		fmt.Fprintf(output, "%s%s %s", strings.Repeat("  ", depth), symbolKind(symbol.Kind), symbol.Name)
+		if symbol.Detail != "" {
+			fmt.Fprintf(output, " %s", symbol.Detail)
+		}
+		fmt.Fprintf(output, " (line %d)\n", symbol.SelectionRange.Start.Line+1)
+		writeDocumentSymbols(output, symbol.Children, depth+1)
+	}
+}
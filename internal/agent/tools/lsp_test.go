@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolColumn(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() { fmt.Println(\"hi\") }\n"), 0o644))
+
+	column, err := symbolColumn(path, 3, "main")
+	require.NoError(t, err)
+	require.Equal(t, 6, column)
+
+	column, err = symbolColumn(path, 3, "fmt.Println")
+	require.NoError(t, err)
+	require.Equal(t, 19, column)
+
+	_, err = symbolColumn(path, 1, "main.go")
+	require.EqualError(t, err, "symbol 'main.go' not found on line 1")
+	_, err = symbolColumn(path, 10, "main")
+	require.EqualError(t, err, "line 10 is out of range, the file has 4 lines")
+}
+
+func TestWorkspaceEditPaths(t *testing.T) {
+	t.Parallel()
+
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath("/src/b.go"): {{NewText: "b"}},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath("/src/a.go")},
+				},
+			}},
+			{RenameFile: &protocol.RenameFile{
+				OldURI: protocol.URIFromPath("/src/b.go"),
+				NewURI: protocol.URIFromPath("/src/c.go"),
+			}},
+		},
+	}
+	require.Equal(t, []string{"/src/a.go", "/src/b.go", "/src/c.go"}, workspaceEditPaths(edit))
+}
+
+func TestOutsideWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{"/src/a.go", "/src/internal/b.go", "/srcs/c.go", "/etc/hosts"}
+	require.Equal(t, []string{"/srcs/c.go", "/etc/hosts"}, outsideWorkingDir(paths, "/src"))
+	require.Empty(t, outsideWorkingDir(paths[:2], "/src"))
+}
+
+func TestLineRange(t *testing.T) {
+	t.Parallel()
+
+	content := "package main\n\nfunc main() {}\n"
+	rng, err := lineRange(content, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, protocol.Range{
+		End: protocol.Position{Line: 2, Character: 14},
+	}, rng)
+
+	_, err = lineRange(content, 5, 5)
+	require.EqualError(t, err, "line 5 is out of range, the file has 4 lines")
+	_, err = lineRange(content, 3, 2)
+	require.EqualError(t, err, "end_line 2 is out of range, it must be between 3 and 4")
+}
+
+func TestRangeDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	diag := func(start, end uint32) protocol.Diagnostic {
+		return protocol.Diagnostic{Range: protocol.Range{
+			Start: protocol.Position{Line: start},
+			End:   protocol.Position{Line: end},
+		}}
+	}
+	rng := protocol.Range{Start: protocol.Position{Line: 4}, End: protocol.Position{Line: 6}}
+	diagnostics := []protocol.Diagnostic{diag(1, 2), diag(3, 4), diag(5, 5), diag(6, 8), diag(7, 9)}
+	require.Equal(t, []protocol.Diagnostic{diag(3, 4), diag(5, 5), diag(6, 8)}, rangeDiagnostics(diagnostics, rng))
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type WorkspaceSymbolsParams struct {
+	Query string `json:"query" description:"The symbol name or part of it to search for"`
+}
+
+const WorkspaceSymbolsToolName = "lsp_workspace_symbols"
+
+// maxWorkspaceSymbols caps the symbols returned by the tool.
+const maxWorkspaceSymbols = 100
+
+//go:embed lsp_workspace_symbols.md
+var workspaceSymbolsDescription []byte
+
+func NewWorkspaceSymbolsTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		WorkspaceSymbolsToolName,
+		string(workspaceSymbolsDescription),
+		func(ctx context.Context, params WorkspaceSymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Query == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			var symbols []protocol.SymbolInformation
+			var allErrs error
+			for name, client := range lspClients.Seq2() {
+				found, err := client.WorkspaceSymbols(ctx, params.Query)
+				if err != nil {
+					slog.Error("Failed to search workspace symbols", "lsp", name, "error", err)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				symbols = append(symbols, found...)
+			}
+			if len(symbols) == 0 {
+				if allErrs != nil {
+					return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+				}
+				return fantasy.NewTextResponse(fmt.Sprintf("No symbols found matching '%s'", params.Query)), nil
+			}
+
+			slices.SortStableFunc(symbols, func(a, b protocol.SymbolInformation) int {
+				return cmp.Or(
+					cmp.Compare(a.Location.URI, b.Location.URI),
+					cmp.Compare(a.Location.Range.Start.Line, b.Location.Range.Start.Line),
+				)
+			})
+			var output strings.Builder
+			fmt.Fprintf(&output, "Found %d symbol(s)", len(symbols))
+			if len(symbols) > maxWorkspaceSymbols {
+				fmt.Fprintf(&output, ", showing the first %d", maxWorkspaceSymbols)
+				symbols = symbols[:maxWorkspaceSymbols]
+			}
+			output.WriteString(":\n\n")
+			for _, symbol := range symbols {
+				name := symbol.Name
+				if symbol.ContainerName != "" {
+					name = symbol.ContainerName + "." + name
+				}
+				path, err := symbol.Location.URI.Path()
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(&output, "%s %s - %s:%d\n", symbolKind(symbol.Kind), name, path, symbol.Location.Range.Start.Line+1)
+			}
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
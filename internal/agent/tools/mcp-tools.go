@@ -94,6 +94,7 @@ func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolRe
 			SessionID:   sessionID,
 			ToolCallID:  params.ID,
 			Path:        m.workingDir,
+			MCPName:     m.mcpName,
 			ToolName:    m.Info().Name,
 			Action:      "execute",
 			Description: permissionDescription,
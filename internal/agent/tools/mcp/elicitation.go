@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Elicitation actions.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+var (
+	elicitations       = pubsub.NewBroker[ElicitationRequest]()
+	elicitationReplies = csync.NewMap[string, chan *mcp.ElicitResult]()
+	elicitationEnabled atomic.Bool
+)
+
+// ElicitationRequest is a request of an MCP server for input from the user.
+type ElicitationRequest struct {
+	ID      string
+	MCPName string
+	Message string
+	Fields  []ElicitationField
+}
+
+// ElicitationField is a field of the form requested by a server.
+type ElicitationField struct {
+	Name        string
+	Title       string
+	Description string
+	// Type is one of string, number, integer and boolean.
+	Type      string
+	Format    string
+	Enum      []string
+	EnumNames []string
+	Required  bool
+	Default   string
+}
+
+// Label returns the title of the field, or its name.
+func (f ElicitationField) Label() string {
+	return cmp.Or(f.Title, f.Name)
+}
+
+// EnableElicitation lets servers request input from the user. It must only
+// be called by interfaces that handle the requests from
+// [SubscribeElicitations]; otherwise requests are declined.
+func EnableElicitation() {
+	elicitationEnabled.Store(true)
+}
+
+// SubscribeElicitations returns a channel for elicitation requests. Requests
+// that are canceled by the server are published as deleted.
+func SubscribeElicitations(ctx context.Context) <-chan pubsub.Event[ElicitationRequest] {
+	return elicitations.Subscribe(ctx)
+}
+
+// RespondElicitation answers the elicitation request with the given ID.
+func RespondElicitation(id, action string, content map[string]any) {
+	reply, ok := elicitationReplies.Take(id)
+	if !ok {
+		return
+	}
+	result := &mcp.ElicitResult{Action: action}
+	if action == ElicitationAccept {
+		result.Content = content
+	}
+	reply <- result
+}
+
+func elicit(ctx context.Context, name string, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+	if !elicitationEnabled.Load() {
+		return &mcp.ElicitResult{Action: ElicitationDecline}, nil
+	}
+	if params.Mode == "url" {
+		return nil, errors.New("url elicitation is not supported")
+	}
+	fields, err := parseElicitationSchema(params.RequestedSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	req := ElicitationRequest{
+		ID:      uuid.NewString(),
+		MCPName: name,
+		Message: params.Message,
+		Fields:  fields,
+	}
+	reply := make(chan *mcp.ElicitResult, 1)
+	elicitationReplies.Set(req.ID, reply)
+	elicitations.Publish(pubsub.CreatedEvent, req)
+
+	select {
+	case result := <-reply:
+		return result, nil
+	case <-ctx.Done():
+		elicitationReplies.Del(req.ID)
+		elicitations.Publish(pubsub.DeletedEvent, req)
+		return &mcp.ElicitResult{Action: ElicitationCancel}, nil
+	}
+}
+
+type elicitationSchema struct {
+	Properties map[string]struct {
+		Type        string   `json:"type"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		Format      string   `json:"format"`
+		Enum        []any    `json:"enum"`
+		EnumNames   []string `json:"enumNames"`
+		Default     any      `json:"default"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+// parseElicitationSchema returns the fields of a requested schema, required
+// ones first.
+func parseElicitationSchema(schema any) ([]ElicitationField, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var s elicitationSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+
+	fields := make([]ElicitationField, 0, len(s.Properties))
+	for name, p := range s.Properties {
+		f := ElicitationField{
+			Name:        name,
+			Title:       p.Title,
+			Description: p.Description,
+			Type:        p.Type,
+			Format:      p.Format,
+			EnumNames:   p.EnumNames,
+			Required:    slices.Contains(s.Required, name),
+		}
+		switch f.Type {
+		case "string", "number", "integer", "boolean":
+		default:
+			return nil, fmt.Errorf("unsupported type %q of field %q", f.Type, name)
+		}
+		for _, v := range p.Enum {
+			f.Enum = append(f.Enum, fmt.Sprint(v))
+		}
+		if p.Default != nil {
+			f.Default = fmt.Sprint(p.Default)
+		}
+		fields = append(fields, f)
+	}
+	slices.SortFunc(fields, func(a, b ElicitationField) int {
+		if a.Required != b.Required {
+			if a.Required {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return fields, nil
+}
+
+// Values converts the values entered for the fields of the request to the
+// types of the fields. Empty values of optional fields are left out.
+func (r ElicitationRequest) Values(values map[string]string) (map[string]any, error) {
+	content := make(map[string]any, len(values))
+	for _, f := range r.Fields {
+		v := strings.TrimSpace(values[f.Name])
+		if v == "" {
+			if f.Required {
+				return nil, fmt.Errorf("%s is required", f.Label())
+			}
+			continue
+		}
+		if len(f.Enum) > 0 && !slices.Contains(f.Enum, v) {
+			return nil, fmt.Errorf("%s must be one of %s", f.Label(), strings.Join(f.Enum, ", "))
+		}
+		switch f.Type {
+		case "number":
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", f.Label())
+			}
+			content[f.Name] = n
+		case "integer":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer", f.Label())
+			}
+			content[f.Name] = n
+		case "boolean":
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", f.Label())
+			}
+			content[f.Name] = b
+		default:
+			content[f.Name] = v
+		}
+	}
+	return content, nil
+}
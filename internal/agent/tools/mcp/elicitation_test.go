@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseElicitationSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"notes": {"type": "string"},
+			"count": {"type": "integer", "title": "Count", "default": 3},
+			"env": {"type": "string", "enum": ["dev", "prod"]},
+			"confirm": {"type": "boolean", "description": "Are you sure?"}
+		},
+		"required": ["env", "confirm"]
+	}`)
+	fields, err := parseElicitationSchema(schema)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"confirm", "env", "count", "notes"}, names)
+	require.True(t, fields[0].Required)
+	require.Equal(t, []string{"dev", "prod"}, fields[1].Enum)
+	require.Equal(t, "Count", fields[2].Label())
+	require.Equal(t, "3", fields[2].Default)
+	require.Equal(t, "notes", fields[3].Label())
+
+	req := ElicitationRequest{Fields: fields}
+	content, err := req.Values(map[string]string{"confirm": "true", "env": "prod", "count": "5"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"confirm": true, "env": "prod", "count": int64(5)}, content)
+
+	_, err = req.Values(map[string]string{"confirm": "true"})
+	require.EqualError(t, err, "env is required")
+	_, err = req.Values(map[string]string{"confirm": "true", "env": "staging"})
+	require.EqualError(t, err, "env must be one of dev, prod")
+	_, err = req.Values(map[string]string{"confirm": "true", "env": "dev", "count": "many"})
+	require.EqualError(t, err, "Count must be an integer")
+
+	_, err = parseElicitationSchema(json.RawMessage(`{"properties": {"tags": {"type": "array"}}}`))
+	require.Error(t, err)
+}
+
+func TestElicit(t *testing.T) {
+	elicitationEnabled.Store(false)
+	result, err := elicit(t.Context(), "test", &mcp.ElicitParams{Message: "hi"})
+	require.NoError(t, err)
+	require.Equal(t, ElicitationDecline, result.Action)
+
+	elicitationEnabled.Store(true)
+	t.Cleanup(func() { elicitationEnabled.Store(false) })
+	events := SubscribeElicitations(t.Context())
+	received := make(chan pubsub.Event[ElicitationRequest], 1)
+	go func() {
+		event := <-events
+		received <- event
+		RespondElicitation(event.Payload.ID, ElicitationAccept, map[string]any{"name": "brush"})
+	}()
+	result, err = elicit(t.Context(), "test", &mcp.ElicitParams{
+		Message:         "What's your name?",
+		RequestedSchema: map[string]any{"properties": map[string]any{"name": map[string]any{"type": "string"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ElicitationAccept, result.Action)
+	require.Equal(t, map[string]any{"name": "brush"}, result.Content)
+	event := <-received
+	require.Equal(t, pubsub.CreatedEvent, event.Type)
+	require.Equal(t, "test", event.Payload.MCPName)
+	require.Equal(t, "What's your name?", event.Payload.Message)
+}
@@ -41,6 +41,8 @@ const (
 	StateStarting
 	StateConnected
 	StateError
+	// StateUnauthorized is the state of servers that require a [Login].
+	StateUnauthorized
 )
 
 func (s State) String() string {
@@ -53,6 +55,8 @@ func (s State) String() string {
 		return "connected"
 	case StateError:
 		return "error"
+	case StateUnauthorized:
+		return "unauthorized"
 	default:
 		return "unknown"
 	}
@@ -65,6 +69,7 @@ const (
 	EventStateChanged EventType = iota
 	EventToolsListChanged
 	EventPromptsListChanged
+	EventResourcesListChanged
 )
 
 // Event represents an event in the MCP system
@@ -78,8 +83,9 @@ type Event struct {
 
 // Counts number of available tools, prompts, etc.
 type Counts struct {
-	Tools   int
-	Prompts int
+	Tools     int
+	Prompts   int
+	Resources int
 }
 
 // ClientInfo holds information about an MCP client's state
@@ -130,6 +136,7 @@ func Close() error {
 	case <-time.After(5 * time.Second):
 	}
 	broker.Shutdown()
+	elicitations.Shutdown()
 	return nil
 }
 
@@ -166,42 +173,57 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 				}
 			}()
 
-			// createSession handles its own timeout internally.
-			session, err := createSession(ctx, name, m, cfg.Resolver())
-			if err != nil {
-				return
-			}
-
-			tools, err := getTools(ctx, session)
-			if err != nil {
-				slog.Error("error listing tools", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
-
-			prompts, err := getPrompts(ctx, session)
-			if err != nil {
-				slog.Error("error listing prompts", "error", err)
-				updateState(name, StateError, err, nil, Counts{})
-				session.Close()
-				return
-			}
-
-			toolCount := updateTools(name, tools)
-			updatePrompts(name, prompts)
-			sessions.Set(name, session)
-
-			updateState(name, StateConnected, nil, session, Counts{
-				Tools:   toolCount,
-				Prompts: len(prompts),
-			})
+			connect(ctx, name, m, cfg)
 		}(name, m)
 	}
 	wg.Wait()
 	initOnce.Do(func() { close(initDone) })
 }
 
+// connect creates a session with the MCP server and lists its tools, prompts
+// and resources.
+func connect(ctx context.Context, name string, m config.MCPConfig, cfg *config.Config) {
+	// createSession handles its own timeout internally.
+	session, err := createSession(ctx, name, m, cfg)
+	if err != nil {
+		return
+	}
+
+	tools, err := getTools(ctx, session)
+	if err != nil {
+		slog.Error("error listing tools", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return
+	}
+
+	prompts, err := getPrompts(ctx, session)
+	if err != nil {
+		slog.Error("error listing prompts", "error", err)
+		updateState(name, StateError, err, nil, Counts{})
+		session.Close()
+		return
+	}
+
+	// The tools and prompts of a server are still usable when listing its
+	// resources fails.
+	resources, templates, err := getResources(ctx, session)
+	if err != nil {
+		slog.Error("error listing resources", "name", name, "error", err)
+	}
+
+	toolCount := updateTools(name, tools)
+	updatePrompts(name, prompts)
+	updateResources(name, resources, templates)
+	sessions.Set(name, session)
+
+	updateState(name, StateConnected, nil, session, Counts{
+		Tools:     toolCount,
+		Prompts:   len(prompts),
+		Resources: len(resources) + len(templates),
+	})
+}
+
 // WaitForInit blocks until MCP initialization is complete.
 // If Initialize was never called, this returns immediately.
 func WaitForInit(ctx context.Context) error {
@@ -232,7 +254,7 @@ func getOrRenewClient(ctx context.Context, name string) (*mcp.ClientSession, err
 	}
 	updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, state.Counts)
 
-	sess, err = createSession(ctx, name, m, cfg.Resolver())
+	sess, err = createSession(ctx, name, m, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -254,7 +276,7 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 	switch state {
 	case StateConnected:
 		info.ConnectedAt = time.Now()
-	case StateError:
+	case StateError, StateUnauthorized:
 		sessions.Del(name)
 	}
 	states.Set(name, info)
@@ -269,12 +291,12 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 	})
 }
 
-func createSession(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (*mcp.ClientSession, error) {
+func createSession(ctx context.Context, name string, m config.MCPConfig, cfg *config.Config) (*mcp.ClientSession, error) {
 	timeout := mcpTimeout(m)
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
-	transport, err := createTransport(mcpCtx, m, resolver)
+	transport, err := createTransport(mcpCtx, name, m, cfg.Resolver())
 	if err != nil {
 		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("error creating mcp client", "error", err, "name", name)
@@ -302,19 +324,41 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 					Name: name,
 				})
 			},
+			ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+				// Refresh right away so resources stay current in every UI.
+				go func() {
+					RefreshResources(context.Background(), name)
+					broker.Publish(pubsub.UpdatedEvent, Event{
+						Type: EventResourcesListChanged,
+						Name: name,
+					})
+				}()
+			},
 			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
 				slog.Info("MCP log", "name", name, "data", req.Params.Data)
 			},
+			CreateMessageHandler: func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+				return sample(ctx, name, req.Params)
+			},
+			ElicitationHandler: func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+				return elicit(ctx, name, req.Params)
+			},
 		},
 	)
+	client.AddRoots(workingDirRoot(cfg.WorkingDir()))
 
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
+		cancel()
+		cancelTimer.Stop()
+		if _, ok := authChallenges.Get(name); ok {
+			slog.Info("MCP server requires login", "name", name)
+			updateState(name, StateUnauthorized, ErrUnauthorized, nil, Counts{})
+			return nil, ErrUnauthorized
+		}
 		err = maybeStdioErr(err, transport)
 		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
 		slog.Error("MCP client failed to initialize", "error", err, "name", name)
-		cancel()
-		cancelTimer.Stop()
 		return nil, err
 	}
 
@@ -351,7 +395,7 @@ func maybeTimeoutErr(err error, timeout time.Duration) error {
 	return err
 }
 
-func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
+func createTransport(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		command, err := resolver.ResolveValue(m.Command)
@@ -370,9 +414,13 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
+		loadOAuth(name, m)
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+			Transport: oauthRoundTripper{
+				name: name,
+				base: &headerRoundTripper{
+					headers: m.ResolvedHeaders(),
+				},
 			},
 		}
 		return &mcp.StreamableClientTransport{
@@ -383,9 +431,13 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 		if strings.TrimSpace(m.URL) == "" {
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
+		loadOAuth(name, m)
 		client := &http.Client{
-			Transport: &headerRoundTripper{
-				headers: m.ResolvedHeaders(),
+			Transport: oauthRoundTripper{
+				name: name,
+				base: &headerRoundTripper{
+					headers: m.ResolvedHeaders(),
+				},
 			},
 		}
 		return &mcp.SSEClientTransport{
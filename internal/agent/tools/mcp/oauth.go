@@ -0,0 +1,471 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/oauth"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnauthorized is the error of MCP servers that require an OAuth login.
+var ErrUnauthorized = errors.New("login required")
+
+var (
+	// authChallenges holds the WWW-Authenticate headers of the servers that
+	// rejected a request as unauthorized.
+	authChallenges = csync.NewMap[string, []string]()
+	oauthTokens    = csync.NewMap[string, *oauth.Token]()
+	oauthClients   = csync.NewMap[string, config.MCPOAuthConfig]()
+	refreshMu      sync.Mutex
+)
+
+// saveOAuth persists the OAuth client and token of an MCP server next to the
+// provider tokens.
+var saveOAuth = func(name string, client config.MCPOAuthConfig, token *oauth.Token) error {
+	cfg := config.Get()
+	if err := cfg.SetConfigField(fmt.Sprintf("mcp.%s.oauth_client", name), client); err != nil {
+		return err
+	}
+	return cfg.SetConfigField(fmt.Sprintf("mcp.%s.oauth", name), token)
+}
+
+// NeedsLogin returns whether the MCP server rejected the connection until
+// logged in with [Login].
+func NeedsLogin(name string) bool {
+	state, ok := states.Get(name)
+	return ok && state.State == StateUnauthorized
+}
+
+// Login authorizes with an HTTP or SSE MCP server through the OAuth 2.1
+// authorization code flow with PKCE. The authorization page is opened with
+// openURL, and the redirect is received on a local port. The token is then
+// saved and the server reconnected.
+func Login(ctx context.Context, cfg *config.Config, name string, openURL func(string) error) error {
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return fmt.Errorf("mcp %q not found", name)
+	}
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return fmt.Errorf("mcp %q is not an http or sse server", name)
+	}
+	loadOAuth(name, m)
+
+	if err := authorize(ctx, name, m, openURL); err != nil {
+		return err
+	}
+
+	if sess, ok := sessions.Take(name); ok {
+		sess.Close()
+	}
+	updateState(name, StateStarting, nil, nil, Counts{})
+	// The session outlives the login.
+	connect(context.WithoutCancel(ctx), name, m, cfg)
+	if state, _ := states.Get(name); state.State != StateConnected {
+		return fmt.Errorf("logged in, but failed to connect: %w", state.Error)
+	}
+	return nil
+}
+
+// loadOAuth loads the OAuth client and token of the server from its
+// configuration, unless they were already updated.
+func loadOAuth(name string, m config.MCPConfig) {
+	if m.OAuth != nil {
+		oauthClients.GetOrSet(name, func() config.MCPOAuthConfig { return *m.OAuth })
+	}
+	if m.OAuthToken != nil {
+		oauthTokens.GetOrSet(name, func() *oauth.Token { return m.OAuthToken })
+	}
+}
+
+func authorize(ctx context.Context, name string, m config.MCPConfig, openURL func(string) error) error {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+
+	challenges, _ := authChallenges.Get(name)
+	discovery, err := discoverAuth(ctx, httpClient, m.URL, challenges)
+	if err != nil {
+		return err
+	}
+
+	client, _ := oauthClients.Get(name)
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", client.CallbackPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for the authorization callback: %w", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+	redirectURL := fmt.Sprintf("http://127.0.0.1:%d/callback", port)
+
+	if client.ClientID == "" {
+		if discovery.server.RegistrationEndpoint == "" {
+			return fmt.Errorf("mcp %q doesn't support dynamic client registration, configure oauth_client.client_id", name)
+		}
+		registered, err := registerClient(ctx, httpClient, discovery.server.RegistrationEndpoint, redirectURL)
+		if err != nil {
+			return err
+		}
+		client.ClientID = registered.ClientID
+		client.ClientSecret = registered.ClientSecret
+		// The redirect URL was registered with this port.
+		client.CallbackPort = port
+	}
+	client.TokenURL = discovery.server.TokenEndpoint
+
+	scopes := client.Scopes
+	if len(scopes) == 0 {
+		scopes = discovery.scopes
+	}
+	conf := &oauth2.Config{
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.server.AuthorizationEndpoint,
+			TokenURL: discovery.server.TokenEndpoint,
+		},
+		RedirectURL: redirectURL,
+		Scopes:      scopes,
+	}
+	verifier := oauth2.GenerateVerifier()
+	state := oauth2.GenerateVerifier()
+	resource := oauth2.SetAuthURLParam("resource", discovery.resource)
+
+	results := make(chan callbackResult, 1)
+	srv := &http.Server{
+		Handler:           callbackHandler(state, results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(listener) //nolint:errcheck
+	defer srv.Close()
+
+	if err := openURL(conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), resource)); err != nil {
+		return err
+	}
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result = <-results:
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	t, err := conf.Exchange(ctx, result.code, oauth2.VerifierOption(verifier), resource)
+	if err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	token := fromOAuth2(t)
+	oauthClients.Set(name, client)
+	oauthTokens.Set(name, token)
+	authChallenges.Del(name)
+	if err := saveOAuth(name, client, token); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	slog.Info("Logged in to MCP server", "name", name)
+	return nil
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// callbackHandler receives the redirect of the authorization server.
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		var result callbackResult
+		switch {
+		case q.Get("error") != "":
+			result.err = fmt.Errorf("authorization failed: %s", strings.TrimSpace(q.Get("error")+" "+q.Get("error_description")))
+		case q.Get("state") != state:
+			result.err = errors.New("authorization failed: state mismatch")
+		case q.Get("code") == "":
+			result.err = errors.New("authorization failed: no code received")
+		default:
+			result.code = q.Get("code")
+		}
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in. You can close this window and go back to Brush.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+}
+
+// authDiscovery is what's needed to authorize with an MCP server.
+type authDiscovery struct {
+	resource string
+	scopes   []string
+	server   authServerMetadata
+}
+
+type protectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported,omitempty"`
+}
+
+type authServerMetadata struct {
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// discoverAuth finds the authorization server of the MCP server at serverURL
+// through its protected resource metadata, located by the WWW-Authenticate
+// challenge or the well-known URL. Without metadata, the MCP server's origin
+// is assumed to be the authorization server.
+func discoverAuth(ctx context.Context, client *http.Client, serverURL string, challenges []string) (authDiscovery, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return authDiscovery{}, fmt.Errorf("invalid mcp url: %w", err)
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	var candidates []string
+	if metadataURL := challengeParam(challenges, "resource_metadata"); metadataURL != "" {
+		candidates = append(candidates, metadataURL)
+	}
+	if p := strings.TrimSuffix(u.Path, "/"); p != "" {
+		candidates = append(candidates, origin+"/.well-known/oauth-protected-resource"+p)
+	}
+	candidates = append(candidates, origin+"/.well-known/oauth-protected-resource")
+
+	discovery := authDiscovery{
+		resource: serverURL,
+		scopes:   strings.Fields(challengeParam(challenges, "scope")),
+	}
+	issuer := origin
+	for _, candidate := range candidates {
+		var prm protectedResourceMetadata
+		if err := getJSON(ctx, client, candidate, &prm); err != nil {
+			slog.Debug("No protected resource metadata", "url", candidate, "error", err)
+			continue
+		}
+		if len(prm.AuthorizationServers) == 0 {
+			continue
+		}
+		issuer = prm.AuthorizationServers[0]
+		if prm.Resource != "" {
+			discovery.resource = prm.Resource
+		}
+		if len(discovery.scopes) == 0 {
+			discovery.scopes = prm.ScopesSupported
+		}
+		break
+	}
+
+	discovery.server, err = getAuthServerMetadata(ctx, client, issuer)
+	return discovery, err
+}
+
+// getAuthServerMetadata looks up the metadata of the authorization server at
+// its OAuth and OpenID Connect well-known URLs.
+func getAuthServerMetadata(ctx context.Context, client *http.Client, issuer string) (authServerMetadata, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return authServerMetadata{}, fmt.Errorf("invalid authorization server: %w", err)
+	}
+	origin := u.Scheme + "://" + u.Host
+	p := strings.TrimSuffix(u.Path, "/")
+	candidates := []string{
+		origin + "/.well-known/oauth-authorization-server" + p,
+		origin + "/.well-known/openid-configuration" + p,
+	}
+	if p != "" {
+		candidates = append(candidates, origin+p+"/.well-known/openid-configuration")
+	}
+
+	for _, candidate := range candidates {
+		var meta authServerMetadata
+		if err := getJSON(ctx, client, candidate, &meta); err != nil {
+			slog.Debug("No authorization server metadata", "url", candidate, "error", err)
+			continue
+		}
+		if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" {
+			continue
+		}
+		if len(meta.CodeChallengeMethodsSupported) > 0 && !slices.Contains(meta.CodeChallengeMethodsSupported, "S256") {
+			return authServerMetadata{}, fmt.Errorf("authorization server %s doesn't support PKCE", issuer)
+		}
+		return meta, nil
+	}
+
+	// Servers without metadata use the default endpoints.
+	return authServerMetadata{
+		AuthorizationEndpoint: origin + "/authorize",
+		TokenEndpoint:         origin + "/token",
+		RegistrationEndpoint:  origin + "/register",
+	}, nil
+}
+
+// registerClient registers brush as a public client with the authorization
+// server through dynamic client registration.
+func registerClient(ctx context.Context, client *http.Client, endpoint, redirectURL string) (config.MCPOAuthConfig, error) {
+	body, err := json.Marshal(map[string]any{
+		"client_name":                "Brush",
+		"redirect_uris":              []string{redirectURL},
+		"grant_types":                []string{"authorization_code", "refresh_token"},
+		"response_types":             []string{"code"},
+		"token_endpoint_auth_method": "none",
+	})
+	if err != nil {
+		return config.MCPOAuthConfig{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return config.MCPOAuthConfig{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return config.MCPOAuthConfig{}, fmt.Errorf("failed to register client: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return config.MCPOAuthConfig{}, fmt.Errorf("failed to register client: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var registered struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&registered); err != nil {
+		return config.MCPOAuthConfig{}, fmt.Errorf("failed to decode client registration: %w", err)
+	}
+	if registered.ClientID == "" {
+		return config.MCPOAuthConfig{}, errors.New("failed to register client: no client_id returned")
+	}
+	return config.MCPOAuthConfig{
+		ClientID:     registered.ClientID,
+		ClientSecret: registered.ClientSecret,
+	}, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// challengeParam returns a parameter of the Bearer WWW-Authenticate
+// challenges.
+func challengeParam(challenges []string, name string) string {
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(name) + `\s*=\s*(?:"([^"]*)"|([^\s,]+))`)
+	for _, challenge := range challenges {
+		if m := re.FindStringSubmatch(challenge); m != nil {
+			return m[1] + m[2]
+		}
+	}
+	return ""
+}
+
+// validToken returns the token of the server, refreshing it first if it
+// expired.
+func validToken(ctx context.Context, name string) *oauth.Token {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	token, ok := oauthTokens.Get(name)
+	if !ok || token.ExpiresAt == 0 || token.RefreshToken == "" || !token.IsExpired() {
+		return token
+	}
+	client, _ := oauthClients.Get(name)
+	if client.TokenURL == "" {
+		return token
+	}
+	conf := &oauth2.Config{
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Endpoint:     oauth2.Endpoint{TokenURL: client.TokenURL},
+	}
+	t, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		slog.Warn("Failed to refresh MCP OAuth token", "name", name, "error", err)
+		return token
+	}
+	token = fromOAuth2(t)
+	oauthTokens.Set(name, token)
+	if err := saveOAuth(name, client, token); err != nil {
+		slog.Warn("Failed to save refreshed MCP OAuth token", "name", name, "error", err)
+	}
+	slog.Info("Refreshed MCP OAuth token", "name", name)
+	return token
+}
+
+func fromOAuth2(t *oauth2.Token) *oauth.Token {
+	token := &oauth.Token{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+	}
+	if !t.Expiry.IsZero() {
+		token.ExpiresAt = t.Expiry.Unix()
+		token.SetExpiresIn()
+	}
+	return token
+}
+
+// oauthRoundTripper authorizes requests to an MCP server with its OAuth
+// token, and records the challenge when the server asks for authorization.
+type oauthRoundTripper struct {
+	name string
+	base http.RoundTripper
+}
+
+func (rt oauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token := validToken(req.Context(), rt.name); token != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+	resp, err := rt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		authChallenges.Set(rt.name, resp.Header.Values("WWW-Authenticate"))
+	case resp.StatusCode < http.StatusBadRequest:
+		authChallenges.Del(rt.name)
+	}
+	return resp, nil
+}
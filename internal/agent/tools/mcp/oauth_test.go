@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/oauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthLogin(t *testing.T) {
+	var challenge string
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v) //nolint:errcheck
+	}
+	mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer access", "Bearer refreshed":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+srv.URL+`/.well-known/oauth-protected-resource/mcp", scope="read"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc("/.well-known/oauth-protected-resource/mcp", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, protectedResourceMetadata{
+			Resource:             srv.URL + "/mcp",
+			AuthorizationServers: []string{srv.URL + "/auth"},
+		})
+	})
+	mux.HandleFunc("/.well-known/oauth-authorization-server/auth", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, authServerMetadata{
+			AuthorizationEndpoint:         srv.URL + "/auth/authorize",
+			TokenEndpoint:                 srv.URL + "/auth/token",
+			RegistrationEndpoint:          srv.URL + "/auth/register",
+			CodeChallengeMethodsSupported: []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/auth/register", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			RedirectURIs []string `json:"redirect_uris"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.RedirectURIs, 1)
+		writeJSON(w, http.StatusCreated, map[string]string{"client_id": "client"})
+	})
+	mux.HandleFunc("/auth/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		require.Equal(t, "client", q.Get("client_id"))
+		require.Equal(t, "S256", q.Get("code_challenge_method"))
+		require.Equal(t, srv.URL+"/mcp", q.Get("resource"))
+		require.Equal(t, "read", q.Get("scope"))
+		challenge = q.Get("code_challenge")
+		redirect, err := url.Parse(q.Get("redirect_uri"))
+		require.NoError(t, err)
+		redirect.RawQuery = url.Values{"code": {"code"}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			require.Equal(t, "code", r.Form.Get("code"))
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			require.Equal(t, challenge, base64.RawURLEncoding.EncodeToString(sum[:]))
+			writeJSON(w, http.StatusOK, map[string]any{"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600})
+		case "refresh_token":
+			require.Equal(t, "refresh", r.Form.Get("refresh_token"))
+			writeJSON(w, http.StatusOK, map[string]any{"access_token": "refreshed", "token_type": "Bearer", "expires_in": 3600})
+		}
+	})
+
+	var saved *oauth.Token
+	save := saveOAuth
+	t.Cleanup(func() { saveOAuth = save })
+	saveOAuth = func(_ string, client config.MCPOAuthConfig, token *oauth.Token) error {
+		require.Equal(t, "client", client.ClientID)
+		require.Equal(t, srv.URL+"/auth/token", client.TokenURL)
+		saved = token
+		return nil
+	}
+
+	const name = "oauth-test"
+	client := &http.Client{Transport: oauthRoundTripper{name: name, base: http.DefaultTransport}}
+	get := func() int {
+		resp, err := client.Get(srv.URL + "/mcp")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get())
+	_, ok := authChallenges.Get(name)
+	require.True(t, ok)
+
+	m := config.MCPConfig{Type: config.MCPHttp, URL: srv.URL + "/mcp"}
+	err := authorize(t.Context(), name, m, func(authURL string) error {
+		resp, err := http.Get(authURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "access", saved.AccessToken)
+	require.Equal(t, http.StatusOK, get())
+	_, ok = authChallenges.Get(name)
+	require.False(t, ok)
+
+	// Expired tokens are refreshed before the request.
+	oauthTokens.Set(name, &oauth.Token{
+		AccessToken:  "expired",
+		RefreshToken: "refresh",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	})
+	require.Equal(t, http.StatusOK, get())
+	require.Equal(t, "refreshed", saved.AccessToken)
+	require.Equal(t, "refresh", saved.RefreshToken)
+}
+
+func TestChallengeParam(t *testing.T) {
+	t.Parallel()
+
+	challenges := []string{`Bearer error="invalid_token", resource_metadata="https://example.com/.well-known/oauth-protected-resource", scope=read`}
+	require.Equal(t, "https://example.com/.well-known/oauth-protected-resource", challengeParam(challenges, "resource_metadata"))
+	require.Equal(t, "read", challengeParam(challenges, "scope"))
+	require.Empty(t, challengeParam(challenges, "realm"))
+	require.Empty(t, challengeParam(nil, "scope"))
+}
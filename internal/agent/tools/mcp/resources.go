@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type (
+	Resource         = mcp.Resource
+	ResourceTemplate = mcp.ResourceTemplate
+	ResourceContents = mcp.ResourceContents
+)
+
+var (
+	allResources         = csync.NewMap[string, []*Resource]()
+	allResourceTemplates = csync.NewMap[string, []*ResourceTemplate]()
+)
+
+// Resources returns all available MCP resources.
+func Resources() iter.Seq2[string, []*Resource] {
+	return allResources.Seq2()
+}
+
+// ResourceTemplates returns all available MCP resource templates.
+func ResourceTemplates() iter.Seq2[string, []*ResourceTemplate] {
+	return allResourceTemplates.Seq2()
+}
+
+// ReadResource reads the contents of a resource from an MCP.
+func ReadResource(ctx context.Context, name, uri string) ([]*ResourceContents, error) {
+	c, err := getOrRenewClient(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// RefreshResources gets the updated list of resources from the MCP and
+// updates the global state.
+func RefreshResources(ctx context.Context, name string) {
+	session, ok := sessions.Get(name)
+	if !ok {
+		slog.Warn("refresh resources: no session", "name", name)
+		return
+	}
+
+	resources, templates, err := getResources(ctx, session)
+	if err != nil {
+		updateState(name, StateError, err, nil, Counts{})
+		return
+	}
+
+	updateResources(name, resources, templates)
+
+	prev, _ := states.Get(name)
+	prev.Counts.Resources = len(resources) + len(templates)
+	updateState(name, StateConnected, nil, session, prev.Counts)
+}
+
+// getResources lists the resources and resource templates of the MCP,
+// following pagination.
+func getResources(ctx context.Context, c *mcp.ClientSession) ([]*Resource, []*ResourceTemplate, error) {
+	if c.InitializeResult().Capabilities.Resources == nil {
+		return nil, nil, nil
+	}
+	var resources []*Resource
+	for resource, err := range c.Resources(ctx, &mcp.ListResourcesParams{}) {
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, resource)
+	}
+	var templates []*ResourceTemplate
+	for template, err := range c.ResourceTemplates(ctx, &mcp.ListResourceTemplatesParams{}) {
+		if err != nil {
+			// Resource templates are optional, not every server implements
+			// listing them.
+			slog.Debug("error listing resource templates", "error", err)
+			break
+		}
+		templates = append(templates, template)
+	}
+	return resources, templates, nil
+}
+
+// updateResources updates the global resources and resource templates maps.
+func updateResources(mcpName string, resources []*Resource, templates []*ResourceTemplate) {
+	if len(resources) == 0 {
+		allResources.Del(mcpName)
+	} else {
+		allResources.Set(mcpName, resources)
+	}
+	if len(templates) == 0 {
+		allResourceTemplates.Del(mcpName)
+	} else {
+		allResourceTemplates.Set(mcpName, templates)
+	}
+}
@@ -0,0 +1,45 @@
+package mcp
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetResources(t *testing.T) {
+	ctx := t.Context()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	read := func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: req.Params.URI, Text: "hello"}}}, nil
+	}
+	server.AddResource(&mcp.Resource{URI: "file:///readme.md", Name: "readme", MIMEType: "text/markdown"}, read)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{URITemplate: "file:///docs/{name}", Name: "docs"}, read)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { serverSession.Close() })
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+
+	resources, templates, err := getResources(ctx, session)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Equal(t, "file:///readme.md", resources[0].URI)
+	require.Len(t, templates, 1)
+	require.Equal(t, "file:///docs/{name}", templates[0].URITemplate)
+
+	updateResources("test", resources, templates)
+	t.Cleanup(func() { updateResources("test", nil, nil) })
+	require.Equal(t, map[string][]*Resource{"test": resources}, maps.Collect(Resources()))
+	require.Equal(t, map[string][]*ResourceTemplate{"test": templates}, maps.Collect(ResourceTemplates()))
+
+	updateResources("test", nil, nil)
+	require.Empty(t, maps.Collect(Resources()))
+	require.Empty(t, maps.Collect(ResourceTemplates()))
+}
@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type (
+	CreateMessageParams = mcp.CreateMessageParams
+	CreateMessageResult = mcp.CreateMessageResult
+	TextContent         = mcp.TextContent
+	ImageContent        = mcp.ImageContent
+)
+
+// SamplingHandler answers a request of an MCP server for a completion from
+// the client's model.
+type SamplingHandler func(ctx context.Context, name string, params *CreateMessageParams) (*CreateMessageResult, error)
+
+var (
+	samplingHandler = csync.NewValue[SamplingHandler](nil)
+
+	// toolCalls holds the contexts of the running tool calls of each server.
+	toolCalls   = make(map[string][]context.Context)
+	toolCallsMu sync.Mutex
+)
+
+// SetSamplingHandler sets the handler of sampling requests. Until it's set,
+// they fail.
+func SetSamplingHandler(h SamplingHandler) {
+	samplingHandler.Set(h)
+}
+
+func sample(ctx context.Context, name string, params *CreateMessageParams) (*CreateMessageResult, error) {
+	h := samplingHandler.Get()
+	if h == nil {
+		return nil, errors.New("sampling is not available")
+	}
+	return h(withToolCall(ctx, name), name, params)
+}
+
+// trackToolCall records the context of a running tool call of the server
+// until the returned function is called.
+func trackToolCall(ctx context.Context, name string) func() {
+	toolCallsMu.Lock()
+	toolCalls[name] = append(toolCalls[name], ctx)
+	toolCallsMu.Unlock()
+	return func() {
+		toolCallsMu.Lock()
+		defer toolCallsMu.Unlock()
+		calls := toolCalls[name]
+		for i, c := range calls {
+			if c == ctx {
+				toolCalls[name] = append(calls[:i], calls[i+1:]...)
+				break
+			}
+		}
+		if len(toolCalls[name]) == 0 {
+			delete(toolCalls, name)
+		}
+	}
+}
+
+// withToolCall gives a request of the server the values of its latest
+// running tool call, like its session, since requests made while handling a
+// call belong to it.
+func withToolCall(ctx context.Context, name string) context.Context {
+	toolCallsMu.Lock()
+	defer toolCallsMu.Unlock()
+	calls := toolCalls[name]
+	if len(calls) == 0 {
+		return ctx
+	}
+	return valuesContext{Context: ctx, values: calls[len(calls)-1]}
+}
+
+// valuesContext is a context with the values of another one.
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// workingDirRoot reports the working directory to servers as their root.
+func workingDirRoot(dir string) *mcp.Root {
+	path := filepath.ToSlash(dir)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &mcp.Root{
+		URI:  (&url.URL{Scheme: "file", Path: path}).String(),
+		Name: filepath.Base(dir),
+	}
+}
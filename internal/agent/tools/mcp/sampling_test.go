@@ -0,0 +1,36 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testContextKey struct{}
+
+func TestWithToolCall(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	require.Equal(t, ctx, withToolCall(ctx, "with-tool-call"))
+
+	call := context.WithValue(context.Background(), testContextKey{}, "session")
+	done := trackToolCall(call, "with-tool-call")
+	reqCtx, cancel := context.WithCancel(ctx)
+	withCall := withToolCall(reqCtx, "with-tool-call")
+	require.Equal(t, "session", withCall.Value(testContextKey{}))
+	cancel()
+	require.Error(t, withCall.Err())
+
+	done()
+	require.Nil(t, withToolCall(ctx, "with-tool-call").Value(testContextKey{}))
+}
+
+func TestWorkingDirRoot(t *testing.T) {
+	t.Parallel()
+
+	root := workingDirRoot("/home/user/my project")
+	require.Equal(t, "file:///home/user/my%20project", root.URI)
+	require.Equal(t, "my project", root.Name)
+}
@@ -42,6 +42,7 @@ func RunTool(ctx context.Context, name, toolName string, input string) (ToolResu
 	if err != nil {
 		return ToolResult{}, err
 	}
+	defer trackToolCall(ctx, name)()
 	result, err := c.CallTool(ctx, &mcp.CallToolParams{
 		Name:      toolName,
 		Arguments: args,
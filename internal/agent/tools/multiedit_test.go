@@ -29,10 +29,14 @@ func (m *mockPermissionService) Deny(req permission.PermissionRequest) {}
 
 func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest) {}
 
+func (m *mockPermissionService) GrantAlways(req permission.PermissionRequest) {}
+
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
 
+func (m *mockPermissionService) SetNonInteractive(nonInteractive bool) {}
+
 func (m *mockPermissionService) SkipRequests() bool {
 	return false
 }
@@ -41,6 +45,14 @@ func (m *mockPermissionService) SubscribeNotifications(ctx context.Context) <-ch
 	return make(<-chan pubsub.Event[permission.PermissionNotification])
 }
 
+func (m *mockPermissionService) ListGrants(ctx context.Context) ([]permission.Grant, error) {
+	return nil, nil
+}
+
+func (m *mockPermissionService) RevokeGrant(ctx context.Context, id string) error {
+	return nil
+}
+
 type mockHistoryService struct {
 	*pubsub.Broker[history.File]
 }
@@ -49,6 +61,10 @@ func (m *mockHistoryService) Create(ctx context.Context, sessionID, path, conten
 	return history.File{Path: path, Content: content}, nil
 }
 
+func (m *mockHistoryService) CreateNew(ctx context.Context, sessionID, path string) (history.File, error) {
+	return history.File{Path: path, IsNew: true}, nil
+}
+
 func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
 	return history.File{}, nil
 }
@@ -77,6 +93,18 @@ func (m *mockHistoryService) DeleteSessionFiles(ctx context.Context, sessionID s
 	return nil
 }
 
+func (m *mockHistoryService) CreateCheckpoint(ctx context.Context, sessionID, messageID string) (history.Checkpoint, error) {
+	return history.Checkpoint{}, nil
+}
+
+func (m *mockHistoryService) GetCheckpoint(ctx context.Context, messageID string) (history.Checkpoint, error) {
+	return history.Checkpoint{}, nil
+}
+
+func (m *mockHistoryService) ChangedSince(ctx context.Context, checkpoint history.Checkpoint) ([]history.File, error) {
+	return nil, nil
+}
+
 func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	t.Parallel()
 
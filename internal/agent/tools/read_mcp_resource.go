@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+)
+
+const (
+	ReadMCPResourceToolName = "read_mcp_resource"
+)
+
+//go:embed read_mcp_resource.md
+var readMCPResourceDescription []byte
+
+type ReadMCPResourceParams struct {
+	Server string `json:"server,omitempty" description:"The name of the MCP server, required if several servers could serve the uri"`
+	URI    string `json:"uri,omitempty" description:"The uri of the resource to read, omit to list the available resources"`
+}
+
+type ReadMCPResourceResponseMetadata struct {
+	Server   string `json:"server"`
+	URI      string `json:"uri"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// HasMCPResources reports whether any of the allowed MCP servers has
+// resources or resource templates. A nil allowed function allows every
+// server.
+func HasMCPResources(allowed func(server string) bool) bool {
+	for server := range mcp.Resources() {
+		if allowed == nil || allowed(server) {
+			return true
+		}
+	}
+	for server := range mcp.ResourceTemplates() {
+		if allowed == nil || allowed(server) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewReadMCPResourceTool returns a tool that reads the resources of the
+// allowed MCP servers. A nil allowed function allows every server.
+func NewReadMCPResourceTool(allowed func(server string) bool) fantasy.AgentTool {
+	if allowed == nil {
+		allowed = func(string) bool { return true }
+	}
+	return fantasy.NewAgentTool(
+		ReadMCPResourceToolName,
+		string(readMCPResourceDescription),
+		func(ctx context.Context, params ReadMCPResourceParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Server != "" && !allowed(params.Server) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("MCP server %q is not available", params.Server)), nil
+			}
+			if params.URI == "" {
+				return fantasy.NewTextResponse(listMCPResources(params.Server, allowed)), nil
+			}
+
+			server := params.Server
+			if server == "" {
+				servers := mcpResourceServers(params.URI, allowed)
+				switch len(servers) {
+				case 0:
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("no MCP server lists resource %s, set server to read it anyway", params.URI)), nil
+				case 1:
+					server = servers[0]
+				default:
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("several MCP servers list resource %s, set server to one of: %s", params.URI, strings.Join(servers, ", "))), nil
+				}
+			}
+
+			contents, err := mcp.ReadResource(ctx, server, params.URI)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error reading resource: %s", err)), nil
+			}
+			metadata := ReadMCPResourceResponseMetadata{Server: server, URI: params.URI}
+			var texts []string
+			for _, content := range contents {
+				metadata.MIMEType = cmp.Or(metadata.MIMEType, content.MIMEType)
+				switch {
+				case content.Blob == nil:
+					texts = append(texts, content.Text)
+				case strings.HasPrefix(content.MIMEType, "image/") && GetSupportsImagesFromContext(ctx):
+					encoded := base64.StdEncoding.EncodeToString(content.Blob)
+					return fantasy.WithResponseMetadata(fantasy.NewImageResponse([]byte(encoded), content.MIMEType), metadata), nil
+				default:
+					texts = append(texts, fmt.Sprintf("[binary content of %s: %s, %d bytes]", content.URI, cmp.Or(content.MIMEType, "unknown type"), len(content.Blob)))
+				}
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(strings.Join(texts, "\n")), metadata), nil
+		})
+}
+
+// listMCPResources describes the resources and resource templates of the
+// allowed servers, or of the given one.
+func listMCPResources(server string, allowed func(string) bool) string {
+	include := func(name string) bool {
+		return allowed(name) && (server == "" || name == server)
+	}
+	var sb strings.Builder
+	for name, resources := range mcp.Resources() {
+		if !include(name) {
+			continue
+		}
+		fmt.Fprintf(&sb, "<resources server=%q>\n", name)
+		for _, r := range resources {
+			fmt.Fprintf(&sb, "- %s: %s", r.URI, cmp.Or(r.Title, r.Name))
+			if r.Description != "" {
+				fmt.Fprintf(&sb, " - %s", r.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</resources>\n")
+	}
+	for name, templates := range mcp.ResourceTemplates() {
+		if !include(name) {
+			continue
+		}
+		fmt.Fprintf(&sb, "<resource_templates server=%q>\n", name)
+		for _, t := range templates {
+			fmt.Fprintf(&sb, "- %s: %s", t.URITemplate, cmp.Or(t.Title, t.Name))
+			if t.Description != "" {
+				fmt.Fprintf(&sb, " - %s", t.Description)
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</resource_templates>\n")
+	}
+	if sb.Len() == 0 {
+		return "No MCP resources available."
+	}
+	return sb.String()
+}
+
+// mcpResourceServers returns the allowed servers listing the resource, or
+// the ones with resource templates if none does.
+func mcpResourceServers(uri string, allowed func(string) bool) []string {
+	var servers []string
+	for name, resources := range mcp.Resources() {
+		if allowed(name) && slices.ContainsFunc(resources, func(r *mcp.Resource) bool { return r.URI == uri }) {
+			servers = append(servers, name)
+		}
+	}
+	if len(servers) == 0 {
+		for name := range mcp.ResourceTemplates() {
+			if allowed(name) {
+				servers = append(servers, name)
+			}
+		}
+	}
+	slices.Sort(servers)
+	return servers
+}
@@ -85,6 +85,7 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 					permission.CreatePermissionRequest{
 						SessionID:   sessionID,
 						Path:        absFilePath,
+						FilePath:    absFilePath,
 						ToolCallID:  call.ID,
 						ToolName:    ViewToolName,
 						Action:      "read",
@@ -108,6 +108,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					FilePath:    filePath,
 					ToolCallID:  call.ID,
 					ToolName:    WriteToolName,
 					Action:      "write",
@@ -134,7 +135,11 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			// Check if file exists in history
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
-				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				if fileInfo == nil {
+					_, err = files.CreateNew(ctx, sessionID, filePath)
+				} else {
+					_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				}
 				if err != nil {
 					// Log error but don't fail the operation
 					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -156,7 +161,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			filetracker.RecordWrite(filePath)
 			filetracker.RecordRead(filePath)
 
-			notifyLSPs(ctx, lspClients, params.FilePath)
+			notifyLSPsAfterEdit(ctx, lspClients, files, filePath)
 
 			result := fmt.Sprintf("File successfully written: %s", filePath)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
@@ -10,8 +10,10 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
@@ -63,7 +65,9 @@ type App struct {
 	serviceEventsWG *sync.WaitGroup
 	eventsCtx       context.Context
 	events          chan tea.Msg
-	tuiWG           *sync.WaitGroup
+	// eventsDropped counts the service events that didn't fit in events.
+	eventsDropped atomic.Uint64
+	tuiWG         *sync.WaitGroup
 
 	// global context and cleanup functions
 	globalCtx    context.Context
@@ -78,15 +82,27 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	files := history.NewService(q, conn)
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	var allowedTools []string
-	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
+	var rules []config.PermissionRule
+	if cfg.Permissions != nil {
 		allowedTools = cfg.Permissions.AllowedTools
+		rules = cfg.Permissions.Rules
+	}
+	policy, err := permission.NewPolicy(cfg.WorkingDir(), rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permission rules: %w", err)
+	}
+	if sb := cfg.Tools.Bash.Sandbox; sb.Enabled {
+		sandbox := shell.NewSandbox(cfg.WorkingDir(), sb.Paths(cfg.WorkingDir()), sb.AllowNetwork)
+		if err := shell.GetBackgroundShellManager().SetSandbox(sandbox); err != nil {
+			return nil, err
+		}
 	}
 
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools, policy, q),
 		LSPClients:  csync.NewMap[string, *lsp.Client](),
 
 		globalCtx: ctx,
@@ -100,6 +116,11 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 
 	app.setupEvents()
 
+	// Keep the output of background jobs on disk.
+	if err := shell.GetBackgroundShellManager().SetLogDir(filepath.Join(cfg.Options.DataDirectory, "jobs")); err != nil {
+		slog.Warn("Failed to set up background job logs", "error", err)
+	}
+
 	// Initialize LSP clients in the background.
 	app.initLSPClients(ctx)
 
@@ -130,20 +151,46 @@ func (app *App) Config() *config.Config {
 	return app.config
 }
 
+// RunOptions configures a non-interactive run.
+type RunOptions struct {
+	// LargeModel and SmallModel override the configured models. They accept
+	// "model" or "provider/model".
+	LargeModel string
+	SmallModel string
+	// Quiet hides the spinner.
+	Quiet bool
+	// SessionID resumes an existing session instead of creating one.
+	SessionID string
+	// Continue resumes the most recently updated session.
+	Continue bool
+	// OutputFormat is the format printed to the output writer.
+	OutputFormat OutputFormat
+	// MaxCost is the maximum cost of the run, in USD. A resumed session can
+	// spend up to this much more.
+	MaxCost float64
+}
+
 // RunNonInteractive runs the application in non-interactive mode with the
 // given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string, quiet bool) error {
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, opts RunOptions) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	if largeModel != "" || smallModel != "" {
-		if err := app.overrideModelsForNonInteractive(ctx, largeModel, smallModel); err != nil {
+	quiet := opts.Quiet
+	structured := opts.OutputFormat == OutputFormatJSON || opts.OutputFormat == OutputFormatStreamJSON
+
+	if opts.LargeModel != "" || opts.SmallModel != "" {
+		if err := app.overrideModelsForNonInteractive(ctx, opts.LargeModel, opts.SmallModel); err != nil {
 			return fmt.Errorf("failed to override models: %w", err)
 		}
 	}
 
+	if opts.MaxCost > 0 {
+		app.setMaxCost(opts.MaxCost)
+	}
+
 	var (
 		spinner   *format.Spinner
 		stdoutTTY bool
@@ -198,26 +245,29 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 	defer stopSpinner()
 
-	const maxPromptLengthForTitle = 100
-	const titlePrefix = "Non-interactive: "
-	var titleSuffix string
-
-	if len(prompt) > maxPromptLengthForTitle {
-		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
-	} else {
-		titleSuffix = prompt
-	}
-	title := titlePrefix + titleSuffix
-
-	sess, err := app.Sessions.Create(ctx, title)
+	sess, err := app.nonInteractiveSession(ctx, prompt, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+		return err
 	}
-	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
 
 	// Automatically approve all permission requests for this non-interactive
-	// session.
+	// session. Those that must be asked are denied, as there is no one to ask.
 	app.Permissions.AutoApproveSession(sess.ID)
+	app.Permissions.SetNonInteractive(true)
+
+	var events *eventWriter
+	if structured {
+		events = newEventWriter(output, sess.ID, opts.OutputFormat)
+		// Only report messages created by this run when resuming a session.
+		existing, err := app.Messages.List(ctx, sess.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list session messages: %w", err)
+		}
+		events.skip(existing)
+		if err := events.writeSession(); err != nil {
+			return err
+		}
+	}
 
 	type response struct {
 		result *fantasy.AgentResult
@@ -237,7 +287,8 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		}
 	}(ctx, sess.ID, prompt)
 
-	messageEvents := app.Messages.Subscribe(ctx)
+	// Every event is needed to print the messages as they're streamed.
+	messageEvents := app.Messages.SubscribeLossless(ctx)
 	messageReadBytes := make(map[string]int)
 
 	defer func() {
@@ -247,7 +298,12 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 		// Always print a newline at the end. If output is a TTY this will
 		// prevent the prompt from overwriting the last line of output.
-		_, _ = fmt.Fprintln(output)
+		if !structured {
+			_, _ = fmt.Fprintln(output)
+		}
+
+		// Print the session ID so later runs can resume it with --session.
+		_, _ = fmt.Fprintf(os.Stderr, "Session: %s\n", sess.ID)
 	}()
 
 	for {
@@ -260,6 +316,11 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		select {
 		case result := <-done:
 			stopSpinner()
+			if structured {
+				if err := app.finishEvents(ctx, events, sess.ID, result.err); err != nil {
+					return err
+				}
+			}
 			if result.err != nil {
 				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
 					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
@@ -271,6 +332,13 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 		case event := <-messageEvents:
 			msg := event.Payload
+			if structured {
+				stopSpinner()
+				if err := events.writeMessage(msg); err != nil {
+					return err
+				}
+				continue
+			}
 			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
@@ -299,6 +367,68 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 }
 
+// nonInteractiveSession returns the session a non-interactive run should use:
+// the one requested in opts, or a new one titled after the prompt.
+func (app *App) nonInteractiveSession(ctx context.Context, prompt string, opts RunOptions) (session.Session, error) {
+	switch {
+	case opts.SessionID != "":
+		sess, err := app.Sessions.Get(ctx, opts.SessionID)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to get session %q: %w", opts.SessionID, err)
+		}
+		slog.Info("Resuming session for non-interactive run", "session_id", sess.ID)
+		return sess, nil
+	case opts.Continue:
+		sessions, err := app.Sessions.List(ctx)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(sessions) == 0 {
+			return session.Session{}, errors.New("no session to continue")
+		}
+		// Sessions are sorted by last update.
+		slog.Info("Continuing most recent session for non-interactive run", "session_id", sessions[0].ID)
+		return sessions[0], nil
+	}
+
+	const maxPromptLengthForTitle = 100
+	const titlePrefix = "Non-interactive: "
+	var titleSuffix string
+
+	if len(prompt) > maxPromptLengthForTitle {
+		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
+	} else {
+		titleSuffix = prompt
+	}
+	title := titlePrefix + titleSuffix
+
+	sess, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+	}
+	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+	return sess, nil
+}
+
+// finishEvents writes any message events that were missed while streaming
+// and the final result event of a structured non-interactive run.
+func (app *App) finishEvents(ctx context.Context, events *eventWriter, sessionID string, runErr error) error {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list session messages: %w", err)
+	}
+	for _, msg := range msgs {
+		if err := events.writeMessage(msg); err != nil {
+			return err
+		}
+	}
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	return events.writeResult(sess, runErr)
+}
+
 func (app *App) UpdateAgentModel(ctx context.Context) error {
 	if app.AgentCoordinator == nil {
 		return fmt.Errorf("agent configuration is missing")
@@ -306,6 +436,16 @@ func (app *App) UpdateAgentModel(ctx context.Context) error {
 	return app.AgentCoordinator.UpdateModels(ctx)
 }
 
+// setMaxCost limits what the run spends, whatever the session spent before,
+// on top of the other budgets.
+func (app *App) setMaxCost(maxCost float64) {
+	opts := app.config.Options
+	if opts.Budgets == nil {
+		opts.Budgets = &config.Budgets{}
+	}
+	opts.Budgets.Run = &config.Budget{MaxCost: maxCost}
+}
+
 // overrideModelsForNonInteractive parses the model strings and temporarily
 // overrides the model configurations, then rebuilds the agent.
 // Format: "model-name" (searches all providers) or "provider/model-name".
@@ -399,13 +539,18 @@ func (app *App) GetDefaultSmallModel(providerID string) config.SelectedModel {
 func (app *App) setupEvents() {
 	ctx, cancel := context.WithCancel(app.globalCtx)
 	app.eventsCtx = ctx
-	setupSubscriber(ctx, app.serviceEventsWG, "sessions", app.Sessions.Subscribe, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "messages", app.Messages.Subscribe, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
-	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "sessions", app.Sessions.Subscribe, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "messages", app.Messages.SubscribeLossless, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp-elicitations", mcp.SubscribeElicitations, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events, &app.eventsDropped)
+	setupSubscriber(ctx, app.serviceEventsWG, "jobs", shell.GetBackgroundShellManager().SubscribeEvents, app.events, &app.eventsDropped)
+	app.serviceEventsWG.Go(func() {
+		pubsub.LogStats(ctx, eventStatsInterval, app.eventStats())
+	})
 	cleanupFunc := func() error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -414,12 +559,38 @@ func (app *App) setupEvents() {
 	app.cleanupFuncs = append(app.cleanupFuncs, cleanupFunc)
 }
 
+// eventStatsInterval is how often the event delivery stats are logged.
+const eventStatsInterval = time.Minute
+
+// eventStats returns the delivery stats of the services' events, and of the
+// events forwarded to the TUI.
+func (app *App) eventStats() map[string]pubsub.StatsReporter {
+	reporters := map[string]pubsub.StatsReporter{
+		"app": pubsub.StatsFunc(func() pubsub.Stats {
+			return pubsub.Stats{Dropped: app.eventsDropped.Load()}
+		}),
+	}
+	services := map[string]any{
+		"sessions":    app.Sessions,
+		"messages":    app.Messages,
+		"permissions": app.Permissions,
+		"history":     app.History,
+	}
+	for name, service := range services {
+		if r, ok := service.(pubsub.StatsReporter); ok {
+			reporters[name] = r
+		}
+	}
+	return reporters
+}
+
 func setupSubscriber[T any](
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	name string,
 	subscriber func(context.Context) <-chan pubsub.Event[T],
 	outputCh chan<- tea.Msg,
+	dropped *atomic.Uint64,
 ) {
 	wg.Go(func() {
 		subCh := subscriber(ctx)
@@ -434,7 +605,7 @@ func setupSubscriber[T any](
 				select {
 				case outputCh <- msg:
 				case <-time.After(2 * time.Second):
-					slog.Warn("message dropped due to slow consumer", "name", name)
+					slog.Warn("message dropped due to slow consumer", "name", name, "dropped", dropped.Add(1))
 				case <-ctx.Done():
 					slog.Debug("subscription cancelled", "name", name)
 					return
@@ -466,6 +637,8 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	mcp.SetSamplingHandler(app.AgentCoordinator.Sample)
+	setupSubscriber(app.eventsCtx, app.serviceEventsWG, "retries", app.AgentCoordinator.SubscribeRetries, app.events, &app.eventsDropped)
 	return nil
 }
 
@@ -501,6 +674,12 @@ func (app *App) Subscribe(program *tea.Program) {
 	}
 }
 
+// Events returns the channel service events are forwarded to. It has a single
+// consumer: either the TUI through [App.Subscribe] or the server.
+func (app *App) Events() <-chan tea.Msg {
+	return app.events
+}
+
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
 	start := time.Now()
@@ -0,0 +1,286 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+)
+
+// OutputFormat is the output format of non-interactive runs.
+type OutputFormat string
+
+const (
+	// OutputFormatText prints the assistant's text as it's generated.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON prints newline-delimited JSON events for every
+	// completed message part.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatStreamJSON is like [OutputFormatJSON] but also prints text
+	// and reasoning deltas as they are generated.
+	OutputFormatStreamJSON OutputFormat = "stream-json"
+)
+
+// ParseOutputFormat validates an output format name. An empty name is
+// [OutputFormatText].
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch f := OutputFormat(name); f {
+	case "":
+		return OutputFormatText, nil
+	case OutputFormatText, OutputFormatJSON, OutputFormatStreamJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q: must be one of text, json, stream-json", name)
+	}
+}
+
+// EventType is the type of a structured output event.
+type EventType string
+
+const (
+	EventSession        EventType = "session"
+	EventTextDelta      EventType = "text_delta"
+	EventReasoningDelta EventType = "reasoning_delta"
+	EventText           EventType = "text"
+	EventReasoning      EventType = "reasoning"
+	EventToolCall       EventType = "tool_call"
+	EventToolResult     EventType = "tool_result"
+	EventFinish         EventType = "finish"
+	EventResult         EventType = "result"
+)
+
+// OutputEvent is a single line of structured output. Only the fields relevant
+// to the event type are set.
+type OutputEvent struct {
+	Type      EventType `json:"type"`
+	SessionID string    `json:"session_id"`
+	MessageID string    `json:"message_id,omitempty"`
+
+	// Text is set for text and reasoning events and their deltas.
+	Text string `json:"text,omitempty"`
+
+	ToolCall   *OutputToolCall   `json:"tool_call,omitempty"`
+	ToolResult *OutputToolResult `json:"tool_result,omitempty"`
+	Finish     *OutputFinish     `json:"finish,omitempty"`
+
+	// Usage and Cost are set for the final result event.
+	Usage *OutputUsage `json:"usage,omitempty"`
+	Cost  *float64     `json:"cost,omitempty"`
+	// Error is set on the final result event if the run failed.
+	Error string `json:"error,omitempty"`
+}
+
+type OutputToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+type OutputToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+type OutputFinish struct {
+	Reason  message.FinishReason `json:"reason"`
+	Message string               `json:"message,omitempty"`
+	Details string               `json:"details,omitempty"`
+}
+
+type OutputUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// messageOutputState tracks what has already been written for a message.
+type messageOutputState struct {
+	textBytes      int
+	reasoningBytes int
+	reasoningDone  bool
+	toolCalls      map[string]bool
+	toolResults    map[string]bool
+	finished       bool
+}
+
+// eventWriter writes the events of a non-interactive session as
+// newline-delimited JSON.
+type eventWriter struct {
+	enc       *json.Encoder
+	sessionID string
+	deltas    bool
+	messages  map[string]*messageOutputState
+}
+
+func newEventWriter(w io.Writer, sessionID string, format OutputFormat) *eventWriter {
+	return &eventWriter{
+		enc:       json.NewEncoder(w),
+		sessionID: sessionID,
+		deltas:    format == OutputFormatStreamJSON,
+		messages:  make(map[string]*messageOutputState),
+	}
+}
+
+// skip marks messages that existed before the run as already written.
+func (w *eventWriter) skip(msgs []message.Message) {
+	for _, msg := range msgs {
+		state := w.state(msg.ID)
+		state.finished = true
+		for _, tr := range msg.ToolResults() {
+			state.toolResults[tr.ToolCallID] = true
+		}
+	}
+}
+
+func (w *eventWriter) write(event OutputEvent) error {
+	event.SessionID = w.sessionID
+	return w.enc.Encode(event)
+}
+
+func (w *eventWriter) writeSession() error {
+	return w.write(OutputEvent{Type: EventSession})
+}
+
+// writeMessage writes the events for everything new in msg since the last
+// time it was seen. It can be called with every update of a message.
+func (w *eventWriter) writeMessage(msg message.Message) error {
+	if msg.SessionID != w.sessionID {
+		return nil
+	}
+	switch msg.Role {
+	case message.Assistant:
+		return w.writeAssistant(msg)
+	case message.Tool:
+		return w.writeToolResults(msg)
+	}
+	return nil
+}
+
+func (w *eventWriter) state(id string) *messageOutputState {
+	state, ok := w.messages[id]
+	if !ok {
+		state = &messageOutputState{
+			toolCalls:   make(map[string]bool),
+			toolResults: make(map[string]bool),
+		}
+		w.messages[id] = state
+	}
+	return state
+}
+
+func (w *eventWriter) writeAssistant(msg message.Message) error {
+	state := w.state(msg.ID)
+	if state.finished {
+		return nil
+	}
+
+	reasoning := msg.ReasoningContent()
+	if w.deltas && len(reasoning.Thinking) > state.reasoningBytes {
+		if err := w.write(OutputEvent{
+			Type:      EventReasoningDelta,
+			MessageID: msg.ID,
+			Text:      reasoning.Thinking[state.reasoningBytes:],
+		}); err != nil {
+			return err
+		}
+		state.reasoningBytes = len(reasoning.Thinking)
+	}
+	if !state.reasoningDone && reasoning.Thinking != "" && (reasoning.FinishedAt > 0 || msg.IsFinished()) {
+		if err := w.write(OutputEvent{Type: EventReasoning, MessageID: msg.ID, Text: reasoning.Thinking}); err != nil {
+			return err
+		}
+		state.reasoningDone = true
+	}
+
+	text := msg.Content().Text
+	if w.deltas && len(text) > state.textBytes {
+		if err := w.write(OutputEvent{
+			Type:      EventTextDelta,
+			MessageID: msg.ID,
+			Text:      text[state.textBytes:],
+		}); err != nil {
+			return err
+		}
+		state.textBytes = len(text)
+	}
+
+	for _, tc := range msg.ToolCalls() {
+		if !tc.Finished || state.toolCalls[tc.ID] {
+			continue
+		}
+		input := json.RawMessage(tc.Input)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		if err := w.write(OutputEvent{
+			Type:      EventToolCall,
+			MessageID: msg.ID,
+			ToolCall:  &OutputToolCall{ID: tc.ID, Name: tc.Name, Input: input},
+		}); err != nil {
+			return err
+		}
+		state.toolCalls[tc.ID] = true
+	}
+
+	finish := msg.FinishPart()
+	if finish == nil {
+		return nil
+	}
+	if text != "" {
+		if err := w.write(OutputEvent{Type: EventText, MessageID: msg.ID, Text: text}); err != nil {
+			return err
+		}
+	}
+	state.finished = true
+	return w.write(OutputEvent{
+		Type:      EventFinish,
+		MessageID: msg.ID,
+		Finish: &OutputFinish{
+			Reason:  finish.Reason,
+			Message: finish.Message,
+			Details: finish.Details,
+		},
+	})
+}
+
+func (w *eventWriter) writeToolResults(msg message.Message) error {
+	state := w.state(msg.ID)
+	for _, tr := range msg.ToolResults() {
+		if state.toolResults[tr.ToolCallID] {
+			continue
+		}
+		if err := w.write(OutputEvent{
+			Type:      EventToolResult,
+			MessageID: msg.ID,
+			ToolResult: &OutputToolResult{
+				ToolCallID: tr.ToolCallID,
+				Name:       tr.Name,
+				Content:    tr.Content,
+				IsError:    tr.IsError,
+			},
+		}); err != nil {
+			return err
+		}
+		state.toolResults[tr.ToolCallID] = true
+	}
+	return nil
+}
+
+// writeResult writes the final event with the session's usage and cost.
+func (w *eventWriter) writeResult(sess session.Session, runErr error) error {
+	event := OutputEvent{
+		Type: EventResult,
+		Usage: &OutputUsage{
+			PromptTokens:     sess.PromptTokens,
+			CompletionTokens: sess.CompletionTokens,
+		},
+		Cost: &sess.Cost,
+	}
+	if runErr != nil {
+		event.Error = runErr.Error()
+	}
+	return w.write(event)
+}
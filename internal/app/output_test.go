@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeEvents(t *testing.T, data []byte) []OutputEvent {
+	t.Helper()
+	var events []OutputEvent
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var event OutputEvent
+		require.NoError(t, dec.Decode(&event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func eventTypes(events []OutputEvent) []EventType {
+	types := make([]EventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestEventWriter(t *testing.T) {
+	t.Parallel()
+
+	assistant := message.Message{ID: "m1", Role: message.Assistant, SessionID: "s1"}
+	toolMsg := message.Message{
+		ID:        "m2",
+		Role:      message.Tool,
+		SessionID: "s1",
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "t1", Name: "ls", Content: "main.go"},
+		},
+	}
+
+	run := func(format OutputFormat) []OutputEvent {
+		var buf bytes.Buffer
+		w := newEventWriter(&buf, "s1", format)
+		require.NoError(t, w.writeSession())
+
+		msg := assistant.Clone()
+		msg.AppendContent("Hello")
+		require.NoError(t, w.writeMessage(msg))
+		msg.AppendContent(" world")
+		require.NoError(t, w.writeMessage(msg))
+		msg.AddToolCall(message.ToolCall{ID: "t1", Name: "ls", Input: `{"path":"."}`, Finished: true})
+		require.NoError(t, w.writeMessage(msg))
+		msg.AddFinish(message.FinishReasonToolUse, "", "")
+		require.NoError(t, w.writeMessage(msg))
+		// Repeated updates don't repeat events.
+		require.NoError(t, w.writeMessage(msg))
+		require.NoError(t, w.writeMessage(toolMsg))
+		require.NoError(t, w.writeMessage(toolMsg))
+		// Other sessions are ignored.
+		require.NoError(t, w.writeMessage(message.Message{ID: "x", Role: message.Tool, SessionID: "s2"}))
+
+		require.NoError(t, w.writeResult(session.Session{ID: "s1", PromptTokens: 10, CompletionTokens: 5, Cost: 0.5}, nil))
+		return decodeEvents(t, buf.Bytes())
+	}
+
+	events := run(OutputFormatStreamJSON)
+	require.Equal(t, []EventType{
+		EventSession,
+		EventTextDelta,
+		EventTextDelta,
+		EventToolCall,
+		EventText,
+		EventFinish,
+		EventToolResult,
+		EventResult,
+	}, eventTypes(events))
+	require.Equal(t, " world", events[2].Text)
+	require.JSONEq(t, `{"path":"."}`, string(events[3].ToolCall.Input))
+	require.Equal(t, "Hello world", events[4].Text)
+	require.Equal(t, message.FinishReasonToolUse, events[5].Finish.Reason)
+	require.Equal(t, "main.go", events[6].ToolResult.Content)
+	require.Equal(t, int64(10), events[7].Usage.PromptTokens)
+	require.Equal(t, 0.5, *events[7].Cost)
+	for _, e := range events {
+		require.Equal(t, "s1", e.SessionID)
+	}
+
+	events = run(OutputFormatJSON)
+	require.Equal(t, []EventType{
+		EventSession,
+		EventToolCall,
+		EventText,
+		EventFinish,
+		EventToolResult,
+		EventResult,
+	}, eventTypes(events))
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	format, err := ParseOutputFormat("")
+	require.NoError(t, err)
+	require.Equal(t, OutputFormatText, format)
+
+	format, err = ParseOutputFormat("stream-json")
+	require.NoError(t, err)
+	require.Equal(t, OutputFormatStreamJSON, format)
+
+	_, err = ParseOutputFormat("yaml")
+	require.Error(t, err)
+}
+
+func TestEventWriterSkipsExistingMessages(t *testing.T) {
+	t.Parallel()
+
+	old := message.Message{ID: "m1", Role: message.Assistant, SessionID: "s1"}
+	old.AppendContent("from a previous run")
+	old.AddFinish(message.FinishReasonEndTurn, "", "")
+
+	var buf bytes.Buffer
+	w := newEventWriter(&buf, "s1", OutputFormatJSON)
+	w.skip([]message.Message{old})
+	require.NoError(t, w.writeMessage(old))
+	require.Empty(t, buf.String())
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+)
+
+// ErrNoCheckpoint is returned when rewinding to a message that has no
+// checkpoint, e.g. one sent before checkpoints existed.
+var ErrNoCheckpoint = errors.New("no checkpoint for this message")
+
+// RewindResult describes what rewinding a session changed.
+type RewindResult struct {
+	// Restored are the files written back to their checkpoint version.
+	Restored []string `json:"restored"`
+	// Removed are the files created after the checkpoint, which were deleted.
+	Removed []string `json:"removed"`
+	// DeletedMessages is the number of messages removed from the session.
+	DeletedMessages int `json:"deleted_messages"`
+	// Prompt is the text of the rewound message when the conversation was
+	// truncated, so it can be edited and sent again.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// Rewind restores every file edited after the given user message to the
+// version it had when the message was sent. If truncate is set, the message
+// and everything after it are removed from the session.
+func (app *App) Rewind(ctx context.Context, sessionID, messageID string, truncate bool) (RewindResult, error) {
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return RewindResult{}, errors.New("cannot rewind while the agent is working")
+	}
+	return Rewind(ctx, app.History, app.Messages, sessionID, messageID, truncate)
+}
+
+// Rewind is [App.Rewind] for callers that only have the services, like the
+// CLI.
+func Rewind(ctx context.Context, files history.Service, messages message.Service, sessionID, messageID string, truncate bool) (RewindResult, error) {
+	msg, err := messages.Get(ctx, messageID)
+	if err != nil {
+		return RewindResult{}, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.SessionID != sessionID || msg.Role != message.User {
+		return RewindResult{}, fmt.Errorf("message %s is not a user message of session %s", messageID, sessionID)
+	}
+
+	checkpoint, err := files.GetCheckpoint(ctx, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RewindResult{}, ErrNoCheckpoint
+	}
+	if err != nil {
+		return RewindResult{}, fmt.Errorf("failed to get checkpoint: %w", err)
+	}
+	changed, err := files.ChangedSince(ctx, checkpoint)
+	if err != nil {
+		return RewindResult{}, fmt.Errorf("failed to list changed files: %w", err)
+	}
+
+	var result RewindResult
+	for _, file := range changed {
+		// The version to restore is the initial one of a file the agent
+		// created, so it didn't exist at the checkpoint.
+		if file.IsNew {
+			if err := os.Remove(file.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return result, fmt.Errorf("failed to remove %s: %w", file.Path, err)
+			}
+			result.Removed = append(result.Removed, file.Path)
+		} else {
+			if err := restoreFile(file.Path, file.Content); err != nil {
+				return result, fmt.Errorf("failed to restore %s: %w", file.Path, err)
+			}
+			result.Restored = append(result.Restored, file.Path)
+		}
+		if _, err := files.CreateVersion(ctx, sessionID, file.Path, file.Content); err != nil {
+			return result, fmt.Errorf("failed to record version of %s: %w", file.Path, err)
+		}
+	}
+
+	if !truncate {
+		return result, nil
+	}
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list messages: %w", err)
+	}
+	idx := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if idx < 0 {
+		return result, nil
+	}
+	for _, m := range msgs[idx:] {
+		if err := messages.Delete(ctx, m.ID); err != nil {
+			return result, fmt.Errorf("failed to delete message: %w", err)
+		}
+		result.DeletedMessages++
+	}
+	result.Prompt = msg.Content().Text
+	return result, nil
+}
+
+// restoreFile writes content to path, keeping the file's mode if it exists.
+func restoreFile(path, content string) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), mode)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := message.NewService(q)
+	files := history.NewService(q, conn)
+
+	sess, err := sessions.Create(ctx, "test")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	created := filepath.Join(dir, "new.go")
+	fromSubAgent := filepath.Join(dir, "task.go")
+	empty := filepath.Join(dir, "doc.go")
+	require.NoError(t, os.WriteFile(existing, []byte("v1"), 0o600))
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+
+	// userMessage sends a prompt, checkpointing like the agent does.
+	userMessage := func(text string) message.Message {
+		msg, err := messages.Create(ctx, sess.ID, message.CreateMessageParams{
+			Role:  message.User,
+			Parts: []message.ContentPart{message.TextContent{Text: text}},
+		})
+		require.NoError(t, err)
+		_, err = files.CreateCheckpoint(ctx, sess.ID, msg.ID)
+		require.NoError(t, err)
+		return msg
+	}
+	// edit records an edit the way the edit tools do and applies it.
+	edit := func(sessionID, path, before, after string) {
+		_, err := files.GetByPathAndSession(ctx, path, sessionID)
+		if err != nil {
+			if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+				_, err = files.CreateNew(ctx, sessionID, path)
+			} else {
+				_, err = files.Create(ctx, sessionID, path, before)
+			}
+			require.NoError(t, err)
+		}
+		_, err = files.CreateVersion(ctx, sessionID, path, after)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte(after), 0o600))
+	}
+
+	first := userMessage("first")
+	edit(sess.ID, existing, "v1", "v2")
+
+	second := userMessage("second")
+	edit(sess.ID, existing, "v2", "v3")
+	edit(sess.ID, created, "", "new")
+	edit(sess.ID, empty, "", "package main")
+	task, err := sessions.CreateTaskSession(ctx, "call-1", sess.ID, "task")
+	require.NoError(t, err)
+	edit(task.ID, fromSubAgent, "", "task")
+
+	result, err := Rewind(ctx, files, messages, sess.ID, second.ID, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{existing, empty}, result.Restored)
+	require.ElementsMatch(t, []string{created, fromSubAgent}, result.Removed)
+	require.Zero(t, result.DeletedMessages)
+	requireContent(t, existing, "v2")
+	requireContent(t, empty, "")
+	require.NoFileExists(t, created)
+	require.NoFileExists(t, fromSubAgent)
+
+	result, err = Rewind(ctx, files, messages, sess.ID, first.ID, true)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{existing, empty}, result.Restored)
+	require.Equal(t, 2, result.DeletedMessages)
+	require.Equal(t, "first", result.Prompt)
+	requireContent(t, existing, "v1")
+	msgs, err := messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, msgs)
+
+	_, err = Rewind(ctx, files, messages, sess.ID, first.ID, false)
+	require.Error(t, err)
+}
+
+func TestRewindWithoutCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := message.NewService(q)
+
+	sess, err := sessions.Create(ctx, "test")
+	require.NoError(t, err)
+	msg, err := messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hi"}},
+	})
+	require.NoError(t, err)
+
+	_, err = Rewind(ctx, history.NewService(q, conn), messages, sess.ID, msg.ID, false)
+	require.ErrorIs(t, err, ErrNoCheckpoint)
+}
+
+func requireContent(t *testing.T, path, content string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+}
@@ -0,0 +1,177 @@
+// Package budget checks what the agent spends against the budgets set in the
+// configuration.
+package budget
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/session"
+)
+
+// WarnRatio is the share of a budget after which it's shown as running out.
+const WarnRatio = 0.8
+
+// Scope is what a budget applies to.
+type Scope string
+
+const (
+	ScopeRun     Scope = "run"
+	ScopeSession Scope = "session"
+	ScopeDaily   Scope = "daily"
+	ScopeProject Scope = "project"
+)
+
+// Status is the usage of a scope against its budget.
+type Status struct {
+	Scope  Scope
+	Usage  session.Usage
+	Budget config.Budget
+}
+
+// Ratio returns the share of the budget that is used, 1 meaning all of it.
+// The cost and the tokens are compared separately and the highest counts.
+func (s Status) Ratio() float64 {
+	return max(s.costRatio(), s.tokensRatio())
+}
+
+func (s Status) costRatio() float64 {
+	if s.Budget.MaxCost <= 0 {
+		return 0
+	}
+	return s.Usage.Cost / s.Budget.MaxCost
+}
+
+func (s Status) tokensRatio() float64 {
+	if s.Budget.MaxTokens <= 0 {
+		return 0
+	}
+	return float64(s.Usage.Tokens) / float64(s.Budget.MaxTokens)
+}
+
+// Exceeded reports whether the budget is used up.
+func (s Status) Exceeded() bool {
+	return s.Ratio() >= 1
+}
+
+// Warn reports whether the budget is running out.
+func (s Status) Warn() bool {
+	return s.Ratio() >= WarnRatio
+}
+
+// String describes the most used limit of the budget, e.g. "daily budget:
+// $4.10 of $5.00".
+func (s Status) String() string {
+	return fmt.Sprintf("%s budget: %s", s.Scope, s.Used())
+}
+
+// Used describes the most used limit of the budget, e.g. "$4.10 of $5.00".
+func (s Status) Used() string {
+	if s.Budget.MaxTokens > 0 && (s.Budget.MaxCost <= 0 || s.tokensRatio() > s.costRatio()) {
+		return fmt.Sprintf("%d of %d tokens", s.Usage.Tokens, s.Budget.MaxTokens)
+	}
+	return fmt.Sprintf("$%.2f of $%.2f", s.Usage.Cost, s.Budget.MaxCost)
+}
+
+// ExceededError is returned when a budget is used up.
+type ExceededError struct {
+	Status Status
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s budget exceeded: %s", e.Status.Scope, e.Status.Used())
+}
+
+// Exceeded returns an *ExceededError for the first used up budget of the
+// statuses, or nil.
+func Exceeded(statuses []Status) error {
+	for _, s := range statuses {
+		if s.Exceeded() {
+			return &ExceededError{Status: s}
+		}
+	}
+	return nil
+}
+
+// Checker loads the usage of the budgets of the configuration.
+type Checker struct {
+	cfg      *config.Config
+	sessions session.Service
+	now      func() time.Time
+	// started is when the run began, for the run budget.
+	started time.Time
+}
+
+// NewChecker returns a checker of the budgets of the configuration. The
+// budgets are read on every check, so they can change while running. The
+// run budget counts what was spent since the checker was created. A nil
+// checker has no budgets.
+func NewChecker(cfg *config.Config, sessions session.Service) *Checker {
+	return &Checker{cfg: cfg, sessions: sessions, now: time.Now, started: time.Now()}
+}
+
+// Enabled reports whether any cost or token budget is set.
+func (c *Checker) Enabled() bool {
+	b := c.budgets()
+	return b.Run != nil || b.Session != nil || b.Daily != nil || b.Project != nil
+}
+
+// MaxStepsPerTurn returns the maximum number of agent steps in a turn, or 0
+// when there is no limit.
+func (c *Checker) MaxStepsPerTurn() int {
+	return c.budgets().MaxStepsPerTurn
+}
+
+// Check returns the status of the budgets set for the session, the most used
+// first. The usage of the session includes its sub-agents, and the daily and
+// project usage includes the session. Sub-agent sessions are checked against
+// their parent session.
+func (c *Checker) Check(ctx context.Context, sess session.Session) ([]Status, error) {
+	b := c.budgets()
+	sessionID := cmp.Or(sess.ParentSessionID, sess.ID)
+	var statuses []Status
+	if b.Run != nil {
+		usage, err := c.sessions.SessionUsageSince(ctx, sessionID, c.started)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{Scope: ScopeRun, Usage: usage, Budget: *b.Run})
+	}
+	if b.Session != nil {
+		usage, err := c.sessions.SessionUsageSince(ctx, sessionID, time.Unix(0, 0))
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{Scope: ScopeSession, Usage: usage, Budget: *b.Session})
+	}
+	if b.Daily != nil {
+		now := c.now()
+		usage, err := c.sessions.UsageSince(ctx, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{Scope: ScopeDaily, Usage: usage, Budget: *b.Daily})
+	}
+	if b.Project != nil {
+		usage, err := c.sessions.UsageSince(ctx, time.Unix(0, 0))
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, Status{Scope: ScopeProject, Usage: usage, Budget: *b.Project})
+	}
+	slices.SortStableFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(b.Ratio(), a.Ratio())
+	})
+	return statuses, nil
+}
+
+func (c *Checker) budgets() config.Budgets {
+	if c == nil || c.cfg == nil || c.cfg.Options == nil || c.cfg.Options.Budgets == nil {
+		return config.Budgets{}
+	}
+	return *c.cfg.Options.Budgets
+}
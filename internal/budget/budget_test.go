@@ -0,0 +1,155 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		status   Status
+		ratio    float64
+		warn     bool
+		exceeded bool
+		used     string
+	}{
+		{
+			name:   "no limits",
+			status: Status{Usage: session.Usage{Cost: 10, Tokens: 1000}},
+			used:   "$10.00 of $0.00",
+		},
+		{
+			name: "cost",
+			status: Status{
+				Usage:  session.Usage{Cost: 4.25, Tokens: 1000},
+				Budget: config.Budget{MaxCost: 5},
+			},
+			ratio: 0.85,
+			warn:  true,
+			used:  "$4.25 of $5.00",
+		},
+		{
+			name: "tokens",
+			status: Status{
+				Usage:  session.Usage{Cost: 4, Tokens: 500},
+				Budget: config.Budget{MaxTokens: 1000},
+			},
+			ratio: 0.5,
+			used:  "500 of 1000 tokens",
+		},
+		{
+			name: "most used limit counts",
+			status: Status{
+				Usage:  session.Usage{Cost: 1, Tokens: 1200},
+				Budget: config.Budget{MaxCost: 5, MaxTokens: 1000},
+			},
+			ratio:    1.2,
+			warn:     true,
+			exceeded: true,
+			used:     "1200 of 1000 tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.InDelta(t, tt.ratio, tt.status.Ratio(), 1e-9)
+			require.Equal(t, tt.warn, tt.status.Warn())
+			require.Equal(t, tt.exceeded, tt.status.Exceeded())
+			require.Equal(t, tt.used, tt.status.Used())
+		})
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	t.Parallel()
+
+	ok := Status{Scope: ScopeSession, Usage: session.Usage{Cost: 1}, Budget: config.Budget{MaxCost: 5}}
+	used := Status{Scope: ScopeDaily, Usage: session.Usage{Cost: 5}, Budget: config.Budget{MaxCost: 5}}
+	require.NoError(t, Exceeded(nil))
+	require.NoError(t, Exceeded([]Status{ok}))
+
+	err := Exceeded([]Status{ok, used})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, ScopeDaily, exceeded.Status.Scope)
+	require.EqualError(t, err, "daily budget exceeded: $5.00 of $5.00")
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	sessions := session.NewService(db.New(conn), conn)
+
+	spend := func(sessionID string, cost float64, tokens int64) {
+		require.NoError(t, sessions.RecordUsage(ctx, sessionID, session.Usage{Cost: cost, Tokens: tokens}))
+	}
+	age := func(sessionID string) {
+		_, err := conn.ExecContext(ctx, "UPDATE session_usage SET created_at = ? WHERE session_id = ?", time.Now().AddDate(0, 0, -2).Unix(), sessionID)
+		require.NoError(t, err)
+	}
+	old, err := sessions.Create(ctx, "Old")
+	require.NoError(t, err)
+	spend(old.ID, 3, 3000)
+	age(old.ID)
+	// What deleted sessions spent still counts.
+	require.NoError(t, sessions.Delete(ctx, old.ID))
+
+	current, err := sessions.Create(ctx, "Session")
+	require.NoError(t, err)
+	spend(current.ID, 0.5, 500)
+	age(current.ID)
+	spend(current.ID, 1, 1000)
+	task, err := sessions.CreateTaskSession(ctx, "task", current.ID, "Task")
+	require.NoError(t, err)
+	spend(task.ID, 2, 200)
+
+	cfg := &config.Config{Options: &config.Options{}}
+	checker := NewChecker(cfg, sessions)
+	checker.started = time.Now().Add(-time.Hour)
+	require.False(t, checker.Enabled())
+	statuses, err := checker.Check(ctx, current)
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+
+	cfg.Options.Budgets = &config.Budgets{
+		Run:             &config.Budget{MaxCost: 4},
+		Session:         &config.Budget{MaxCost: 10},
+		Daily:           &config.Budget{MaxTokens: 2000},
+		Project:         &config.Budget{MaxCost: 10},
+		MaxStepsPerTurn: 20,
+	}
+	require.True(t, checker.Enabled())
+	require.Equal(t, 20, checker.MaxStepsPerTurn())
+	statuses, err = checker.Check(ctx, current)
+	require.NoError(t, err)
+	// The session includes its sub-agents, and the run only what was spent
+	// since it started.
+	require.Equal(t, []Status{
+		{Scope: ScopeRun, Usage: session.Usage{Cost: 3, Tokens: 1200}, Budget: config.Budget{MaxCost: 4}},
+		{Scope: ScopeProject, Usage: session.Usage{Cost: 6.5, Tokens: 4700}, Budget: config.Budget{MaxCost: 10}},
+		{Scope: ScopeDaily, Usage: session.Usage{Cost: 3, Tokens: 1200}, Budget: config.Budget{MaxTokens: 2000}},
+		{Scope: ScopeSession, Usage: session.Usage{Cost: 3.5, Tokens: 1700}, Budget: config.Budget{MaxCost: 10}},
+	}, statuses)
+	require.NoError(t, Exceeded(statuses))
+
+	// Sub-agents are checked against their parent session.
+	taskStatuses, err := checker.Check(ctx, task)
+	require.NoError(t, err)
+	require.Equal(t, statuses, taskStatuses)
+
+	var nilChecker *Checker
+	require.False(t, nilChecker.Enabled())
+	require.Zero(t, nilChecker.MaxStepsPerTurn())
+}
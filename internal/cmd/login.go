@@ -10,6 +10,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/atotto/clipboard"
 	hyperp "github.com/charmbracelet/brush/internal/agent/hyper"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/oauth"
 	"github.com/charmbracelet/brush/internal/oauth/copilot"
@@ -20,25 +21,30 @@ import (
 
 var loginCmd = &cobra.Command{
 	Aliases: []string{"auth"},
-	Use:     "login [platform]",
+	Use:     "login [platform] [mcp server]",
 	Short:   "Login Crush to a platform",
 	Long: `Login Crush to a specified platform.
 The platform should be provided as an argument.
-Available platforms are: hyper, copilot.`,
+Available platforms are: hyper, copilot, mcp.
+Use mcp with the name of an HTTP or SSE MCP server that requires OAuth.`,
 	Example: `
 # Authenticate with Charm Hyper
 crush login
 
 # Authenticate with GitHub Copilot
 crush login copilot
+
+# Authenticate with an MCP server
+crush login mcp github
   `,
 	ValidArgs: []cobra.Completion{
 		"hyper",
 		"copilot",
 		"github",
 		"github-copilot",
+		"mcp",
 	},
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupAppWithProgressBar(cmd)
 		if err != nil {
@@ -55,6 +61,11 @@ crush login copilot
 			return loginHyper()
 		case "copilot", "github", "github-copilot":
 			return loginCopilot()
+		case "mcp":
+			if len(args) < 2 {
+				return fmt.Errorf("missing mcp server name")
+			}
+			return loginMCP(args[1])
 		default:
 			return fmt.Errorf("unknown platform: %s", args[0])
 		}
@@ -190,6 +201,30 @@ func loginCopilot() error {
 	return nil
 }
 
+func loginMCP(name string) error {
+	ctx := getLoginContext()
+
+	err := mcp.Login(ctx, config.Get(), name, func(url string) error {
+		fmt.Println()
+		fmt.Println("Open the following URL to log in to " + name + ":")
+		fmt.Println()
+		fmt.Println(lipgloss.NewStyle().Hyperlink(url, "id=mcp").Render(url))
+		fmt.Println()
+		if err := browser.OpenURL(url); err != nil {
+			fmt.Println("Could not open the URL. You'll need to manually open the URL in your browser.")
+		}
+		fmt.Println("Waiting for authorization...")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("You're now logged in to %s!\n", name)
+	return nil
+}
+
 func getLoginContext() context.Context {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	go func() {
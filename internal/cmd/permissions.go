@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Manage remembered permissions",
+	Long:  "List and revoke the permission grants remembered for the current project",
+}
+
+var permissionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List remembered permissions",
+	Example: `
+# List all remembered permissions in a table
+brush permissions list
+
+# Output remembered permissions as JSON
+brush permissions list --json
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		grants, err := permission.NewPermissionService("", false, nil, nil, db.New(conn)).ListGrants(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list permissions: %w", err)
+		}
+
+		if jsonOutput {
+			// List no grants as [] rather than null.
+			if grants == nil {
+				grants = []permission.Grant{}
+			}
+			output := struct {
+				Grants []permission.Grant `json:"grants"`
+			}{Grants: grants}
+
+			data, err := json.Marshal(output)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(grants) == 0 {
+			cmd.Println("No remembered permissions.")
+			return nil
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("ID", "Tool", "Action", "Path", "Scope", "Created")
+
+			for _, g := range grants {
+				t.Row(g.ID, g.ToolName, g.Action, g.Path, grantScope(g), time.Unix(g.CreatedAt, 0).Local().Format("2006-01-02 15:04"))
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+
+		for _, g := range grants {
+			cmd.Printf("%s\t%s\t%s\t%s\t%s\t%s\n", g.ID, g.ToolName, g.Action, g.Path, grantScope(g), time.Unix(g.CreatedAt, 0).Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+var permissionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke a remembered permission",
+	Example: `
+# Revoke a permission by its ID, as shown by "brush permissions list"
+brush permissions revoke 0f1c9e2a-...
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if err := permission.NewPermissionService("", false, nil, nil, db.New(conn)).RevokeGrant(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to revoke permission: %w", err)
+		}
+		cmd.Printf("Revoked permission %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	permissionsListCmd.Flags().Bool("json", false, "Output as JSON")
+	permissionsCmd.AddCommand(permissionsListCmd, permissionsRevokeCmd)
+}
+
+// connectProjectDB opens the database of the project in the working
+// directory, honoring the --cwd and --data-dir flags.
+func connectProjectDB(cmd *cobra.Command) (*sql.DB, error) {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataDir == "" {
+		cfg, err := config.Init(cwd, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize config: %w", err)
+		}
+		dataDir = cfg.Options.DataDirectory
+	}
+
+	conn, err := db.Connect(cmd.Context(), dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return conn, nil
+}
+
+func grantScope(g permission.Grant) string {
+	if g.SessionID != "" {
+		return "session " + g.SessionID
+	}
+	return "project"
+}
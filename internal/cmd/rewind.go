@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/brush/internal/app"
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/spf13/cobra"
+)
+
+var rewindCmd = &cobra.Command{
+	Use:   "rewind",
+	Short: "Rewind files to a user message",
+	Long: `Restore every file edited after a user message to the version it had
+when the message was sent. With --truncate, the message and everything after
+it are also removed from the session.`,
+	Example: `
+# Restore the files as they were when the message was sent
+brush rewind --session <session-id> --message <message-id>
+
+# Also remove the message and the rest of the conversation
+brush rewind --session <session-id> --message <message-id> --truncate
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		sessionID, _ := cmd.Flags().GetString("session")
+		messageID, _ := cmd.Flags().GetString("message")
+		truncate, _ := cmd.Flags().GetBool("truncate")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		result, err := app.Rewind(cmd.Context(), history.NewService(q, conn), message.NewService(q), sessionID, messageID, truncate)
+		if err != nil {
+			return fmt.Errorf("failed to rewind: %w", err)
+		}
+
+		if jsonOutput {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		for _, path := range result.Restored {
+			cmd.Printf("Restored %s\n", path)
+		}
+		for _, path := range result.Removed {
+			cmd.Printf("Removed %s\n", path)
+		}
+		if len(result.Restored)+len(result.Removed) == 0 {
+			cmd.Println("No files changed since that message.")
+		}
+		if truncate {
+			cmd.Printf("Deleted %d messages\n", result.DeletedMessages)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rewindCmd.Flags().StringP("session", "s", "", "ID of the session to rewind")
+	rewindCmd.Flags().StringP("message", "m", "", "ID of the user message to rewind to")
+	rewindCmd.Flags().Bool("truncate", false, "Also delete the message and every message after it")
+	rewindCmd.Flags().Bool("json", false, "Output as JSON")
+	_ = rewindCmd.MarkFlagRequired("session")
+	_ = rewindCmd.MarkFlagRequired("message")
+}
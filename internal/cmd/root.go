@@ -15,6 +15,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/app"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/db"
@@ -53,6 +54,10 @@ func init() {
 		schemaCmd,
 		loginCmd,
 		statsCmd,
+		permissionsCmd,
+		serveCmd,
+		rewindCmd,
+		sessionsCmd,
 	)
 }
 
@@ -101,6 +106,7 @@ brush -y
 			ui := ui.New(com)
 			ui.QueryCapabilities = shouldQueryCapabilities(env)
 			model = ui
+			mcp.EnableElicitation()
 		} else {
 			ui := tui.New(app)
 			ui.QueryVersion = shouldQueryCapabilities(env)
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"strings"
 
+	"github.com/charmbracelet/brush/internal/app"
 	"github.com/charmbracelet/brush/internal/event"
 	"github.com/spf13/cobra"
 )
@@ -29,23 +30,47 @@ crush run "What is this code doing?" <<< prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Continue the most recent session
+crush run --continue "Now add tests for it"
+
+# Resume a specific session, using the ID printed by a previous run
+crush run --session 2f6c4c57-... "What did we change?"
+
+# Print newline-delimited JSON events, including text deltas
+crush run --output-format stream-json "List the TODOs in this project"
+
+# Stop once the run costs more than $2
+crush run --max-cost 2 "Fix the failing tests"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
 		largeModel, _ := cmd.Flags().GetString("model")
 		smallModel, _ := cmd.Flags().GetString("small-model")
+		outputFormat, _ := cmd.Flags().GetString("output-format")
+		sessionID, _ := cmd.Flags().GetString("session")
+		continueLast, _ := cmd.Flags().GetBool("continue")
+		maxCost, _ := cmd.Flags().GetFloat64("max-cost")
+
+		format, err := app.ParseOutputFormat(outputFormat)
+		if err != nil {
+			return err
+		}
+		if maxCost < 0 {
+			return fmt.Errorf("--max-cost must not be negative")
+		}
 
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 		defer cancel()
 
-		app, err := setupApp(cmd)
+		appInstance, err := setupApp(cmd)
 		if err != nil {
 			return err
 		}
-		defer app.Shutdown()
+		defer appInstance.Shutdown()
 
-		if !app.Config().IsConfigured() {
+		if !appInstance.Config().IsConfigured() {
 			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
 		}
 
@@ -64,7 +89,15 @@ crush run --quiet "Generate a README for this project"
 		event.SetNonInteractive(true)
 		event.AppInitialized()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, largeModel, smallModel, quiet)
+		return appInstance.RunNonInteractive(ctx, os.Stdout, prompt, app.RunOptions{
+			LargeModel:   largeModel,
+			SmallModel:   smallModel,
+			Quiet:        quiet,
+			SessionID:    sessionID,
+			Continue:     continueLast,
+			OutputFormat: format,
+			MaxCost:      maxCost,
+		})
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
@@ -75,4 +108,9 @@ func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
 	runCmd.Flags().StringP("model", "m", "", "Model to use. Accepts 'model' or 'provider/model' to disambiguate models with the same name across providers")
 	runCmd.Flags().String("small-model", "", "Small model to use. If not provided, uses the default small model for the provider")
+	runCmd.Flags().StringP("session", "s", "", "Resume the session with the given ID")
+	runCmd.Flags().BoolP("continue", "C", false, "Continue the most recent session")
+	runCmd.MarkFlagsMutuallyExclusive("session", "continue")
+	runCmd.Flags().Float64("max-cost", 0, "Stop once the run costs more than this many dollars, whatever the session spent before")
+	runCmd.Flags().String("output-format", string(app.OutputFormatText), "Output format: text, json or stream-json (newline-delimited JSON events)")
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/charmbracelet/brush/internal/event"
+	"github.com/charmbracelet/brush/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the agent over a local HTTP API",
+	Long: `Start a headless server that exposes sessions, prompts, permissions
+and live events over HTTP, so editors and other tools can drive the agent.
+
+By default the server listens on a unix socket in the data directory. Use
+--addr to listen on a TCP address instead, in which case requests must send
+the token set with --token, or the one printed at startup, as a bearer token.`,
+	Example: `
+# Listen on the default unix socket
+crush serve
+
+# Listen on a custom socket
+crush serve --socket /tmp/crush.sock
+
+# Listen on localhost
+crush serve --addr 127.0.0.1:7777 --token "$BRUSH_TOKEN"
+
+# Follow events over TCP
+curl -N -H "Authorization: Bearer $BRUSH_TOKEN" http://127.0.0.1:7777/v1/events
+
+# Follow events from the default socket
+curl -N --unix-socket .brush/brush.sock http://crush/v1/events
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		socket, _ := cmd.Flags().GetString("socket")
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		appInstance, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer appInstance.Shutdown()
+
+		if !appInstance.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		if addr == "" && socket == "" {
+			socket = filepath.Join(appInstance.Config().Options.DataDirectory, "brush.sock")
+		}
+
+		listener, err := listen(ctx, addr, socket)
+		if err != nil {
+			return err
+		}
+		if socket != "" && addr == "" {
+			defer os.Remove(socket)
+		}
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		fmt.Fprintf(os.Stderr, "Listening on %s\n", listener.Addr())
+		if addr == "" {
+			token = ""
+		} else if token == "" {
+			// Anyone on the machine can connect to a TCP port.
+			token = rand.Text()
+			fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+		}
+		return server.New(appInstance, token).Serve(ctx, listener)
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+// listen listens on addr if set, otherwise on the unix socket, removing a
+// stale socket file left behind by a previous server.
+func listen(ctx context.Context, addr, socket string) (net.Listener, error) {
+	var lc net.ListenConfig
+	if addr != "" {
+		return lc.Listen(ctx, "tcp", addr)
+	}
+	if err := os.Remove(socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	l, err := lc.Listen(ctx, "unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	// Only the current user may talk to the agent.
+	if err := os.Chmod(socket, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func init() {
+	serveCmd.Flags().String("socket", "", "Unix socket to listen on (default: brush.sock in the data directory)")
+	serveCmd.Flags().String("addr", "", "TCP address to listen on instead of a unix socket, e.g. 127.0.0.1:7777")
+	serveCmd.Flags().String("token", "", "Bearer token required with --addr (default: a random token printed at startup)")
+	serveCmd.MarkFlagsMutuallyExclusive("socket", "addr")
+	serveCmd.MarkFlagsMutuallyExclusive("socket", "token")
+}
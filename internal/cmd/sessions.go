@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/charmbracelet/brush/internal/transcript"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+//go:embed sessions/index.html
+var sessionTemplate string
+
+//go:embed sessions/index.css
+var sessionCSS string
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage sessions",
+	Long:  "Search, export and import the sessions of the current project",
+}
+
+var sessionsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search sessions and messages",
+	Long: `Search the session titles, messages and tool calls of the current project.
+Results contain every word of the query, best matches first.`,
+	Example: `
+# Find the conversation about a migration bug
+brush sessions search migration bug
+
+# Output the matches as JSON
+brush sessions search --json migration bug
+  `,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		results, err := session.NewService(db.New(conn), conn).Search(cmd.Context(), strings.Join(args, " "), limit)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			output := struct {
+				Results []session.SearchResult `json:"results"`
+			}{Results: results}
+
+			data, err := json.Marshal(output)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(results) == 0 {
+			cmd.Println("No matches.")
+			return nil
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			match := lipgloss.NewStyle().Bold(true)
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("Session", "Title", "Message", "Match", "Updated")
+
+			for _, r := range results {
+				t.Row(r.SessionID, r.SessionTitle, r.MessageID, highlightSnippet(r, match), time.Unix(r.UpdatedAt, 0).Local().Format("2006-01-02 15:04"))
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+
+		for _, r := range results {
+			cmd.Printf("%s\t%s\t%s\t%s\n", r.SessionID, r.MessageID, r.SessionTitle, r.Snippet)
+		}
+		return nil
+	},
+}
+
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <session-id>",
+	Short: "Export a session",
+	Long: `Export a session with its messages, tool calls and results, file changes,
+and usage. Markdown and HTML are for reading; JSON can be imported again with
+"brush sessions import".`,
+	Example: `
+# Print a session as Markdown
+brush sessions export <session-id>
+
+# Save a session as a self-contained HTML page
+brush sessions export <session-id> --format html -o session.html
+
+# Move a session to another machine
+brush sessions export <session-id> --format json -o session.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+
+		var render func(io.Writer, transcript.Transcript) error
+		switch format {
+		case "md", "markdown":
+			render = transcript.Markdown
+		case "json":
+			render = renderTranscriptJSON
+		case "html":
+			render = renderTranscriptHTML
+		default:
+			return fmt.Errorf("unknown format %q, expected md, json or html", format)
+		}
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		t, err := transcript.Load(cmd.Context(), session.NewService(q, conn), message.NewService(q), history.NewService(q, conn), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to export session: %w", err)
+		}
+
+		var buf bytes.Buffer
+		if err := render(&buf, t); err != nil {
+			return fmt.Errorf("failed to export session: %w", err)
+		}
+		if output == "" || output == "-" {
+			_, err = cmd.OutOrStdout().Write(buf.Bytes())
+			return err
+		}
+		return os.WriteFile(output, buf.Bytes(), 0o644)
+	},
+}
+
+var sessionsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a session",
+	Long: `Import a session exported with "brush sessions export --format json" as a new
+session of the current project. Use "-" to read from standard input.`,
+	Example: `
+# Import a session exported on another machine
+brush sessions import session.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(cmd.InOrStdin())
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return err
+		}
+		var t transcript.Transcript
+		if err := json.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("failed to read transcript: %w", err)
+		}
+
+		conn, err := connectProjectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		sess, err := transcript.Import(cmd.Context(), conn, t)
+		if err != nil {
+			return fmt.Errorf("failed to import session: %w", err)
+		}
+
+		if jsonOutput {
+			data, err := json.Marshal(sess)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+		cmd.Printf("Imported %q as session %s\n", sess.Title, sess.ID)
+		return nil
+	},
+}
+
+func init() {
+	sessionsSearchCmd.Flags().Bool("json", false, "Output as JSON")
+	sessionsSearchCmd.Flags().IntP("limit", "n", 20, "Maximum number of results")
+	sessionsExportCmd.Flags().StringP("format", "f", "md", "Output format: md, json or html")
+	sessionsExportCmd.Flags().StringP("output", "o", "", "Write to a file instead of standard output")
+	sessionsImportCmd.Flags().Bool("json", false, "Output the imported session as JSON")
+	sessionsCmd.AddCommand(sessionsSearchCmd, sessionsExportCmd, sessionsImportCmd)
+}
+
+func renderTranscriptJSON(w io.Writer, t transcript.Transcript) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
+
+// renderTranscriptHTML writes the transcript as a self-contained HTML page,
+// styled like the one of "brush stats".
+func renderTranscriptHTML(w io.Writer, t transcript.Transcript) error {
+	funcs := template.FuncMap{"diffLines": diffLines}
+	tmpl, err := template.New("session").Funcs(funcs).Parse(sessionTemplate)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	data := struct {
+		Doc        transcript.Document
+		CSS        template.CSS
+		Header     template.HTML
+		Heartbit   template.HTML
+		Footer     template.HTML
+		ExportedAt string
+	}{
+		Doc:        transcript.NewDocument(t),
+		CSS:        template.CSS(sessionCSS),
+		Header:     template.HTML(headerSVG),
+		Heartbit:   template.HTML(heartbitSVG),
+		Footer:     template.HTML(footerSVG),
+		ExportedAt: time.Unix(t.ExportedAt, 0).Format("2006-01-02"),
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+	return nil
+}
+
+type diffLine struct {
+	Class string
+	Text  string
+}
+
+// diffLines splits a unified diff into lines classed by their kind, for
+// coloring.
+func diffLines(unified string) []diffLine {
+	var lines []diffLine
+	for line := range strings.SplitSeq(unified, "\n") {
+		var class string
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			class = "file"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		}
+		lines = append(lines, diffLine{Class: class, Text: line})
+	}
+	return lines
+}
+
+// highlightSnippet renders the matches in the snippet of a search result with
+// the given style.
+func highlightSnippet(r session.SearchResult, style lipgloss.Style) string {
+	var b strings.Builder
+	var last int
+	for _, m := range r.Matches {
+		if m[0] < last || m[1] > len(r.Snippet) {
+			continue
+		}
+		b.WriteString(r.Snippet[last:m[0]])
+		b.WriteString(style.Render(r.Snippet[m[0]:m[1]]))
+		last = m[1]
+	}
+	b.WriteString(r.Snippet[last:])
+	return b.String()
+}
@@ -86,6 +86,7 @@ type ModelUsage struct {
 	Model        string `json:"model"`
 	Provider     string `json:"provider"`
 	MessageCount int64  `json:"message_count"`
+	RetryCount   int64  `json:"retry_count"`
 }
 
 type HourlyUsage struct {
@@ -223,6 +224,7 @@ func gatherStats(ctx context.Context, conn *sql.DB) (*Stats, error) {
 			Model:        m.Model,
 			Provider:     m.Provider,
 			MessageCount: m.MessageCount,
+			RetryCount:   m.RetryCount,
 		})
 	}
 
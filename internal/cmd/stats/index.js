@@ -224,6 +224,12 @@ if (stats.tool_usage?.length > 0) {
           },
           borderRadius: 4,
         },
+        {
+          label: "Retries",
+          data: displayModels.map((m) => m.retry_count || 0),
+          backgroundColor: colors.coral,
+          borderRadius: 4,
+        },
       ],
     },
     options: {
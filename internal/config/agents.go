@@ -0,0 +1,119 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// agentFrontmatter is the frontmatter of an agent markdown file.
+type agentFrontmatter struct {
+	Name         string              `yaml:"name"`
+	Description  string              `yaml:"description"`
+	Model        string              `yaml:"model"`
+	Tools        []string            `yaml:"tools"`
+	MCP          map[string][]string `yaml:"mcp"`
+	ContextPaths []string            `yaml:"context_paths"`
+	Disabled     bool                `yaml:"disabled"`
+}
+
+// AgentsDirs returns the directories agent markdown files are loaded from, in
+// order of precedence from lowest to highest.
+func (c *Config) AgentsDirs() []string {
+	dirs := []string{filepath.Join(filepath.Dir(GlobalConfig()), "agents")}
+	if c.Options != nil && c.Options.DataDirectory != "" {
+		dirs = append(dirs, filepath.Join(c.Options.DataDirectory, "agents"))
+	}
+	return dirs
+}
+
+// customAgents returns the agents declared in the config and in the agents
+// directories, keyed by their ID. Agents in files take precedence.
+func (c *Config) customAgents() map[string]Agent {
+	agents := maps.Clone(c.Agents)
+	if agents == nil {
+		agents = make(map[string]Agent)
+	}
+	for _, dir := range c.AgentsDirs() {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			agent, err := LoadAgentFile(path)
+			if err != nil {
+				slog.Warn("Failed to load agent file", "path", path, "error", err)
+				continue
+			}
+			agents[id] = agent
+		}
+	}
+	return agents
+}
+
+// LoadAgentFile loads an agent from a markdown file. The YAML frontmatter
+// configures the agent and the body is its prompt template.
+func LoadAgentFile(path string) (Agent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Agent{}, err
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return Agent{}, errors.New("no YAML frontmatter found")
+	}
+	frontmatter, body, ok := strings.Cut(strings.TrimPrefix(content, "---\n"), "\n---")
+	if !ok {
+		return Agent{}, errors.New("unclosed frontmatter")
+	}
+
+	var fm agentFrontmatter
+	if err := yaml.Unmarshal([]byte(frontmatter), &fm); err != nil {
+		return Agent{}, fmt.Errorf("parsing frontmatter: %w", err)
+	}
+	if fm.Description == "" {
+		return Agent{}, errors.New("description is required")
+	}
+	return Agent{
+		Name:         fm.Name,
+		Description:  fm.Description,
+		Disabled:     fm.Disabled,
+		Model:        SelectedModelType(fm.Model),
+		Prompt:       strings.TrimSpace(body),
+		AllowedTools: fm.Tools,
+		AllowedMCP:   fm.MCP,
+		ContextPaths: fm.ContextPaths,
+	}, nil
+}
+
+// validAgentModel reports whether the model of an agent is one of the
+// selected model types or a provider/model pair.
+func validAgentModel(model SelectedModelType) bool {
+	if model == SelectedModelTypeLarge || model == SelectedModelTypeSmall {
+		return true
+	}
+	provider, name, ok := strings.Cut(string(model), "/")
+	return ok && provider != "" && name != ""
+}
+
+// AgentModel returns the model selected for the agent, either one of the
+// selected model types or an explicit provider/model pair.
+func (c *Config) AgentModel(agent Agent) (SelectedModel, bool) {
+	modelType := cmp.Or(agent.Model, SelectedModelTypeLarge)
+	if model, ok := c.Models[modelType]; ok {
+		return model, true
+	}
+	provider, model, ok := strings.Cut(string(modelType), "/")
+	if !ok || provider == "" || model == "" {
+		return SelectedModel{}, false
+	}
+	return SelectedModel{Provider: provider, Model: model}, true
+}
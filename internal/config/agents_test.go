@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_setupAgentsWithCustomAgents(t *testing.T) {
+	t.Setenv("BRUSH_GLOBAL_CONFIG", t.TempDir())
+	dataDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "agents"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "agents", "reviewer.md"), []byte(`---
+name: Reviewer
+description: Reviews changes for bugs.
+model: small
+tools: [view, grep, agent, edit]
+context_paths: [REVIEW.md]
+---
+
+You review code in {{.WorkingDir}}.
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "agents", "broken.md"), []byte("no frontmatter"), 0o644))
+
+	cfg := &Config{
+		Options: &Options{
+			DataDirectory: dataDir,
+			DisabledTools: []string{"edit"},
+			ContextPaths:  []string{"AGENTS.md"},
+		},
+		Agents: map[string]Agent{
+			"docs": {
+				Description: "Writes documentation.",
+				Model:       "openai/gpt-5",
+				Prompt:      "You write docs.",
+			},
+			"off":    {Description: "Disabled.", Disabled: true},
+			"task":   {Description: "Can't replace the task agent."},
+			"medium": {Description: "Unknown model type.", Model: "medium"},
+		},
+	}
+	cfg.SetupAgents()
+
+	reviewer, ok := cfg.Agents["reviewer"]
+	require.True(t, ok)
+	assert.Equal(t, "reviewer", reviewer.ID)
+	assert.Equal(t, "Reviewer", reviewer.Name)
+	assert.Equal(t, "Reviews changes for bugs.", reviewer.Description)
+	assert.Equal(t, SelectedModelTypeSmall, reviewer.Model)
+	assert.Equal(t, "You review code in {{.WorkingDir}}.", reviewer.Prompt)
+	assert.Equal(t, []string{"view", "grep"}, reviewer.AllowedTools)
+	assert.Equal(t, []string{"REVIEW.md"}, reviewer.ContextPaths)
+
+	docs, ok := cfg.Agents["docs"]
+	require.True(t, ok)
+	assert.Equal(t, "docs", docs.Name)
+	assert.NotContains(t, docs.AllowedTools, "agent")
+	assert.NotContains(t, docs.AllowedTools, "edit")
+	assert.Contains(t, docs.AllowedTools, "bash")
+	assert.Equal(t, []string{"AGENTS.md"}, docs.ContextPaths)
+
+	assert.NotContains(t, cfg.Agents, "broken")
+	assert.NotContains(t, cfg.Agents, "off")
+	assert.NotContains(t, cfg.Agents, "medium")
+	assert.Equal(t, "Task", cfg.Agents[AgentTask].Name)
+
+	// Setting up the agents again keeps them the same.
+	cfg.SetupAgents()
+	assert.Equal(t, reviewer, cfg.Agents["reviewer"])
+	assert.Equal(t, docs, cfg.Agents["docs"])
+}
+
+func TestConfig_AgentModel(t *testing.T) {
+	cfg := &Config{
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeLarge: {Provider: "anthropic", Model: "claude-sonnet-4-5"},
+			SelectedModelTypeSmall: {Provider: "anthropic", Model: "claude-haiku-4-5"},
+		},
+	}
+
+	model, ok := cfg.AgentModel(Agent{})
+	require.True(t, ok)
+	assert.Equal(t, "claude-sonnet-4-5", model.Model)
+
+	model, ok = cfg.AgentModel(Agent{Model: SelectedModelTypeSmall})
+	require.True(t, ok)
+	assert.Equal(t, "claude-haiku-4-5", model.Model)
+
+	model, ok = cfg.AgentModel(Agent{Model: "openrouter/z-ai/glm-4.6"})
+	require.True(t, ok)
+	assert.Equal(t, SelectedModel{Provider: "openrouter", Model: "z-ai/glm-4.6"}, model)
+
+	_, ok = cfg.AgentModel(Agent{Model: "medium"})
+	require.False(t, ok)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// defaultDeniedCommands are the commands the bash tool refuses to run unless
+// the config allows them.
+var defaultDeniedCommands = []string{
+	// Network/Download tools
+	"alias",
+	"aria2c",
+	"axel",
+	"chrome",
+	"curl",
+	"curlie",
+	"firefox",
+	"http-prompt",
+	"httpie",
+	"links",
+	"lynx",
+	"nc",
+	"safari",
+	"scp",
+	"ssh",
+	"telnet",
+	"w3m",
+	"wget",
+	"xh",
+
+	// System administration
+	"doas",
+	"su",
+	"sudo",
+
+	// Package managers
+	"apk",
+	"apt",
+	"apt-cache",
+	"apt-get",
+	"dnf",
+	"dpkg",
+	"emerge",
+	"home-manager",
+	"makepkg",
+	"opkg",
+	"pacman",
+	"paru",
+	"pkg",
+	"pkg_add",
+	"pkg_delete",
+	"portage",
+	"rpm",
+	"yay",
+	"yum",
+	"zypper",
+
+	// System modification
+	"at",
+	"batch",
+	"chkconfig",
+	"crontab",
+	"fdisk",
+	"mkfs",
+	"mount",
+	"parted",
+	"service",
+	"systemctl",
+	"umount",
+
+	// Network configuration
+	"firewall-cmd",
+	"ifconfig",
+	"ip",
+	"iptables",
+	"netstat",
+	"pfctl",
+	"route",
+	"ufw",
+}
+
+// defaultAllowedCommands are the read-only commands the bash tool runs
+// without asking for permission.
+var defaultAllowedCommands = []string{
+	// Bash builtins and core utils
+	"cal",
+	"date",
+	"df",
+	"du",
+	"echo",
+	"free",
+	"groups",
+	"hostname",
+	"id",
+	"kill",
+	"killall",
+	"ls",
+	"printenv",
+	"ps",
+	"pwd",
+	"set",
+	"top",
+	"type",
+	"uname",
+	"unset",
+	"uptime",
+	"whatis",
+	"whereis",
+	"which",
+	"whoami",
+
+	// Git
+	"git blame",
+	"git branch",
+	"git config --get",
+	"git config --list",
+	"git describe",
+	"git diff",
+	"git grep",
+	"git log",
+	"git ls-files",
+	"git ls-remote",
+	"git remote",
+	"git rev-parse",
+	"git shortlog",
+	"git show",
+	"git status",
+	"git tag",
+}
+
+func init() {
+	if runtime.GOOS == "windows" {
+		defaultAllowedCommands = append(
+			defaultAllowedCommands,
+			// Windows-specific commands
+			"ipconfig",
+			"nslookup",
+			"ping",
+			"systeminfo",
+			"tasklist",
+			"where",
+		)
+	}
+}
+
+// DeniedCommands returns the commands the bash tool refuses to run: the
+// built-in ones, except those allowed or asked for as is in the config,
+// followed by the configured ones.
+func (t ToolBash) DeniedCommands() []string {
+	lifted := make(map[string]bool)
+	for _, pattern := range slices.Concat(t.Allow, t.Ask) {
+		lifted[normalizeCommand(pattern)] = true
+	}
+	denied := make([]string, 0, len(defaultDeniedCommands)+len(t.Deny))
+	for _, pattern := range defaultDeniedCommands {
+		if !lifted[pattern] {
+			denied = append(denied, pattern)
+		}
+	}
+	return append(denied, t.Deny...)
+}
+
+// DeniedExceptions returns the commands allowed or asked for in the config
+// that are more specific than a built-in denied command, keyed by that
+// command, like "ssh deploy" for "ssh". The bash tool runs them despite the
+// denied command, which still applies to everything else.
+func (t ToolBash) DeniedExceptions() map[string][]string {
+	exceptions := make(map[string][]string)
+	for _, pattern := range slices.Concat(t.Allow, t.Ask) {
+		name := commandName(pattern)
+		if normalizeCommand(pattern) != name && slices.Contains(defaultDeniedCommands, name) {
+			exceptions[name] = append(exceptions[name], pattern)
+		}
+	}
+	return exceptions
+}
+
+// AllowedCommands returns the commands the bash tool runs without asking for
+// permission: the built-in read-only commands and the configured ones.
+func (t ToolBash) AllowedCommands() []string {
+	return slices.Concat(defaultAllowedCommands, t.Allow)
+}
+
+// AskCommands returns the commands the bash tool always asks permission for,
+// even if they are allowed or were granted before.
+func (t ToolBash) AskCommands() []string {
+	return slices.Clone(t.Ask)
+}
+
+// normalizeCommand returns the command pattern with single spaces between
+// its words.
+func normalizeCommand(pattern string) string {
+	return strings.Join(strings.Fields(pattern), " ")
+}
+
+// commandName returns the command of a command pattern like "git push".
+func commandName(pattern string) string {
+	name, _, _ := strings.Cut(strings.TrimSpace(pattern), " ")
+	return name
+}
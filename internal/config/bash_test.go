@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolBash_Commands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		var bash ToolBash
+		require.Contains(t, bash.DeniedCommands(), "curl")
+		require.Contains(t, bash.AllowedCommands(), "git status")
+		require.Empty(t, bash.AskCommands())
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		t.Parallel()
+		bash := ToolBash{
+			Deny:  []string{"git push --force"},
+			Allow: []string{"curl", "make test"},
+			Ask:   []string{"git push", "ssh deploy"},
+		}
+		denied := bash.DeniedCommands()
+		require.NotContains(t, denied, "curl")
+		require.Contains(t, denied, "ssh")
+		require.Contains(t, denied, "wget")
+		require.Equal(t, map[string][]string{"ssh": {"ssh deploy"}}, bash.DeniedExceptions())
+		require.Equal(t, "git push --force", denied[len(denied)-1])
+		require.Contains(t, bash.AllowedCommands(), "make test")
+		require.Equal(t, []string{"git push", "ssh deploy"}, bash.AskCommands())
+	})
+}
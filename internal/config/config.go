@@ -18,6 +18,7 @@ import (
 	hyperp "github.com/charmbracelet/brush/internal/agent/hyper"
 	"github.com/charmbracelet/brush/internal/csync"
 	"github.com/charmbracelet/brush/internal/env"
+	"github.com/charmbracelet/brush/internal/home"
 	"github.com/charmbracelet/brush/internal/oauth"
 	"github.com/charmbracelet/brush/internal/oauth/copilot"
 	"github.com/charmbracelet/brush/internal/oauth/hyper"
@@ -92,6 +93,10 @@ type SelectedModel struct {
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// Models to switch to, in order, when the provider of this one can't
+	// answer. Only used for the large model.
+	Fallbacks []SelectedModel `json:"fallbacks,omitempty" jsonschema:"description=Ordered list of models to switch to when this model's provider is overloaded or rejects the request (large model only)"`
 }
 
 type ProviderConfig struct {
@@ -127,6 +132,25 @@ type ProviderConfig struct {
 
 	// The provider models
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
+
+	// How failed requests to the provider are retried.
+	Retry *RetryConfig `json:"retry,omitempty" jsonschema:"description=Retry policy for failed requests to this provider"`
+}
+
+// RetryConfig controls how failed provider requests are retried. Only
+// requests that fail before any output is streamed are retried. Zero values
+// use the defaults.
+type RetryConfig struct {
+	// Maximum number of attempts, including the first one. 1 disables retries.
+	MaxAttempts int `json:"max_attempts,omitempty" jsonschema:"description=Maximum number of attempts per request including the first one; 1 disables retries,default=4,minimum=1"`
+	// Delay before the first retry, in seconds.
+	InitialDelay float64 `json:"initial_delay,omitempty" jsonschema:"description=Delay in seconds before the first retry,default=2"`
+	// Maximum delay between retries, in seconds.
+	MaxDelay float64 `json:"max_delay,omitempty" jsonschema:"description=Maximum delay in seconds between retries,default=60"`
+	// Factor the delay is multiplied by after each retry.
+	BackoffFactor float64 `json:"backoff_factor,omitempty" jsonschema:"description=Factor the delay is multiplied by after each retry,default=2,minimum=1"`
+	// HTTP status codes that are retried.
+	StatusCodes []int `json:"status_codes,omitempty" jsonschema:"description=HTTP status codes that are retried (default: 408 409 429 500 502 503 504 529),example=429,example=529"`
 }
 
 // ToProvider converts the [ProviderConfig] to a [catwalk.Provider].
@@ -183,17 +207,37 @@ type MCPConfig struct {
 
 	// TODO: maybe make it possible to get the value from the env
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	// OAuth configures the OAuth client for HTTP/SSE MCP servers that
+	// require authorization. It's filled in at login through dynamic client
+	// registration when not configured.
+	OAuth *MCPOAuthConfig `json:"oauth_client,omitempty" jsonschema:"description=OAuth client for HTTP/SSE MCP servers that require authorization"`
+	// OAuthToken is the token obtained at login.
+	OAuthToken *oauth.Token `json:"oauth,omitempty" jsonschema:"description=OAuth2 token for authentication with the MCP server"`
+}
+
+// MCPOAuthConfig is the OAuth client used to authorize with an MCP server.
+type MCPOAuthConfig struct {
+	ClientID     string   `json:"client_id,omitempty" jsonschema:"description=OAuth client ID, registered dynamically if empty"`
+	ClientSecret string   `json:"client_secret,omitempty" jsonschema:"description=OAuth client secret for confidential clients"`
+	Scopes       []string `json:"scopes,omitempty" jsonschema:"description=Scopes to request instead of the ones advertised by the server"`
+	CallbackPort int      `json:"callback_port,omitempty" jsonschema:"description=Local port of the redirect URI, random if 0,example=8765"`
+	// TokenURL is discovered at login and used to refresh the token.
+	TokenURL string `json:"token_url,omitempty" jsonschema:"description=Token endpoint of the authorization server, discovered at login"`
 }
 
 type LSPConfig struct {
-	Disabled    bool              `json:"disabled,omitempty" jsonschema:"description=Whether this LSP server is disabled,default=false"`
-	Command     string            `json:"command,omitempty" jsonschema:"required,description=Command to execute for the LSP server,example=gopls"`
-	Args        []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the LSP server command"`
-	Env         map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set to the LSP server command"`
-	FileTypes   []string          `json:"filetypes,omitempty" jsonschema:"description=File types this LSP server handles,example=go,example=mod,example=rs,example=c,example=js,example=ts"`
-	RootMarkers []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
-	InitOptions map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
-	Options     map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+	Disabled              bool              `json:"disabled,omitempty" jsonschema:"description=Whether this LSP server is disabled,default=false"`
+	Command               string            `json:"command,omitempty" jsonschema:"required,description=Command to execute for the LSP server,example=gopls"`
+	Args                  []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the LSP server command"`
+	Env                   map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set to the LSP server command"`
+	FileTypes             []string          `json:"filetypes,omitempty" jsonschema:"description=File types this LSP server handles,example=go,example=mod,example=rs,example=c,example=js,example=ts"`
+	RootMarkers           []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
+	InitOptions           map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
+	Options               map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+	FormatOnEdit          bool              `json:"format_on_edit,omitempty" jsonschema:"description=Format files with this LSP server after the agent edits them,default=false"`
+	OrganizeImportsOnEdit bool              `json:"organize_imports_on_edit,omitempty" jsonschema:"description=Organize imports with this LSP server after the agent edits them,default=false"`
+	TabSize               int               `json:"tab_size,omitempty" jsonschema:"description=Tab size to format files with; defaults to the one set by .editorconfig files or 4,example=2"`
 }
 
 type TUIOptions struct {
@@ -216,8 +260,38 @@ func (c Completions) Limits() (depth, items int) {
 }
 
 type Permissions struct {
-	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
-	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	AllowedTools []string         `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
+	Rules        []PermissionRule `json:"rules,omitempty" jsonschema:"description=Ordered permission rules; the first matching rule decides whether a tool call is allowed, denied or prompted"`
+	SkipRequests bool             `json:"-"` // Automatically accept all permissions (YOLO mode)
+}
+
+type PermissionDecision string
+
+const (
+	PermissionAllow PermissionDecision = "allow"
+	PermissionDeny  PermissionDecision = "deny"
+	PermissionAsk   PermissionDecision = "ask"
+)
+
+type PathLocation string
+
+const (
+	PathInsideWorkingDir  PathLocation = "inside"
+	PathOutsideWorkingDir PathLocation = "outside"
+)
+
+// PermissionRule describes a single entry of the permission policy. Every
+// non-empty matcher must match for the rule to apply.
+type PermissionRule struct {
+	Decision      PermissionDecision `json:"decision" jsonschema:"required,description=What to do when the rule matches,enum=allow,enum=deny,enum=ask"`
+	Tool          string             `json:"tool,omitempty" jsonschema:"description=Tool name or glob pattern to match,example=bash,example=mcp_github_*"`
+	Action        string             `json:"action,omitempty" jsonschema:"description=Tool action to match,example=execute,example=write"`
+	Paths         []string           `json:"paths,omitempty" jsonschema:"description=Glob patterns matched against the target path; relative patterns are resolved against the working directory,example=**/*.go,example=/etc/**"`
+	Location      PathLocation       `json:"location,omitempty" jsonschema:"description=Match only paths inside or outside the working directory,enum=inside,enum=outside"`
+	CommandPrefix []string           `json:"command_prefix,omitempty" jsonschema:"description=Bash command prefixes to match; allow rules need every command of the script to match and deny or ask rules any of them,example=git push,example=make test"`
+	CommandRegex  string             `json:"command_regex,omitempty" jsonschema:"description=Regular expression matched against the bash command,example=^rm\\s+-rf"`
+	MCP           string             `json:"mcp,omitempty" jsonschema:"description=MCP server name to match,example=github"`
+	Reason        string             `json:"reason,omitempty" jsonschema:"description=Explanation returned to the model when the rule denies a call"`
 }
 
 type TrailerStyle string
@@ -243,6 +317,39 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
+type AutoCommitMode string
+
+const (
+	// AutoCommitShadow commits to a separate repository in the data
+	// directory.
+	AutoCommitShadow AutoCommitMode = "shadow"
+	// AutoCommitBranch commits to a scratch branch of the project's
+	// repository.
+	AutoCommitBranch AutoCommitMode = "branch"
+)
+
+type AutoCommit struct {
+	Enabled      bool           `json:"enabled,omitempty" jsonschema:"description=Commit the changes of every agent turn,default=false"`
+	Mode         AutoCommitMode `json:"mode,omitempty" jsonschema:"description=Where to commit: a shadow repository in the data directory or a scratch branch of the project's repository,enum=shadow,enum=branch,default=shadow"`
+	BranchPrefix string         `json:"branch_prefix,omitempty" jsonschema:"description=Prefix of the scratch branch in branch mode; the session ID is appended,default=brush/,example=wip/brush-"`
+}
+
+// Budget limits what the agent can spend. Zero values mean no limit.
+type Budget struct {
+	MaxCost   float64 `json:"max_cost,omitempty" jsonschema:"description=Maximum cost in USD,minimum=0,example=5"`
+	MaxTokens int64   `json:"max_tokens,omitempty" jsonschema:"description=Maximum number of prompt and completion tokens,minimum=0,example=2000000"`
+}
+
+type Budgets struct {
+	Session         *Budget `json:"session,omitempty" jsonschema:"description=Limits for a single session and its sub-agents"`
+	Daily           *Budget `json:"daily,omitempty" jsonschema:"description=Limits for what all the sessions spent since midnight"`
+	Project         *Budget `json:"project,omitempty" jsonschema:"description=Limits for all the sessions of the project"`
+	MaxStepsPerTurn int     `json:"max_steps_per_turn,omitempty" jsonschema:"description=Maximum number of agent steps in a single turn,minimum=0,example=50"`
+	// Run limits what a single run of the program spends, as set by the
+	// --max-cost flag of brush run.
+	Run *Budget `json:"-"`
+}
+
 type Options struct {
 	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
 	SkillsPaths               []string     `json:"skills_paths,omitempty" jsonschema:"description=Paths to directories containing Agent Skills (folders with SKILL.md files),example=~/.config/crush/skills,example=./skills"`
@@ -258,6 +365,8 @@ type Options struct {
 	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
 	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
 	TemplatesDir              string       `json:"templates_dir,omitempty" jsonschema:"description=Path to directory containing custom prompt templates (coder.md.tpl, task.md.tpl, initialize.md.tpl),example=~/.config/brush/templates"`
+	AutoCommit                *AutoCommit  `json:"auto_commit,omitempty" jsonschema:"description=Commit the changes of every agent turn to a scratch branch or a shadow repository"`
+	Budgets                   *Budgets     `json:"budgets,omitempty" jsonschema:"description=Spend limits per session, per day and per project; turns stop once a limit is reached"`
 }
 
 type MCPs map[string]MCPConfig
@@ -330,7 +439,11 @@ type Agent struct {
 	// This is the id of the system prompt used by the agent
 	Disabled bool `json:"disabled,omitempty"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	// The model type to use, or an explicit model as provider/model
+	Model SelectedModelType `json:"model,omitempty" jsonschema:"description=The model to use for this agent: large or small or a provider/model pair,default=large,example=small,example=anthropic/claude-sonnet-4-5"`
+
+	// The system prompt template of the agent, defaults to the task prompt
+	Prompt string `json:"prompt,omitempty" jsonschema:"description=System prompt of the agent as a Go template with the same data as the built-in prompts"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
@@ -347,7 +460,8 @@ type Agent struct {
 }
 
 type Tools struct {
-	Ls ToolLs `json:"ls,omitzero"`
+	Ls   ToolLs   `json:"ls,omitzero"`
+	Bash ToolBash `json:"bash,omitzero"`
 }
 
 type ToolLs struct {
@@ -359,6 +473,58 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+type ToolBash struct {
+	Deny    []string    `json:"deny,omitempty" jsonschema:"description=Commands that are never run; a command followed by the subcommands and flags to match,example=git push --force,example=rm"`
+	Allow   []string    `json:"allow,omitempty" jsonschema:"description=Commands that run without a permission prompt; also lifts the built-in deny of the same pattern,example=make test,example=curl"`
+	Ask     []string    `json:"ask,omitempty" jsonschema:"description=Commands that always prompt for permission even if allowed or granted before,example=git push"`
+	Sandbox BashSandbox `json:"sandbox,omitzero" jsonschema:"description=Linux sandbox for the commands run by the bash tool and background jobs"`
+}
+
+type BashSandbox struct {
+	Enabled       bool     `json:"enabled,omitempty" jsonschema:"description=Run commands in a sandbox that only allows writing to the working directory and temp directories,default=false"`
+	AllowNetwork  bool     `json:"allow_network,omitempty" jsonschema:"description=Allow sandboxed commands to use the network,default=false"`
+	WritablePaths []string `json:"writable_paths,omitempty" jsonschema:"description=Additional paths sandboxed commands can write to,example=~/.cache/go-build"`
+}
+
+// Paths returns the writable paths with ~ expanded and relative paths joined
+// with the working directory.
+func (s BashSandbox) Paths(workingDir string) []string {
+	paths := make([]string, len(s.WritablePaths))
+	for i, path := range s.WritablePaths {
+		path = home.Long(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+type HookEvent string
+
+const (
+	// HookPreToolUse runs before a tool is called. It can block the call or
+	// rewrite its input.
+	HookPreToolUse HookEvent = "PreToolUse"
+	// HookPostToolUse runs after a tool returned a response.
+	HookPostToolUse HookEvent = "PostToolUse"
+	// HookUserPromptSubmit runs when a prompt is submitted, before it's sent
+	// to the model.
+	HookUserPromptSubmit HookEvent = "UserPromptSubmit"
+	// HookStop runs when the agent finished responding to a prompt.
+	HookStop HookEvent = "Stop"
+	// HookSessionStart runs when the first prompt of a session is submitted.
+	HookSessionStart HookEvent = "SessionStart"
+)
+
+type Hook struct {
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regular expression matched against the tool name for tool events (empty matches all tools),example=^(edit|write|multiedit)$"`
+	Command string `json:"command" jsonschema:"required,description=Shell command to run; it receives the event as JSON on stdin,example=./scripts/lint-hook.sh"`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for the command,default=60,example=30"`
+}
+
+type Hooks map[HookEvent][]Hook
+
 // Config holds the configuration for brush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -382,7 +548,9 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitzero" jsonschema:"description=Tool configurations"`
 
-	Agents map[string]Agent `json:"-"`
+	Hooks Hooks `json:"hooks,omitempty" jsonschema:"description=Shell commands run on agent lifecycle events"`
+
+	Agents map[string]Agent `json:"agents,omitempty" jsonschema:"description=Custom agents the main agent can delegate tasks to with the agent tool"`
 
 	// Internal
 	workingDir string `json:"-"`
@@ -698,6 +866,14 @@ func allToolNames() []string {
 		"lsp_diagnostics",
 		"lsp_references",
 		"lsp_restart",
+		"lsp_definition",
+		"lsp_implementation",
+		"lsp_hover",
+		"lsp_document_symbols",
+		"lsp_workspace_symbols",
+		"lsp_call_hierarchy",
+		"lsp_rename",
+		"lsp_code_action",
 		"fetch",
 		"agentic_fetch",
 		"glob",
@@ -719,7 +895,19 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{
+		"glob",
+		"grep",
+		"ls",
+		"lsp_definition",
+		"lsp_implementation",
+		"lsp_hover",
+		"lsp_document_symbols",
+		"lsp_workspace_symbols",
+		"lsp_call_hierarchy",
+		"sourcegraph",
+		"view",
+	}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -760,6 +948,31 @@ func (c *Config) SetupAgents() {
 			AllowedMCP: map[string][]string{},
 		},
 	}
+	for id, agent := range c.customAgents() {
+		if agent.Disabled {
+			continue
+		}
+		// The built-in agents can't be redefined.
+		if id == AgentCoder || id == AgentTask {
+			continue
+		}
+		agent.ID = id
+		agent.Name = cmp.Or(agent.Name, id)
+		agent.Model = cmp.Or(agent.Model, SelectedModelTypeLarge)
+		if !validAgentModel(agent.Model) {
+			slog.Warn("Ignoring agent with an unknown model, use large, small or provider/model", "agent", id, "model", agent.Model)
+			continue
+		}
+		if agent.AllowedTools == nil {
+			agent.AllowedTools = allowedTools
+		}
+		// Only the coder agent can delegate to other agents.
+		agent.AllowedTools = filterSlice(resolveAllowedTools(agent.AllowedTools, c.Options.DisabledTools), []string{"agent"}, false)
+		if agent.ContextPaths == nil {
+			agent.ContextPaths = c.Options.ContextPaths
+		}
+		agents[id] = agent
+	}
 	c.Agents = agents
 }
 
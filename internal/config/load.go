@@ -573,6 +573,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 				large.PresencePenalty = largeModelSelected.PresencePenalty
 			}
 		}
+		large.Fallbacks = largeModelSelected.Fallbacks
 	}
 	smallModelSelected, smallModelConfigured := c.Models[SelectedModelTypeSmall]
 	if smallModelConfigured {
@@ -0,0 +1,124 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The documents of the full-text index: the title of each session, and the
+-- text and tool calls of each message. The id is the rowid of the document
+-- in search_index.
+CREATE TABLE IF NOT EXISTS search_documents (
+    id INTEGER PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    message_id TEXT UNIQUE  -- NULL for the session title
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_documents_session_id ON search_documents (session_id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5 (
+    content,
+    tokenize = 'porter unicode61'
+);
+
+-- The searchable text of a message, and of its tool calls, is kept in sync
+-- by the triggers below.
+CREATE TRIGGER IF NOT EXISTS search_messages_insert
+AFTER INSERT ON messages
+BEGIN
+    INSERT INTO search_documents (session_id, message_id) VALUES (new.session_id, new.id);
+    INSERT INTO search_index (rowid, content) VALUES (
+        last_insert_rowid(),
+        (
+            SELECT group_concat(
+                CASE json_extract(value, '$.type')
+                    WHEN 'text' THEN json_extract(value, '$.data.text')
+                    WHEN 'tool_call' THEN json_extract(value, '$.data.name') || ' ' || json_extract(value, '$.data.input')
+                END,
+                char(10)
+            )
+            FROM json_each(CASE WHEN json_valid(new.parts) THEN new.parts ELSE '[]' END)
+        )
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_messages_update
+AFTER UPDATE OF parts ON messages
+BEGIN
+    UPDATE search_index
+    SET content = (
+        SELECT group_concat(
+            CASE json_extract(value, '$.type')
+                WHEN 'text' THEN json_extract(value, '$.data.text')
+                WHEN 'tool_call' THEN json_extract(value, '$.data.name') || ' ' || json_extract(value, '$.data.input')
+            END,
+            char(10)
+        )
+        FROM json_each(CASE WHEN json_valid(new.parts) THEN new.parts ELSE '[]' END)
+    )
+    WHERE rowid = (SELECT id FROM search_documents WHERE message_id = new.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_messages_delete
+AFTER DELETE ON messages
+BEGIN
+    DELETE FROM search_index WHERE rowid = (SELECT id FROM search_documents WHERE message_id = old.id);
+    DELETE FROM search_documents WHERE message_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_sessions_insert
+AFTER INSERT ON sessions
+BEGIN
+    INSERT INTO search_documents (session_id) VALUES (new.id);
+    INSERT INTO search_index (rowid, content) VALUES (last_insert_rowid(), new.title);
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_sessions_update
+AFTER UPDATE OF title ON sessions
+BEGIN
+    UPDATE search_index
+    SET content = new.title
+    WHERE rowid = (SELECT id FROM search_documents WHERE session_id = new.id AND message_id IS NULL);
+END;
+
+-- Also removes the documents of the session's messages, whichever order
+-- the cascading deletes run in.
+CREATE TRIGGER IF NOT EXISTS search_sessions_delete
+AFTER DELETE ON sessions
+BEGIN
+    DELETE FROM search_index WHERE rowid IN (SELECT id FROM search_documents WHERE session_id = old.id);
+    DELETE FROM search_documents WHERE session_id = old.id;
+END;
+
+-- Index the existing sessions and messages.
+INSERT INTO search_documents (session_id) SELECT id FROM sessions;
+INSERT INTO search_documents (session_id, message_id) SELECT session_id, id FROM messages;
+INSERT INTO search_index (rowid, content)
+SELECT d.id, s.title
+FROM search_documents d
+JOIN sessions s ON s.id = d.session_id
+WHERE d.message_id IS NULL;
+INSERT INTO search_index (rowid, content)
+SELECT
+    d.id,
+    (
+        SELECT group_concat(
+            CASE json_extract(value, '$.type')
+                WHEN 'text' THEN json_extract(value, '$.data.text')
+                WHEN 'tool_call' THEN json_extract(value, '$.data.name') || ' ' || json_extract(value, '$.data.input')
+            END,
+            char(10)
+        )
+        FROM json_each(CASE WHEN json_valid(m.parts) THEN m.parts ELSE '[]' END)
+    )
+FROM search_documents d
+JOIN messages m ON m.id = d.message_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS search_sessions_delete;
+DROP TRIGGER IF EXISTS search_sessions_update;
+DROP TRIGGER IF EXISTS search_sessions_insert;
+DROP TRIGGER IF EXISTS search_messages_delete;
+DROP TRIGGER IF EXISTS search_messages_update;
+DROP TRIGGER IF EXISTS search_messages_insert;
+DROP TABLE IF EXISTS search_index;
+DROP INDEX IF EXISTS idx_search_documents_session_id;
+DROP TABLE IF EXISTS search_documents;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What each agent step, summary and title cost, so budgets can sum what was
+-- spent over a period. The sessions only keep their total cost and the size
+-- of their context. Rows are kept when their session is deleted, as the
+-- money was spent anyway.
+CREATE TABLE IF NOT EXISTS session_usage (
+    id INTEGER PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    cost REAL NOT NULL DEFAULT 0.0,
+    tokens INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_usage_session_id ON session_usage (session_id);
+CREATE INDEX IF NOT EXISTS idx_session_usage_created_at ON session_usage (created_at);
+
+-- The usage of existing sessions is only known as a whole, so it is counted
+-- when they were last updated.
+INSERT INTO session_usage (session_id, cost, tokens, created_at)
+SELECT id, cost, prompt_tokens + completion_tokens, updated_at
+FROM sessions
+WHERE parent_session_id IS NULL
+  AND cost > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_session_usage_created_at;
+DROP INDEX IF EXISTS idx_session_usage_session_id;
+DROP TABLE IF EXISTS session_usage;
+-- +goose StatementEnd
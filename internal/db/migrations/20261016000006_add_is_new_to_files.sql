@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- is_new marks the initial version of a file that didn't exist before it was
+-- first edited, as opposed to one that existed but was empty.
+ALTER TABLE files ADD COLUMN is_new INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN is_new;
+-- +goose StatementEnd
@@ -8,6 +8,19 @@ import (
 	"database/sql"
 )
 
+type Checkpoint struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type CheckpointFile struct {
+	CheckpointID string `json:"checkpoint_id"`
+	Path         string `json:"path"`
+	FileID       string `json:"file_id"`
+}
+
 type File struct {
 	ID        string `json:"id"`
 	SessionID string `json:"session_id"`
@@ -16,6 +29,7 @@ type File struct {
 	Version   int64  `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	IsNew     int64  `json:"is_new"`
 }
 
 type Message struct {
@@ -29,18 +43,43 @@ type Message struct {
 	FinishedAt       sql.NullInt64  `json:"finished_at"`
 	Provider         sql.NullString `json:"provider"`
 	IsSummaryMessage int64          `json:"is_summary_message"`
+	Retries          int64          `json:"retries"`
+}
+
+type PermissionGrant struct {
+	ID        string         `json:"id"`
+	SessionID sql.NullString `json:"session_id"`
+	ToolName  string         `json:"tool_name"`
+	Action    string         `json:"action"`
+	Path      string         `json:"path"`
+	CreatedAt int64          `json:"created_at"`
+}
+
+type SearchDocument struct {
+	ID        int64          `json:"id"`
+	SessionID string         `json:"session_id"`
+	MessageID sql.NullString `json:"message_id"`
 }
 
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Todos            sql.NullString `json:"todos"`
+	ID                  string         `json:"id"`
+	ParentSessionID     sql.NullString `json:"parent_session_id"`
+	Title               string         `json:"title"`
+	MessageCount        int64          `json:"message_count"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	Cost                float64        `json:"cost"`
+	UpdatedAt           int64          `json:"updated_at"`
+	CreatedAt           int64          `json:"created_at"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	Todos               sql.NullString `json:"todos"`
+	ForkedFromSessionID sql.NullString `json:"forked_from_session_id"`
+}
+
+type SessionUsage struct {
+	ID        int64   `json:"id"`
+	SessionID string  `json:"session_id"`
+	Cost      float64 `json:"cost"`
+	Tokens    int64   `json:"tokens"`
+	CreatedAt int64   `json:"created_at"`
 }
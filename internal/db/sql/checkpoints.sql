@@ -0,0 +1,30 @@
+-- name: CreateCheckpoint :one
+INSERT INTO checkpoints (
+    id,
+    session_id,
+    message_id,
+    created_at
+) VALUES (
+    ?, ?, ?, strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: CreateCheckpointFile :exec
+INSERT INTO checkpoint_files (
+    checkpoint_id,
+    path,
+    file_id
+) VALUES (
+    ?, ?, ?
+);
+
+-- name: GetCheckpointByMessage :one
+SELECT *
+FROM checkpoints
+WHERE message_id = ? LIMIT 1;
+
+-- name: ListCheckpointFiles :many
+SELECT *
+FROM checkpoint_files
+WHERE checkpoint_id = ?
+ORDER BY path;
@@ -29,13 +29,28 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
 RETURNING *;
 
+-- name: ImportFile :exec
+INSERT INTO files (
+    id,
+    session_id,
+    path,
+    content,
+    version,
+    is_new,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: DeleteFile :exec
 DELETE FROM files
 WHERE id = ?;
@@ -60,3 +75,11 @@ SELECT *
 FROM files
 WHERE is_new = 1
 ORDER BY version DESC, created_at DESC;
+
+-- name: ListFilesBySessionTree :many
+SELECT *
+FROM files
+WHERE session_id = ? OR session_id IN (
+    SELECT id FROM sessions WHERE parent_session_id = ?
+)
+ORDER BY version ASC, created_at ASC;
@@ -25,11 +25,59 @@ INSERT INTO messages (
 )
 RETURNING *;
 
+-- name: CopyMessage :one
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    retries,
+    finished_at,
+    created_at,
+    updated_at
+)
+SELECT
+    sqlc.arg(id),
+    sqlc.arg(session_id),
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    retries,
+    finished_at,
+    created_at,
+    updated_at
+FROM messages
+WHERE messages.id = sqlc.arg(source_id)
+RETURNING *;
+
+-- name: ImportMessage :exec
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    retries,
+    finished_at,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: UpdateMessage :exec
 UPDATE messages
 SET
     parts = ?,
     finished_at = ?,
+    retries = ?,
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
@@ -0,0 +1,21 @@
+-- name: CreatePermissionGrant :one
+INSERT INTO permission_grants (
+    id,
+    session_id,
+    tool_name,
+    action,
+    path,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now')
+)
+RETURNING *;
+
+-- name: ListPermissionGrants :many
+SELECT *
+FROM permission_grants
+ORDER BY created_at DESC;
+
+-- name: DeletePermissionGrant :execrows
+DELETE FROM permission_grants
+WHERE id = ?;
@@ -0,0 +1,14 @@
+-- name: SearchMessages :many
+SELECT
+    d.session_id,
+    CAST(COALESCE(d.message_id, '') AS TEXT) AS message_id,
+    s.title AS session_title,
+    s.updated_at AS session_updated_at,
+    CAST(snippet(search_index, 0, char(2), char(3), '…', 16) AS TEXT) AS snippet
+FROM search_index
+JOIN search_documents d ON d.id = search_index.rowid
+JOIN sessions s ON s.id = d.session_id
+WHERE search_index MATCH sqlc.arg(query)
+  AND s.parent_session_id IS NULL
+ORDER BY rank
+LIMIT sqlc.arg(limit);
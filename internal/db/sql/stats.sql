@@ -14,7 +14,8 @@ ORDER BY day DESC;
 SELECT
     COALESCE(model, 'unknown') as model,
     COALESCE(provider, 'unknown') as provider,
-    COUNT(*) as message_count
+    COUNT(*) as message_count,
+    CAST(COALESCE(SUM(retries), 0) AS INTEGER) as retry_count
 FROM messages
 WHERE role = 'assistant'
 GROUP BY model, provider
@@ -0,0 +1,27 @@
+-- name: CreateSessionUsage :exec
+INSERT INTO session_usage (
+    session_id,
+    cost,
+    tokens,
+    created_at
+) VALUES (
+    ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: GetSessionUsageSince :one
+SELECT
+    CAST(COALESCE(SUM(cost), 0) AS REAL) as cost,
+    CAST(COALESCE(SUM(tokens), 0) AS INTEGER) as tokens
+FROM session_usage
+WHERE created_at >= ?
+  AND (
+    session_id = ?
+    OR session_id IN (SELECT id FROM sessions WHERE parent_session_id = ?)
+  );
+
+-- name: GetUsageSince :one
+SELECT
+    CAST(COALESCE(SUM(cost), 0) AS REAL) as cost,
+    CAST(COALESCE(SUM(tokens), 0) AS INTEGER) as tokens
+FROM session_usage
+WHERE created_at >= ?;
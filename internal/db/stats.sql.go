@@ -330,7 +330,8 @@ const getUsageByModel = `-- name: GetUsageByModel :many
 SELECT
     COALESCE(model, 'unknown') as model,
     COALESCE(provider, 'unknown') as provider,
-    COUNT(*) as message_count
+    COUNT(*) as message_count,
+    CAST(COALESCE(SUM(retries), 0) AS INTEGER) as retry_count
 FROM messages
 WHERE role = 'assistant'
 GROUP BY model, provider
@@ -341,6 +342,7 @@ type GetUsageByModelRow struct {
 	Model        string `json:"model"`
 	Provider     string `json:"provider"`
 	MessageCount int64  `json:"message_count"`
+	RetryCount   int64  `json:"retry_count"`
 }
 
 func (q *Queries) GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, error) {
@@ -352,7 +354,12 @@ func (q *Queries) GetUsageByModel(ctx context.Context) ([]GetUsageByModelRow, er
 	items := []GetUsageByModelRow{}
 	for rows.Next() {
 		var i GetUsageByModelRow
-		if err := rows.Scan(&i.Model, &i.Provider, &i.MessageCount); err != nil {
+		if err := rows.Scan(
+			&i.Model,
+			&i.Provider,
+			&i.MessageCount,
+			&i.RetryCount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -0,0 +1,329 @@
+// Package git commits the changes made by the agent, either to a scratch
+// branch of the project's repository or to a shadow repository kept in the
+// data directory, without touching the user's branch, index or work tree.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxDiffBytes is how much of a diff is returned, to keep prompts small.
+const maxDiffBytes = 32 * 1024
+
+// ErrNotRepository is returned by [Open] when the directory isn't in a git
+// repository.
+var ErrNotRepository = errors.New("not a git repository")
+
+// Repo runs git for a work tree, optionally with a separate git directory.
+type Repo struct {
+	// WorkTree is the top-level directory of the work tree.
+	WorkTree string
+	// GitDir is the git directory, or empty to use the work tree's.
+	GitDir string
+}
+
+// Open returns the repository containing dir.
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	r := &Repo{WorkTree: dir}
+	top, err := r.run(ctx, nil, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotRepository, dir)
+	}
+	r.WorkTree = strings.TrimSpace(top)
+	return r, nil
+}
+
+// OpenShadow returns a repository that tracks workTree from gitDir, creating
+// it if needed. Paths matching the exclude patterns, like the data
+// directory, are never committed.
+func OpenShadow(ctx context.Context, workTree, gitDir string, exclude ...string) (*Repo, error) {
+	r := &Repo{WorkTree: workTree, GitDir: gitDir}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		initRepo := &Repo{WorkTree: workTree}
+		if _, err := initRepo.run(ctx, nil, nil, "init", "--quiet", "--bare", gitDir); err != nil {
+			return nil, err
+		}
+	}
+	if len(exclude) > 0 {
+		excludeFile := filepath.Join(gitDir, "info", "exclude")
+		if err := os.MkdirAll(filepath.Dir(excludeFile), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create shadow repository: %w", err)
+		}
+		if err := os.WriteFile(excludeFile, []byte(strings.Join(exclude, "\n")+"\n"), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to create shadow repository: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// run runs git with the extra environment and stdin, and returns its output.
+func (r *Repo) run(ctx context.Context, env []string, stdin []byte, args ...string) (string, error) {
+	name := args[0]
+	if r.GitDir != "" {
+		args = append([]string{"--git-dir", r.GitDir, "--work-tree", r.WorkTree}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.WorkTree
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", name, msg)
+	}
+	return stdout.String(), nil
+}
+
+// revParse returns the commit rev points to, or an empty string if it
+// doesn't exist.
+func (r *Repo) revParse(ctx context.Context, rev string) string {
+	out, err := r.run(ctx, nil, nil, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Snapshot is the state of the work tree compared to the tip of a ref.
+type Snapshot struct {
+	// Ref is the ref the snapshot is committed to.
+	Ref string
+	// Parent is the commit the ref points to, or the base it starts from. It
+	// is empty for a new shadow repository.
+	Parent string
+	// Tree is the tree of the work tree.
+	Tree string
+	// New reports whether the ref doesn't exist yet.
+	New bool
+}
+
+// Changed reports whether the snapshot differs from its parent.
+func (r *Repo) Changed(ctx context.Context, s *Snapshot) bool {
+	if s.Parent == "" {
+		return true
+	}
+	parentTree, err := r.run(ctx, nil, nil, "rev-parse", s.Parent+"^{tree}")
+	return err != nil || strings.TrimSpace(parentTree) != s.Tree
+}
+
+// Snapshot writes the whole work tree, respecting .gitignore, to a tree using
+// the private index at indexPath. The parent is the tip of ref, or base if
+// the ref doesn't exist yet.
+func (r *Repo) Snapshot(ctx context.Context, ref, base, indexPath string) (*Snapshot, error) {
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	env := []string{"GIT_INDEX_FILE=" + indexPath}
+
+	parent := r.revParse(ctx, ref)
+	isNew := parent == ""
+	if isNew && base != "" {
+		parent = r.revParse(ctx, base)
+	}
+	if _, err := os.Stat(indexPath); err != nil && parent != "" {
+		// Start from the parent so unchanged files are not hashed again.
+		if _, err := r.run(ctx, env, nil, "read-tree", parent); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := r.run(ctx, env, nil, "add", "--all", "--", "."); err != nil {
+		return nil, err
+	}
+	tree, err := r.run(ctx, env, nil, "write-tree")
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Ref: ref, Parent: parent, Tree: strings.TrimSpace(tree), New: isNew}, nil
+}
+
+// Diff returns the changes of the snapshot compared to its parent, preceded
+// by a summary of the changed files, truncated to a reasonable size.
+func (r *Repo) Diff(ctx context.Context, s *Snapshot) (string, error) {
+	from := s.Parent
+	if from == "" {
+		// The empty tree.
+		from = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	}
+	stat, err := r.run(ctx, nil, nil, "diff-tree", "--stat", "-r", from, s.Tree)
+	if err != nil {
+		return "", err
+	}
+	patch, err := r.run(ctx, nil, nil, "diff-tree", "-p", "-r", from, s.Tree)
+	if err != nil {
+		return "", err
+	}
+	return truncate(stat + "\n" + patch), nil
+}
+
+// Commit commits the snapshot with the message and moves its ref to the new
+// commit, which it returns.
+func (r *Repo) Commit(ctx context.Context, s *Snapshot, message string) (string, error) {
+	args := []string{"commit-tree", s.Tree, "-F", "-"}
+	if s.Parent != "" {
+		args = append(args, "-p", s.Parent)
+	}
+	out, err := r.run(ctx, identityEnv(ctx, r), []byte(message), args...)
+	if err != nil {
+		return "", err
+	}
+	commit := strings.TrimSpace(out)
+	oldValue := s.Parent
+	if s.New {
+		// The ref must still not exist when it's created.
+		oldValue = strings.Repeat("0", len(commit))
+	}
+	if _, err := r.run(ctx, nil, nil, "update-ref", "-m", "brush: auto-commit", s.Ref, commit, oldValue); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// Reword replaces the message of commit, which must be the tip of ref, and
+// moves the ref to the new commit, which it returns. It fails if the ref
+// moved since.
+func (r *Repo) Reword(ctx context.Context, ref, commit, message string) (string, error) {
+	tree, err := r.run(ctx, nil, nil, "rev-parse", commit+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	parents, err := r.run(ctx, nil, nil, "rev-parse", commit+"^@")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", strings.TrimSpace(tree), "-F", "-"}
+	for _, parent := range strings.Fields(parents) {
+		args = append(args, "-p", parent)
+	}
+	out, err := r.run(ctx, identityEnv(ctx, r), []byte(message), args...)
+	if err != nil {
+		return "", err
+	}
+	reworded := strings.TrimSpace(out)
+	if _, err := r.run(ctx, nil, nil, "update-ref", "-m", "brush: reword auto-commit", ref, reworded, commit); err != nil {
+		return "", err
+	}
+	return reworded, nil
+}
+
+// identityEnv sets a committer identity if git has none configured, which is
+// common in shadow repositories and containers.
+func identityEnv(ctx context.Context, r *Repo) []string {
+	if _, err := r.run(ctx, nil, nil, "var", "GIT_COMMITTER_IDENT"); err == nil {
+		return nil
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=Brush",
+		"GIT_AUTHOR_EMAIL=brush@localhost",
+		"GIT_COMMITTER_NAME=Brush",
+		"GIT_COMMITTER_EMAIL=brush@localhost",
+	}
+}
+
+// Changes returns the paths, among the given ones, that differ from HEAD,
+// relative to the work tree, and their diff preceded by a summary, truncated
+// to a reasonable size. Ignored paths are skipped. The repository's index is
+// left untouched.
+func (r *Repo) Changes(ctx context.Context, paths ...string) ([]string, string, error) {
+	changed, err := r.changedPaths(ctx, paths...)
+	if err != nil || len(changed) == 0 {
+		return nil, "", err
+	}
+
+	// Stage the paths in a throwaway index to diff them like a commit would.
+	dir, err := os.MkdirTemp("", "brush-index-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create index directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(dir, "index")}
+	if head := r.revParse(ctx, "HEAD"); head != "" {
+		if _, err := r.run(ctx, env, nil, "read-tree", head); err != nil {
+			return nil, "", err
+		}
+	}
+	if _, err := r.run(ctx, env, nil, append([]string{"add", "--all", "--"}, changed...)...); err != nil {
+		return nil, "", err
+	}
+	stat, err := r.run(ctx, env, nil, "diff", "--cached", "--stat")
+	if err != nil {
+		return nil, "", err
+	}
+	patch, err := r.run(ctx, env, nil, "diff", "--cached")
+	if err != nil {
+		return nil, "", err
+	}
+	return changed, truncate(stat + "\n" + patch), nil
+}
+
+// changedPaths returns the paths, among the given ones, that differ from
+// HEAD, including deletions, relative to the work tree.
+func (r *Repo) changedPaths(ctx context.Context, paths ...string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"status", "--porcelain", "-z", "--untracked-files=all", "--"}, paths...)
+	out, err := r.run(ctx, nil, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	entries := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		changed = append(changed, entry[3:])
+		if entry[0] == 'R' || entry[0] == 'C' {
+			// Renames and copies are followed by the original path.
+			i++
+		}
+	}
+	return changed, nil
+}
+
+// StagedFiles returns the paths of the staged changes, relative to the work
+// tree.
+func (r *Repo) StagedFiles(ctx context.Context) ([]string, error) {
+	out, err := r.run(ctx, nil, nil, "diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// CommitPaths stages the paths, relative to the work tree, and commits only
+// them with the message, running the repository's hooks like a regular
+// commit. Changes staged before for other paths stay staged.
+func (r *Repo) CommitPaths(ctx context.Context, message string, paths ...string) error {
+	if len(paths) == 0 {
+		return errors.New("no changes to commit")
+	}
+	if _, err := r.run(ctx, nil, nil, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return err
+	}
+	args := append([]string{"commit", "--quiet", "--file", "-", "--only", "--"}, paths...)
+	_, err := r.run(ctx, nil, []byte(message), args...)
+	return err
+}
+
+func truncate(diff string) string {
+	if len(diff) <= maxDiffBytes {
+		return diff
+	}
+	return diff[:maxDiffBytes] + fmt.Sprintf("\n... [%d bytes truncated] ...\n", len(diff)-maxDiffBytes)
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	r := &Repo{WorkTree: dir}
+	_, err := r.run(t.Context(), nil, nil, "init", "--quiet", "--initial-branch", "main")
+	require.NoError(t, err)
+	writeFile(t, dir, "README.md", "hello\n")
+	_, err = r.run(t.Context(), nil, nil, "add", "README.md")
+	require.NoError(t, err)
+	_, err = r.run(t.Context(), nil, nil, "commit", "--quiet", "-m", "Initial commit")
+	require.NoError(t, err)
+
+	r, err = Open(t.Context(), dir)
+	require.NoError(t, err)
+	return r
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestOpen_NotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := Open(t.Context(), t.TempDir())
+	require.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestSnapshot_Branch(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := t.Context()
+	index := filepath.Join(t.TempDir(), "index")
+	const ref = "refs/heads/brush/session"
+
+	s, err := r.Snapshot(ctx, ref, "HEAD", index)
+	require.NoError(t, err)
+	require.False(t, r.Changed(ctx, s))
+
+	writeFile(t, r.WorkTree, "main.go", "package main\n")
+	s, err = r.Snapshot(ctx, ref, "HEAD", index)
+	require.NoError(t, err)
+	require.True(t, r.Changed(ctx, s))
+
+	diff, err := r.Diff(ctx, s)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+package main")
+
+	commit, err := r.Commit(ctx, s, "Add main.go")
+	require.NoError(t, err)
+	require.Equal(t, commit, r.revParse(ctx, ref))
+
+	// Rewording keeps the tree and parent, and only moves the ref from the
+	// reworded commit.
+	reworded, err := r.Reword(ctx, ref, commit, "Add the main package")
+	require.NoError(t, err)
+	require.Equal(t, reworded, r.revParse(ctx, ref))
+	subject, err := r.run(ctx, nil, nil, "log", "-1", "--format=%s", ref)
+	require.NoError(t, err)
+	require.Equal(t, "Add the main package", strings.TrimSpace(subject))
+	before, err := r.run(ctx, nil, nil, "rev-parse", commit+"^{tree}", commit+"^")
+	require.NoError(t, err)
+	after, err := r.run(ctx, nil, nil, "rev-parse", reworded+"^{tree}", reworded+"^")
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+	_, err = r.Reword(ctx, ref, commit, "Stale")
+	require.Error(t, err)
+	commit = reworded
+
+	// The user's branch, index and work tree are untouched.
+	head, err := r.run(ctx, nil, nil, "log", "--format=%s", "main")
+	require.NoError(t, err)
+	require.Equal(t, "Initial commit", strings.TrimSpace(head))
+	status, err := r.run(ctx, nil, nil, "status", "--porcelain")
+	require.NoError(t, err)
+	require.Equal(t, "?? main.go", strings.TrimSpace(status))
+
+	// The next snapshot builds on the scratch branch.
+	s, err = r.Snapshot(ctx, ref, "HEAD", index)
+	require.NoError(t, err)
+	require.Equal(t, commit, s.Parent)
+	require.False(t, r.Changed(ctx, s))
+}
+
+func TestSnapshot_Shadow(t *testing.T) {
+	project := newTestRepo(t)
+	ctx := t.Context()
+	dataDir := filepath.Join(project.WorkTree, ".brush")
+	writeFile(t, project.WorkTree, ".gitignore", "*.log\n")
+	writeFile(t, project.WorkTree, "debug.log", "noise\n")
+
+	r, err := OpenShadow(ctx, project.WorkTree, filepath.Join(dataDir, "shadow.git"), "/.brush/")
+	require.NoError(t, err)
+	s, err := r.Snapshot(ctx, "refs/heads/session", "", filepath.Join(dataDir, "index"))
+	require.NoError(t, err)
+	require.Empty(t, s.Parent)
+	require.True(t, s.New)
+	require.True(t, r.Changed(ctx, s))
+	_, err = r.Commit(ctx, s, "Start of session")
+	require.NoError(t, err)
+
+	files, err := r.run(ctx, nil, nil, "ls-tree", "-r", "--name-only", "refs/heads/session")
+	require.NoError(t, err)
+	require.Equal(t, []string{".gitignore", "README.md"}, strings.Fields(files))
+
+	// The project's own repository doesn't see the shadow commits.
+	log, err := project.run(ctx, nil, nil, "log", "--all", "--format=%s")
+	require.NoError(t, err)
+	require.Equal(t, "Initial commit", strings.TrimSpace(log))
+}
+
+func TestCommitPaths(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := t.Context()
+	writeFile(t, r.WorkTree, "new.go", "package main\n")
+	writeFile(t, r.WorkTree, "other.go", "package other\n")
+	writeFile(t, r.WorkTree, "staged.go", "package staged\n")
+	_, err := r.run(ctx, nil, nil, "add", "staged.go")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(r.WorkTree, "README.md")))
+
+	changed, diff, err := r.Changes(ctx,
+		filepath.Join(r.WorkTree, "new.go"),
+		filepath.Join(r.WorkTree, "README.md"),
+		filepath.Join(r.WorkTree, "missing.go"),
+	)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"new.go", "README.md"}, changed)
+	require.Contains(t, diff, "-hello")
+	require.Contains(t, diff, "+package main")
+	require.NotContains(t, diff, "package staged")
+
+	// Looking at the changes doesn't stage anything.
+	staged, err := r.StagedFiles(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"staged.go"}, staged)
+
+	require.NoError(t, r.CommitPaths(ctx, "Replace README with new.go", changed...))
+	files, err := r.run(ctx, nil, nil, "show", "--name-only", "--format=", "HEAD")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"new.go", "README.md"}, strings.Fields(files))
+
+	// What the user staged before stays staged.
+	status, err := r.run(ctx, nil, nil, "status", "--porcelain")
+	require.NoError(t, err)
+	require.Equal(t, "A  staged.go\n?? other.go", strings.TrimSpace(status))
+}
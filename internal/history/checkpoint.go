@@ -0,0 +1,154 @@
+package history
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/google/uuid"
+)
+
+// Checkpoint records the files of a session as they were when a user message
+// was sent, so they can be rewound to that point.
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	MessageID string
+	// Files maps the path of each file edited before the checkpoint to the ID
+	// of its latest version at the time.
+	Files     map[string]string
+	CreatedAt int64
+}
+
+// CreateCheckpoint records the latest version of every file edited in the
+// session, including by its sub-agents, for the given user message.
+func (s *service) CreateCheckpoint(ctx context.Context, sessionID, messageID string) (Checkpoint, error) {
+	files, err := s.listSessionTree(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.q.WithTx(tx)
+
+	dbCheckpoint, err := qtx.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		MessageID: messageID,
+	})
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoint := Checkpoint{
+		ID:        dbCheckpoint.ID,
+		SessionID: dbCheckpoint.SessionID,
+		MessageID: dbCheckpoint.MessageID,
+		Files:     make(map[string]string),
+		CreatedAt: dbCheckpoint.CreatedAt,
+	}
+	for path, file := range latestVersions(files) {
+		if err := qtx.CreateCheckpointFile(ctx, db.CreateCheckpointFileParams{
+			CheckpointID: checkpoint.ID,
+			Path:         path,
+			FileID:       file.ID,
+		}); err != nil {
+			return Checkpoint{}, err
+		}
+		checkpoint.Files[path] = file.ID
+	}
+	if err := tx.Commit(); err != nil {
+		return Checkpoint{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// GetCheckpoint returns the checkpoint of the given user message.
+func (s *service) GetCheckpoint(ctx context.Context, messageID string) (Checkpoint, error) {
+	dbCheckpoint, err := s.q.GetCheckpointByMessage(ctx, messageID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	dbFiles, err := s.q.ListCheckpointFiles(ctx, dbCheckpoint.ID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoint := Checkpoint{
+		ID:        dbCheckpoint.ID,
+		SessionID: dbCheckpoint.SessionID,
+		MessageID: dbCheckpoint.MessageID,
+		Files:     make(map[string]string, len(dbFiles)),
+		CreatedAt: dbCheckpoint.CreatedAt,
+	}
+	for _, f := range dbFiles {
+		checkpoint.Files[f.Path] = f.FileID
+	}
+	return checkpoint, nil
+}
+
+// ChangedSince returns the version to restore for every file edited after the
+// checkpoint: the version recorded by the checkpoint, or the content the file
+// had before it was first edited.
+func (s *service) ChangedSince(ctx context.Context, checkpoint Checkpoint) ([]File, error) {
+	files, err := s.listSessionTree(ctx, checkpoint.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]File, len(files))
+	first := make(map[string]File)
+	for _, f := range files {
+		byID[f.ID] = f
+		if prev, ok := first[f.Path]; !ok || f.CreatedAt < prev.CreatedAt ||
+			(f.CreatedAt == prev.CreatedAt && f.Version < prev.Version) {
+			first[f.Path] = f
+		}
+	}
+
+	var changed []File
+	for path, latest := range latestVersions(files) {
+		id, ok := checkpoint.Files[path]
+		switch {
+		case !ok:
+			changed = append(changed, first[path])
+		case id != latest.ID:
+			if f, ok := byID[id]; ok {
+				changed = append(changed, f)
+			}
+		}
+	}
+	slices.SortFunc(changed, func(a, b File) int { return cmp.Compare(a.Path, b.Path) })
+	return changed, nil
+}
+
+// listSessionTree lists the file versions of the session and of its
+// sub-agent sessions.
+func (s *service) listSessionTree(ctx context.Context, sessionID string) ([]File, error) {
+	dbFiles, err := s.q.ListFilesBySessionTree(ctx, db.ListFilesBySessionTreeParams{
+		SessionID:       sessionID,
+		ParentSessionID: sql.NullString{String: sessionID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]File, len(dbFiles))
+	for i, dbFile := range dbFiles {
+		files[i] = s.fromDBItem(dbFile)
+	}
+	return files, nil
+}
+
+// latestVersions returns the latest version of each path, given versions
+// ordered by version and creation time.
+func latestVersions(files []File) map[string]File {
+	latest := make(map[string]File)
+	for _, f := range files {
+		latest[f.Path] = f
+	}
+	return latest
+}
@@ -21,6 +21,9 @@ type File struct {
 	Path      string
 	Content   string
 	Version   int64
+	// IsNew is set on the initial version of a file that didn't exist before
+	// it was first edited.
+	IsNew     bool
 	CreatedAt int64
 	UpdatedAt int64
 }
@@ -30,6 +33,10 @@ type Service interface {
 	pubsub.Subscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
 
+	// CreateNew creates the empty initial version of a file that didn't exist
+	// before it was first edited.
+	CreateNew(ctx context.Context, sessionID, path string) (File, error)
+
 	// CreateVersion creates a new version of a file.
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
 
@@ -39,6 +46,12 @@ type Service interface {
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+
+	CreateCheckpoint(ctx context.Context, sessionID, messageID string) (Checkpoint, error)
+	GetCheckpoint(ctx context.Context, messageID string) (Checkpoint, error)
+	// ChangedSince returns the version to restore for every file edited
+	// after the checkpoint.
+	ChangedSince(ctx context.Context, checkpoint Checkpoint) ([]File, error)
 }
 
 type service struct {
@@ -56,7 +69,11 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
+}
+
+func (s *service) CreateNew(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createWithVersion(ctx, sessionID, path, "", InitialVersion, true)
 }
 
 // CreateVersion creates a new version of a file with auto-incremented version
@@ -78,12 +95,16 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, false)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64, isNew bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
+	newFlag := int64(0)
+	if isNew {
+		newFlag = 1
+	}
 	var file File
 	var err error
 
@@ -105,6 +126,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			IsNew:     newFlag,
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -211,6 +233,7 @@ func (s *service) fromDBItem(item db.File) File {
 		Path:      item.Path,
 		Content:   item.Content,
 		Version:   item.Version,
+		IsNew:     item.IsNew != 0,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
 	}
@@ -0,0 +1,221 @@
+// Package hooks runs user-configured shell commands on agent lifecycle
+// events.
+//
+// Each hook receives an [Input] encoded as JSON on stdin. A hook that exits
+// with status 0 may print an [Output] JSON object on stdout to block the
+// action, rewrite a tool call's input, or add context for the model; plain
+// text output is used as context. Exiting with status 2 blocks the action
+// using stderr as the reason. Any other failure is logged and ignored.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/shell"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+
+	// blockExitCode is the exit status a hook uses to block an action.
+	blockExitCode = 2
+)
+
+// Input is the JSON document sent to hooks on stdin.
+type Input struct {
+	Event      config.HookEvent `json:"event"`
+	SessionID  string           `json:"session_id"`
+	WorkingDir string           `json:"cwd"`
+	// Prompt is set for UserPromptSubmit and SessionStart.
+	Prompt string `json:"prompt,omitempty"`
+	// ToolCall is set for PreToolUse and PostToolUse.
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
+	// ToolResponse is set for PostToolUse.
+	ToolResponse *ToolResponse `json:"tool_response,omitempty"`
+	// Response is the final text of the assistant, set for Stop.
+	Response string `json:"response,omitempty"`
+	// StopHookActive is set for Stop when the agent is already continuing
+	// because a Stop hook blocked it before. Hooks should use it to avoid
+	// looping forever.
+	StopHookActive bool `json:"stop_hook_active,omitempty"`
+}
+
+// ToolCall describes the tool call a hook runs for.
+type ToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// ToolResponse describes the response of a tool.
+type ToolResponse struct {
+	Content string `json:"content"`
+	IsError bool   `json:"is_error"`
+}
+
+// Output is the JSON document a hook can print on stdout.
+type Output struct {
+	// Decision can be set to "block" to block the action.
+	Decision string `json:"decision,omitempty"`
+	// Reason explains why the action was blocked. It is returned to the
+	// model, or to the user for UserPromptSubmit.
+	Reason string `json:"reason,omitempty"`
+	// Input replaces the tool call input. Only used for PreToolUse.
+	Input json.RawMessage `json:"input,omitempty"`
+	// Context is added to the conversation for the model to consider.
+	Context string `json:"context,omitempty"`
+}
+
+// Result is the combined outcome of all hooks run for an event.
+type Result struct {
+	Blocked bool
+	Reason  string
+	// Input is the rewritten tool input, if any hook changed it.
+	Input string
+	// Context is the context added by the hooks, if any.
+	Context string
+}
+
+// BlockedError is returned when a hook blocks an action.
+type BlockedError struct {
+	Event  config.HookEvent
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("blocked by %s hook", e.Event)
+	}
+	return fmt.Sprintf("blocked by %s hook: %s", e.Event, e.Reason)
+}
+
+type hook struct {
+	config.Hook
+	matcher *regexp.Regexp
+}
+
+// Runner runs the hooks configured for each event. A nil Runner runs
+// nothing.
+type Runner struct {
+	workingDir string
+	hooks      map[config.HookEvent][]hook
+}
+
+// NewRunner creates a runner for the given hooks, validating their matchers.
+func NewRunner(workingDir string, cfg config.Hooks) (*Runner, error) {
+	r := &Runner{
+		workingDir: workingDir,
+		hooks:      make(map[config.HookEvent][]hook, len(cfg)),
+	}
+	for event, hooks := range cfg {
+		switch event {
+		case config.HookPreToolUse, config.HookPostToolUse, config.HookUserPromptSubmit, config.HookStop, config.HookSessionStart:
+		default:
+			return nil, fmt.Errorf("unknown hook event %q", event)
+		}
+		for i, h := range hooks {
+			if strings.TrimSpace(h.Command) == "" {
+				return nil, fmt.Errorf("%s hook %d: command is required", event, i)
+			}
+			compiled := hook{Hook: h}
+			if h.Matcher != "" {
+				re, err := regexp.Compile(h.Matcher)
+				if err != nil {
+					return nil, fmt.Errorf("%s hook %d: invalid matcher: %w", event, i, err)
+				}
+				compiled.matcher = re
+			}
+			r.hooks[event] = append(r.hooks[event], compiled)
+		}
+	}
+	return r, nil
+}
+
+// Has reports whether any hook is configured for the event.
+func (r *Runner) Has(event config.HookEvent) bool {
+	return r != nil && len(r.hooks[event]) > 0
+}
+
+// Run runs the hooks for input.Event in order. Hooks for tool events only
+// run if their matcher matches the tool name. A rewritten tool input is
+// passed on to the following hooks, and the first hook to block stops the
+// chain.
+func (r *Runner) Run(ctx context.Context, input Input) Result {
+	var result Result
+	if !r.Has(input.Event) {
+		return result
+	}
+	input.WorkingDir = r.workingDir
+
+	var contexts []string
+	for _, h := range r.hooks[input.Event] {
+		if h.matcher != nil && (input.ToolCall == nil || !h.matcher.MatchString(input.ToolCall.Name)) {
+			continue
+		}
+
+		out, err := r.run(ctx, h.Hook, input)
+		if err != nil {
+			slog.Warn("Hook failed", "event", input.Event, "command", h.Command, "error", err)
+			continue
+		}
+
+		if out.Context != "" {
+			contexts = append(contexts, out.Context)
+		}
+		if len(out.Input) > 0 && input.ToolCall != nil && input.Event == config.HookPreToolUse {
+			input.ToolCall.Input = out.Input
+			result.Input = string(out.Input)
+		}
+		if out.Decision == "block" {
+			result.Blocked = true
+			result.Reason = out.Reason
+			break
+		}
+	}
+	result.Context = strings.Join(contexts, "\n\n")
+	return result
+}
+
+func (r *Runner) run(ctx context.Context, h config.Hook, input Input) (Output, error) {
+	var out Output
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return out, err
+	}
+
+	timeout := defaultTimeout
+	if h.Timeout > 0 {
+		timeout = time.Duration(h.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{WorkingDir: r.workingDir})
+	sh.SetEnv("BRUSH_HOOK_EVENT", string(input.Event))
+	stdout, stderr, err := sh.ExecInput(ctx, h.Command, bytes.NewReader(data))
+	if shell.ExitCode(err) == blockExitCode && !shell.IsInterrupt(err) {
+		return Output{Decision: "block", Reason: strings.TrimSpace(stderr)}, nil
+	}
+	if err != nil {
+		return out, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+
+	stdout = strings.TrimSpace(stdout)
+	if !strings.HasPrefix(stdout, "{") {
+		out.Context = stdout
+		return out, nil
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		return out, fmt.Errorf("invalid hook output: %w", err)
+	}
+	return out, nil
+}
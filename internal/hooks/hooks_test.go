@@ -0,0 +1,108 @@
+package hooks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	call := func() *ToolCall {
+		return &ToolCall{ID: "1", Name: "bash", Input: json.RawMessage(`{"command":"ls"}`)}
+	}
+
+	tests := []struct {
+		name   string
+		hooks  []config.Hook
+		input  Input
+		expect Result
+	}{
+		{
+			name:   "plain output is context",
+			hooks:  []config.Hook{{Command: "echo remember to run gofmt"}},
+			input:  Input{Event: config.HookPreToolUse, ToolCall: call()},
+			expect: Result{Context: "remember to run gofmt"},
+		},
+		{
+			name:   "exit code 2 blocks",
+			hooks:  []config.Hook{{Command: "echo no network >&2; exit 2"}},
+			input:  Input{Event: config.HookPreToolUse, ToolCall: call()},
+			expect: Result{Blocked: true, Reason: "no network"},
+		},
+		{
+			name:   "other failures are ignored",
+			hooks:  []config.Hook{{Command: "exit 1"}, {Command: "echo ok"}},
+			input:  Input{Event: config.HookPreToolUse, ToolCall: call()},
+			expect: Result{Context: "ok"},
+		},
+		{
+			name: "json output rewrites input",
+			hooks: []config.Hook{
+				{Command: `echo '{"input":{"command":"ls -la"},"context":"rewritten"}'`},
+			},
+			input:  Input{Event: config.HookPreToolUse, ToolCall: call()},
+			expect: Result{Input: `{"command":"ls -la"}`, Context: "rewritten"},
+		},
+		{
+			name: "matcher filters tools",
+			hooks: []config.Hook{
+				{Matcher: "^edit$", Command: "exit 2"},
+				{Matcher: "^ba", Command: "echo matched"},
+			},
+			input:  Input{Event: config.HookPreToolUse, ToolCall: call()},
+			expect: Result{Context: "matched"},
+		},
+		{
+			name: "first block stops the chain",
+			hooks: []config.Hook{
+				{Command: `echo '{"decision":"block","reason":"not now"}'`},
+				{Command: "echo unreachable"},
+			},
+			input:  Input{Event: config.HookUserPromptSubmit, Prompt: "hi"},
+			expect: Result{Blocked: true, Reason: "not now"},
+		},
+		{
+			name:   "hook receives input on stdin",
+			hooks:  []config.Hook{{Command: `grep -o '"prompt":"[^"]*"'`}},
+			input:  Input{Event: config.HookUserPromptSubmit, SessionID: "s1", Prompt: "hi"},
+			expect: Result{Context: `"prompt":"hi"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			runner, err := NewRunner(dir, config.Hooks{tt.input.Event: tt.hooks})
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expect, runner.Run(t.Context(), tt.input))
+		})
+	}
+}
+
+func TestNewRunnerValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRunner("/tmp", config.Hooks{"OnLaunch": {{Command: "true"}}})
+	require.Error(t, err)
+
+	_, err = NewRunner("/tmp", config.Hooks{config.HookPreToolUse: {{Command: " "}}})
+	require.Error(t, err)
+
+	_, err = NewRunner("/tmp", config.Hooks{config.HookPreToolUse: {{Command: "true", Matcher: "("}}})
+	require.Error(t, err)
+}
+
+func TestNilRunner(t *testing.T) {
+	t.Parallel()
+
+	var runner *Runner
+	require.False(t, runner.Has(config.HookStop))
+	require.Equal(t, Result{}, runner.Run(t.Context(), Input{Event: config.HookStop}))
+}
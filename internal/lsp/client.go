@@ -17,7 +17,6 @@ import (
 	"github.com/charmbracelet/brush/internal/csync"
 	"github.com/charmbracelet/brush/internal/fsext"
 	"github.com/charmbracelet/brush/internal/home"
-	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/charmbracelet/x/powernap/pkg/transport"
 )
@@ -31,7 +30,7 @@ type DiagnosticCounts struct {
 }
 
 type Client struct {
-	client *powernap.Client
+	server *server
 	name   string
 
 	// File types this LSP server handles (e.g., .go, .rs, .py)
@@ -60,9 +59,15 @@ type Client struct {
 
 	// Server state
 	serverState atomic.Value
+
+	// commandMu serializes the commands of code actions, so the edits the
+	// server applies while one runs go through its editHook.
+	commandMu  sync.Mutex
+	editHookMu sync.Mutex
+	editHook   func(protocol.WorkspaceEdit) error
 }
 
-// New creates a new LSP client using the powernap implementation.
+// New creates a new LSP client and starts its server.
 func New(ctx context.Context, name string, cfg config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
 	client := &Client{
 		name:        name,
@@ -75,7 +80,7 @@ func New(ctx context.Context, name string, cfg config.LSPConfig, resolver config
 	}
 	client.serverState.Store(StateStarting)
 
-	if err := client.createPowernapClient(); err != nil {
+	if err := client.startServer(); err != nil {
 		return nil, err
 	}
 
@@ -84,12 +89,11 @@ func New(ctx context.Context, name string, cfg config.LSPConfig, resolver config
 
 // Initialize initializes the LSP client and returns the server capabilities.
 func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
-	if err := c.client.Initialize(ctx, false); err != nil {
+	if err := c.server.Initialize(ctx); err != nil {
 		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
 	}
 
-	// Convert powernap capabilities to protocol capabilities
-	caps := c.client.GetCapabilities()
+	caps := c.server.Capabilities()
 	protocolCaps := protocol.ServerCapabilities{
 		TextDocumentSync: caps.TextDocumentSync,
 		CompletionProvider: func() *protocol.CompletionOptions {
@@ -118,15 +122,15 @@ func (c *Client) Close(ctx context.Context) error {
 	c.CloseAllFiles(ctx)
 
 	// Shutdown and exit the client
-	if err := c.client.Shutdown(ctx); err != nil {
+	if err := c.server.Shutdown(ctx); err != nil {
 		slog.Warn("Failed to shutdown LSP client", "error", err)
 	}
 
-	return c.client.Exit()
+	return c.server.Exit()
 }
 
-// createPowernapClient creates a new powernap client with the current configuration.
-func (c *Client) createPowernapClient() error {
+// startServer starts the server with the current configuration.
+func (c *Client) startServer() error {
 	workDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get working directory: %w", err)
@@ -139,11 +143,11 @@ func (c *Client) createPowernapClient() error {
 		return fmt.Errorf("invalid lsp command: %w", err)
 	}
 
-	clientConfig := powernap.ClientConfig{
+	server, err := startServer(serverConfig{
 		Command:     home.Long(command),
 		Args:        c.config.Args,
 		RootURI:     rootURI,
-		Environment: maps.Clone(c.config.Env),
+		Env:         maps.Clone(c.config.Env),
 		Settings:    c.config.Options,
 		InitOptions: c.config.InitOptions,
 		WorkspaceFolders: []protocol.WorkspaceFolder{
@@ -152,20 +156,20 @@ func (c *Client) createPowernapClient() error {
 				Name: filepath.Base(workDir),
 			},
 		},
-	}
-
-	powernapClient, err := powernap.NewClient(clientConfig)
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create lsp client: %w", err)
 	}
 
-	c.client = powernapClient
+	c.server = server
 	return nil
 }
 
 // registerHandlers registers the standard LSP notification and request handlers.
 func (c *Client) registerHandlers() {
-	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
+	c.RegisterServerRequestHandler("workspace/applyEdit", func(_ context.Context, _ string, params json.RawMessage) (any, error) {
+		return HandleApplyEdit(c, params)
+	})
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
 	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
@@ -191,7 +195,7 @@ func (c *Client) Restart() error {
 	c.diagCountsCache = DiagnosticCounts{}
 	c.diagCountsVersion = 0
 
-	if err := c.createPowernapClient(); err != nil {
+	if err := c.startServer(); err != nil {
 		return err
 	}
 
@@ -200,7 +204,7 @@ func (c *Client) Restart() error {
 
 	c.SetServerState(StateStarting)
 
-	if err := c.client.Initialize(initCtx, false); err != nil {
+	if err := c.server.Initialize(initCtx); err != nil {
 		c.SetServerState(StateError)
 		return fmt.Errorf("failed to initialize lsp client: %w", err)
 	}
@@ -249,6 +253,11 @@ func (c *Client) GetName() string {
 	return c.name
 }
 
+// Config returns the configuration of the LSP client.
+func (c *Client) Config() config.LSPConfig {
+	return c.config
+}
+
 // SetDiagnosticsCallback sets the callback function for diagnostic changes
 func (c *Client) SetDiagnosticsCallback(callback func(name string, count int)) {
 	c.onDiagnosticsChanged = callback
@@ -282,7 +291,7 @@ func (c *Client) WaitForServerReady(ctx context.Context) error {
 			return fmt.Errorf("timeout waiting for LSP server to be ready")
 		case <-ticker.C:
 			// Check if client is running
-			if !c.client.IsRunning() {
+			if !c.server.IsRunning() {
 				if cfg != nil && cfg.Options.DebugLSP {
 					slog.Debug("LSP server not ready yet", "server", c.name)
 				}
@@ -346,7 +355,15 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	}
 
 	// Notify the server about the opened document
-	if err = c.client.NotifyDidOpenTextDocument(ctx, uri, string(DetectLanguageID(uri)), 1, string(content)); err != nil {
+	params := protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        protocol.DocumentURI(uri),
+			LanguageID: DetectLanguageID(uri),
+			Version:    1,
+			Text:       string(content),
+		},
+	}
+	if err = c.server.Notify(ctx, "textDocument/didOpen", params); err != nil {
 		return err
 	}
 
@@ -384,7 +401,14 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		},
 	}
 
-	return c.client.NotifyDidChangeTextDocument(ctx, uri, int(fileInfo.Version), changes)
+	params := protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI(uri)},
+			Version:                fileInfo.Version,
+		},
+		ContentChanges: changes,
+	}
+	return c.server.Notify(ctx, "textDocument/didChange", params)
 }
 
 // IsFileOpen checks if a file is currently open.
@@ -402,7 +426,10 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 		if debugLSP {
 			slog.Debug("Closing file", "file", uri)
 		}
-		if err := c.client.NotifyDidCloseTextDocument(ctx, uri); err != nil {
+		params := protocol.DidCloseTextDocumentParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI(uri)},
+		}
+		if err := c.server.Notify(ctx, "textDocument/didClose", params); err != nil {
 			slog.Warn("Error closing file", "uri", uri, "error", err)
 			continue
 		}
@@ -493,17 +520,17 @@ func (c *Client) ClearDiagnosticsForURI(uri protocol.DocumentURI) {
 
 // RegisterNotificationHandler registers a notification handler.
 func (c *Client) RegisterNotificationHandler(method string, handler transport.NotificationHandler) {
-	c.client.RegisterNotificationHandler(method, handler)
+	c.server.RegisterNotificationHandler(method, handler)
 }
 
 // RegisterServerRequestHandler handles server requests.
 func (c *Client) RegisterServerRequestHandler(method string, handler transport.Handler) {
-	c.client.RegisterHandler(method, handler)
+	c.server.RegisterHandler(method, handler)
 }
 
 // DidChangeWatchedFiles sends a workspace/didChangeWatchedFiles notification to the server.
 func (c *Client) DidChangeWatchedFiles(ctx context.Context, params protocol.DidChangeWatchedFilesParams) error {
-	return c.client.NotifyDidChangeWatchedFiles(ctx, params.Changes)
+	return c.server.Notify(ctx, "workspace/didChangeWatchedFiles", params)
 }
 
 // openKeyConfigFiles opens important configuration files that help initialize the server.
@@ -552,9 +579,15 @@ func (c *Client) FindReferences(ctx context.Context, filepath string, line, char
 	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
 		return nil, err
 	}
-	// NOTE: line and character should be 0-based.
-	// See: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#position
-	return c.client.FindReferences(ctx, filepath, line-1, character-1, includeDeclaration)
+	params := protocol.ReferenceParams{
+		TextDocumentPositionParams: textDocumentPosition(filepath, line, character),
+		Context:                    protocol.ReferenceContext{IncludeDeclaration: includeDeclaration},
+	}
+	var result []protocol.Location
+	if err := c.call(ctx, "textDocument/references", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // HasRootMarkers checks if any of the specified root marker patterns exist in the given directory.
@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// indentation is the indentation of a file as set by .editorconfig files.
+// Zero values are unset.
+type indentation struct {
+	// style is "tab" or "space".
+	style    string
+	size     int
+	tabWidth int
+}
+
+// tabSize returns the width of an indentation level, if set.
+func (i indentation) tabSize() int {
+	if i.size > 0 {
+		return i.size
+	}
+	return i.tabWidth
+}
+
+// editorConfigIndentation returns the indentation the .editorconfig files in
+// the directories of path, up to the first one marked as root, set for it.
+func editorConfigIndentation(path string) indentation {
+	var files []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		file := filepath.Join(dir, ".editorconfig")
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+			if isEditorConfigRoot(file) {
+				break
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	// Closer files take precedence over the ones above them.
+	var indent indentation
+	for i := len(files) - 1; i >= 0; i-- {
+		applyEditorConfig(files[i], path, &indent)
+	}
+	return indent
+}
+
+// isEditorConfigRoot reports whether the preamble of the file sets root.
+func isEditorConfigRoot(file string) bool {
+	root := false
+	_ = readEditorConfig(file, func(section, key, value string) bool {
+		if section != "" {
+			return false
+		}
+		if key == "root" {
+			root = value == "true"
+		}
+		return true
+	})
+	return root
+}
+
+// applyEditorConfig sets the indentation the sections of the file matching
+// path have, in order.
+func applyEditorConfig(file, path string, indent *indentation) {
+	rel, err := filepath.Rel(filepath.Dir(file), path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	matches := map[string]bool{}
+	_ = readEditorConfig(file, func(section, key, value string) bool {
+		if section == "" {
+			return true
+		}
+		matched, ok := matches[section]
+		if !ok {
+			matched = matchEditorConfigSection(section, rel)
+			matches[section] = matched
+		}
+		if !matched {
+			return true
+		}
+		switch key {
+		case "indent_style":
+			indent.style = value
+		case "indent_size":
+			if n, err := strconv.Atoi(value); err == nil {
+				indent.size = n
+			}
+		case "tab_width":
+			if n, err := strconv.Atoi(value); err == nil {
+				indent.tabWidth = n
+			}
+		}
+		return true
+	})
+}
+
+// matchEditorConfigSection reports whether the glob of a section matches the
+// path, relative to the directory of the .editorconfig file. Globs without a
+// slash match the file name in any directory.
+func matchEditorConfigSection(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	// Unlike doublestar, ** matches across slashes within a path segment
+	// too, as in "src/**.js".
+	glob = strings.ReplaceAll(glob, "**", "**/*")
+	glob = strings.ReplaceAll(glob, "**/*/", "**/")
+	matched, err := doublestar.Match(strings.TrimPrefix(glob, "/"), rel)
+	return err == nil && matched
+}
+
+// readEditorConfig calls fn with the section, empty for the preamble, and
+// the lowercased key and value of every property of the file, until fn
+// returns false.
+func readEditorConfig(file string, fn func(section, key, value string) bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", line[0] == '#', line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !fn(section, key, value) {
+			break
+		}
+	}
+	return scanner.Err()
+}
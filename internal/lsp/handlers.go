@@ -45,12 +45,21 @@ func HandleRegisterCapability(_ context.Context, _ string, params json.RawMessag
 }
 
 // HandleApplyEdit handles workspace edit requests
-func HandleApplyEdit(_ context.Context, _ string, params json.RawMessage) (any, error) {
+func HandleApplyEdit(client *Client, params json.RawMessage) (any, error) {
 	var edit protocol.ApplyWorkspaceEditParams
 	if err := json.Unmarshal(params, &edit); err != nil {
 		return nil, err
 	}
 
+	client.editHookMu.Lock()
+	hook := client.editHook
+	client.editHookMu.Unlock()
+	if hook != nil {
+		if err := hook(edit.Edit); err != nil {
+			return protocol.ApplyWorkspaceEditResult{Applied: false, FailureReason: err.Error()}, nil
+		}
+	}
+
 	err := util.ApplyWorkspaceEdit(edit.Edit)
 	if err != nil {
 		slog.Error("Error applying workspace edit", "error", err)
@@ -0,0 +1,391 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/brush/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// CallHierarchyCall is a call to or from the item a call hierarchy was
+// prepared for.
+type CallHierarchyCall struct {
+	// Item is the caller of incoming calls, or the callee of outgoing ones.
+	Item protocol.CallHierarchyItem
+	// Ranges are the ranges of the calls, in the caller.
+	Ranges []protocol.Range
+}
+
+// Definition returns the locations where the symbol at the given position is
+// defined.
+func (c *Client) Definition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	var result json.RawMessage
+	if err := c.positionRequest(ctx, "textDocument/definition", filepath, line, character, &result); err != nil {
+		return nil, err
+	}
+	return parseLocations(result)
+}
+
+// Implementation returns the locations where the interface or abstract
+// symbol at the given position is implemented.
+func (c *Client) Implementation(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	var result json.RawMessage
+	if err := c.positionRequest(ctx, "textDocument/implementation", filepath, line, character, &result); err != nil {
+		return nil, err
+	}
+	return parseLocations(result)
+}
+
+// Hover returns the hover information of the symbol at the given position,
+// as markdown or plain text.
+func (c *Client) Hover(ctx context.Context, filepath string, line, character int) (string, error) {
+	var result struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.positionRequest(ctx, "textDocument/hover", filepath, line, character, &result); err != nil {
+		return "", err
+	}
+	return hoverText(result.Contents), nil
+}
+
+// DocumentSymbols returns the symbols of the file. Servers that only return
+// flat symbol information have their symbols returned without children.
+func (c *Client) DocumentSymbols(ctx context.Context, filepath string) ([]protocol.DocumentSymbol, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	params := protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+	}
+	var result []json.RawMessage
+	if err := c.call(ctx, "textDocument/documentSymbol", params, &result); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]protocol.DocumentSymbol, 0, len(result))
+	for _, raw := range result {
+		var symbol struct {
+			protocol.DocumentSymbol
+			Location *protocol.Location `json:"location"`
+		}
+		if err := json.Unmarshal(raw, &symbol); err != nil {
+			return nil, fmt.Errorf("invalid document symbol: %w", err)
+		}
+		if symbol.Location != nil {
+			symbol.Range = symbol.Location.Range
+			symbol.SelectionRange = symbol.Location.Range
+		}
+		symbols = append(symbols, symbol.DocumentSymbol)
+	}
+	return symbols, nil
+}
+
+// WorkspaceSymbols returns the symbols of the workspace that match the
+// query.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
+	var result []protocol.SymbolInformation
+	if err := c.call(ctx, "workspace/symbol", protocol.WorkspaceSymbolParams{Query: query}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IncomingCalls returns the calls to the symbol at the given position.
+func (c *Client) IncomingCalls(ctx context.Context, filepath string, line, character int) ([]CallHierarchyCall, error) {
+	items, err := c.prepareCallHierarchy(ctx, filepath, line, character)
+	if err != nil {
+		return nil, err
+	}
+	var calls []CallHierarchyCall
+	for _, item := range items {
+		var result []protocol.CallHierarchyIncomingCall
+		if err := c.call(ctx, "callHierarchy/incomingCalls", protocol.CallHierarchyIncomingCallsParams{Item: item}, &result); err != nil {
+			return nil, err
+		}
+		for _, call := range result {
+			calls = append(calls, CallHierarchyCall{Item: call.From, Ranges: call.FromRanges})
+		}
+	}
+	return calls, nil
+}
+
+// OutgoingCalls returns the calls made by the symbol at the given position.
+func (c *Client) OutgoingCalls(ctx context.Context, filepath string, line, character int) ([]CallHierarchyCall, error) {
+	items, err := c.prepareCallHierarchy(ctx, filepath, line, character)
+	if err != nil {
+		return nil, err
+	}
+	var calls []CallHierarchyCall
+	for _, item := range items {
+		var result []protocol.CallHierarchyOutgoingCall
+		if err := c.call(ctx, "callHierarchy/outgoingCalls", protocol.CallHierarchyOutgoingCallsParams{Item: item}, &result); err != nil {
+			return nil, err
+		}
+		for _, call := range result {
+			calls = append(calls, CallHierarchyCall{Item: call.To, Ranges: call.FromRanges})
+		}
+	}
+	return calls, nil
+}
+
+// Rename returns the edit that renames the symbol at the given position. It
+// doesn't apply it.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	pos := textDocumentPosition(filepath, line, character)
+	params := protocol.RenameParams{
+		TextDocument: pos.TextDocument,
+		Position:     pos.Position,
+		NewName:      newName,
+	}
+	var result *protocol.WorkspaceEdit
+	if err := c.call(ctx, "textDocument/rename", params, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("nothing to rename at the given position")
+	}
+	return result, nil
+}
+
+// Format returns the edits that format the file. It doesn't apply them.
+func (c *Client) Format(ctx context.Context, filepath string) ([]protocol.TextEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Options:      formattingOptions(c.config.TabSize, filepath, content),
+	}
+	var edits []protocol.TextEdit
+	if err := c.call(ctx, "textDocument/formatting", params, &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// formattingOptions returns the options to format the file with: the tab
+// size configured for the server, or else the indentation its .editorconfig
+// files set, or else tabs of 4 if the file is indented with tabs.
+func formattingOptions(tabSize int, path string, content []byte) protocol.FormattingOptions {
+	indent := editorConfigIndentation(path)
+	opts := protocol.FormattingOptions{
+		TabSize:      4,
+		InsertSpaces: !bytes.Contains(content, []byte("\n\t")),
+	}
+	if size := indent.tabSize(); size > 0 {
+		opts.TabSize = uint32(size)
+	}
+	if indent.style != "" {
+		opts.InsertSpaces = indent.style == "space"
+	}
+	if tabSize > 0 {
+		opts.TabSize = uint32(tabSize)
+	}
+	return opts
+}
+
+// CodeActions returns the code actions for the range of the file, given the
+// diagnostics in it. Only actions of the given kinds are returned, if any.
+// Commands are returned as actions that only run the command.
+func (c *Client) CodeActions(ctx context.Context, filepath string, rng protocol.Range, diagnostics []protocol.Diagnostic, only ...protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+	trigger := protocol.CodeActionInvoked
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+			TriggerKind: &trigger,
+		},
+	}
+	var result []json.RawMessage
+	if err := c.call(ctx, "textDocument/codeAction", params, &result); err != nil {
+		return nil, err
+	}
+	return parseCodeActions(result)
+}
+
+// ResolveCodeAction fills in the edit of the code action if the server
+// left it out.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	if action.Edit != nil || action.Data == nil {
+		return action, nil
+	}
+	var resolved protocol.CodeAction
+	if err := c.call(ctx, "codeAction/resolve", action, &resolved); err != nil {
+		return protocol.CodeAction{}, err
+	}
+	return resolved, nil
+}
+
+// ApplyCodeAction applies the edit of the code action, resolving it first if
+// needed, and then runs its command. Edits made by the command are applied
+// through the workspace/applyEdit handler. If before isn't nil, it's called
+// with every edit before it's applied, and an edit it returns an error for
+// isn't applied.
+func (c *Client) ApplyCodeAction(ctx context.Context, action protocol.CodeAction, before func(protocol.WorkspaceEdit) error) error {
+	action, err := c.ResolveCodeAction(ctx, action)
+	if err != nil {
+		return err
+	}
+	if action.Edit != nil {
+		if before != nil {
+			if err := before(*action.Edit); err != nil {
+				return err
+			}
+		}
+		if err := util.ApplyWorkspaceEdit(*action.Edit); err != nil {
+			return err
+		}
+	}
+	if action.Command == nil {
+		return nil
+	}
+
+	c.commandMu.Lock()
+	defer c.commandMu.Unlock()
+	c.setEditHook(before)
+	defer c.setEditHook(nil)
+
+	params := protocol.ExecuteCommandParams{
+		Command:   action.Command.Command,
+		Arguments: action.Command.Arguments,
+	}
+	var result json.RawMessage
+	return c.call(ctx, "workspace/executeCommand", params, &result)
+}
+
+func (c *Client) setEditHook(hook func(protocol.WorkspaceEdit) error) {
+	c.editHookMu.Lock()
+	defer c.editHookMu.Unlock()
+	c.editHook = hook
+}
+
+func (c *Client) prepareCallHierarchy(ctx context.Context, filepath string, line, character int) ([]protocol.CallHierarchyItem, error) {
+	var items []protocol.CallHierarchyItem
+	if err := c.positionRequest(ctx, "textDocument/prepareCallHierarchy", filepath, line, character, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// positionRequest sends a request about the given position of the file,
+// opening it if needed. Line and character are 1-based.
+func (c *Client) positionRequest(ctx context.Context, method, filepath string, line, character int, result any) error {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return err
+	}
+	return c.call(ctx, method, textDocumentPosition(filepath, line, character), result)
+}
+
+// call sends a request to the server and decodes its result.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	return c.server.Call(ctx, method, params, result)
+}
+
+func textDocumentPosition(filepath string, line, character int) protocol.TextDocumentPositionParams {
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Position: protocol.Position{
+			Line:      uint32(max(line-1, 0)),      //nolint:gosec
+			Character: uint32(max(character-1, 0)), //nolint:gosec
+		},
+	}
+}
+
+// parseLocations parses a result that is a location, a list of locations or
+// a list of location links.
+func parseLocations(raw json.RawMessage) ([]protocol.Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if raw[0] != '[' {
+		raw = append(append(json.RawMessage{'['}, raw...), ']')
+	}
+	var items []struct {
+		protocol.Location
+		TargetURI            protocol.DocumentURI `json:"targetUri"`
+		TargetSelectionRange protocol.Range       `json:"targetSelectionRange"`
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("invalid locations: %w", err)
+	}
+	locations := make([]protocol.Location, 0, len(items))
+	for _, item := range items {
+		if item.TargetURI != "" {
+			item.Location = protocol.Location{URI: item.TargetURI, Range: item.TargetSelectionRange}
+		}
+		locations = append(locations, item.Location)
+	}
+	return locations, nil
+}
+
+// parseCodeActions parses the result of a code action request, which mixes
+// commands and code actions. Disabled actions are left out.
+func parseCodeActions(result []json.RawMessage) ([]protocol.CodeAction, error) {
+	actions := make([]protocol.CodeAction, 0, len(result))
+	for _, raw := range result {
+		var command protocol.Command
+		if err := json.Unmarshal(raw, &command); err == nil && command.Command != "" {
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(raw, &action); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		if action.Disabled == nil {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// hoverText returns the text of hover contents, which are a string, markup
+// content, a marked string or a list of marked strings.
+func hoverText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var content struct {
+		Language string `json:"language"`
+		Value    string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &content); err == nil {
+		if content.Language != "" {
+			return "```" + content.Language + "\n" + content.Value + "\n```"
+		}
+		return content.Value
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return ""
+	}
+	parts := make([]string, 0, len(list))
+	for _, item := range list {
+		if text := hoverText(item); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
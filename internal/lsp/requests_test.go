@@ -0,0 +1,142 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallNotInitialized(t *testing.T) {
+	t.Parallel()
+
+	server, err := startServer(serverConfig{Command: "cat"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Exit() })
+	c := &Client{server: server}
+	require.EqualError(t, c.call(t.Context(), "textDocument/definition", nil, nil), "client not initialized")
+}
+
+func TestParseLocations(t *testing.T) {
+	t.Parallel()
+
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 2},
+		End:   protocol.Position{Line: 1, Character: 5},
+	}
+	location := protocol.Location{URI: "file:///a.go", Range: rng}
+
+	for name, raw := range map[string]string{
+		"location":  `{"uri": "file:///a.go", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 5}}}`,
+		"locations": `[{"uri": "file:///a.go", "range": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 5}}}]`,
+		"links":     `[{"targetUri": "file:///a.go", "targetRange": {}, "targetSelectionRange": {"start": {"line": 1, "character": 2}, "end": {"line": 1, "character": 5}}}]`,
+	} {
+		locations, err := parseLocations(json.RawMessage(raw))
+		require.NoError(t, err, name)
+		require.Equal(t, []protocol.Location{location}, locations, name)
+	}
+
+	locations, err := parseLocations(json.RawMessage("null"))
+	require.NoError(t, err)
+	require.Empty(t, locations)
+}
+
+func TestHoverText(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "plain", hoverText(json.RawMessage(`"plain"`)))
+	require.Equal(t, "**markdown**", hoverText(json.RawMessage(`{"kind": "markdown", "value": "**markdown**"}`)))
+	require.Equal(t, "```go\nfunc f()\n```", hoverText(json.RawMessage(`{"language": "go", "value": "func f()"}`)))
+	require.Equal(t, "```go\nfunc f()\n```\n\ndoc", hoverText(json.RawMessage(`[{"language": "go", "value": "func f()"}, "doc"]`)))
+	require.Empty(t, hoverText(json.RawMessage(`null`)))
+}
+
+func TestParseCodeActions(t *testing.T) {
+	t.Parallel()
+
+	result := []json.RawMessage{
+		json.RawMessage(`{"title": "Organize imports", "command": "source.organizeImports", "arguments": ["file:///a.go"]}`),
+		json.RawMessage(`{"title": "Add import", "kind": "quickfix", "isPreferred": true, "command": {"title": "Add import", "command": "addImport"}}`),
+		json.RawMessage(`{"title": "Extract function", "kind": "refactor.extract", "disabled": {"reason": "no selection"}}`),
+	}
+	actions, err := parseCodeActions(result)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+
+	require.Equal(t, "Organize imports", actions[0].Title)
+	require.Equal(t, "source.organizeImports", actions[0].Command.Command)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`"file:///a.go"`)}, actions[0].Command.Arguments)
+
+	require.Equal(t, "Add import", actions[1].Title)
+	require.Equal(t, protocol.QuickFix, actions[1].Kind)
+	require.True(t, actions[1].IsPreferred)
+	require.Equal(t, "addImport", actions[1].Command.Command)
+}
+
+func TestHandleApplyEditHook(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "a.go")
+	require.NoError(t, os.WriteFile(path, []byte("package a\n"), 0o644))
+	params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{
+		Edit: protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath(path): {{NewText: "// a\n"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	c := &Client{}
+	c.setEditHook(func(protocol.WorkspaceEdit) error { return errors.New("denied") })
+	result, err := HandleApplyEdit(c, params)
+	require.NoError(t, err)
+	require.Equal(t, protocol.ApplyWorkspaceEditResult{FailureReason: "denied"}, result)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "package a\n", string(content))
+
+	c.setEditHook(nil)
+	result, err = HandleApplyEdit(c, params)
+	require.NoError(t, err)
+	require.Equal(t, protocol.ApplyWorkspaceEditResult{Applied: true}, result)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "// a\npackage a\n", string(content))
+}
+
+func TestFormattingOptions(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "web", "src")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".editorconfig"), []byte(`root = true
+
+[*]
+indent_style = tab
+tab_width = 8
+
+[web/**.{js,ts}]
+indent_style = space
+indent_size = 2
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, ".editorconfig"), []byte(`[*.ts]
+indent_size = 4
+`), 0o644))
+
+	goFile := filepath.Join(root, "main.go")
+	require.Equal(t, protocol.FormattingOptions{TabSize: 8}, formattingOptions(0, goFile, nil))
+	require.Equal(t, protocol.FormattingOptions{TabSize: 2, InsertSpaces: true}, formattingOptions(0, filepath.Join(sub, "a.js"), nil))
+	require.Equal(t, protocol.FormattingOptions{TabSize: 4, InsertSpaces: true}, formattingOptions(0, filepath.Join(sub, "a.ts"), nil))
+	require.Equal(t, protocol.FormattingOptions{TabSize: 3}, formattingOptions(3, goFile, nil))
+
+	// Without an .editorconfig, files indented with tabs keep them.
+	other := filepath.Join(t.TempDir(), "a.go")
+	require.Equal(t, protocol.FormattingOptions{TabSize: 4}, formattingOptions(0, other, []byte("func a() {\n\treturn\n}\n")))
+	require.Equal(t, protocol.FormattingOptions{TabSize: 4, InsertSpaces: true}, formattingOptions(0, other, []byte("a:\n  b\n")))
+}
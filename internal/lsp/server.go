@@ -0,0 +1,373 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+)
+
+// server is a language server process and the connection to it.
+//
+// The powernap client only wraps a few requests and keeps its connection
+// unexported, so the server is run over the powernap transport instead, which
+// can send any request.
+type server struct {
+	command          string
+	conn             *transport.Connection
+	cancel           context.CancelFunc
+	rootURI          string
+	workspaceFolders []protocol.WorkspaceFolder
+	settings         map[string]any
+	initOptions      map[string]any
+	capabilities     protocol.ServerCapabilities
+	initialized      atomic.Bool
+	shutdown         atomic.Bool
+}
+
+// serverConfig is the configuration of a language server process.
+type serverConfig struct {
+	Command          string
+	Args             []string
+	Env              map[string]string
+	RootURI          string
+	WorkspaceFolders []protocol.WorkspaceFolder
+	Settings         map[string]any
+	InitOptions      map[string]any
+}
+
+// startServer starts the language server process and connects to it.
+func startServer(cfg serverConfig) (*server, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := startServerProcess(ctx, cfg)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start language server: %w", err)
+	}
+	conn, err := transport.NewConnection(ctx, stream, slog.Default())
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create connection: %w", err)
+	}
+
+	s := &server{
+		command:          cfg.Command,
+		conn:             conn,
+		cancel:           cancel,
+		rootURI:          cfg.RootURI,
+		workspaceFolders: cfg.WorkspaceFolders,
+		settings:         cfg.Settings,
+		initOptions:      cfg.InitOptions,
+	}
+	if s.workspaceFolders == nil {
+		// Some servers don't accept a null list.
+		s.workspaceFolders = []protocol.WorkspaceFolder{}
+	}
+	// Servers may ask for their settings while initializing.
+	conn.RegisterHandler("workspace/configuration", func(_ context.Context, _ string, params json.RawMessage) (any, error) {
+		var configParams protocol.ConfigurationParams
+		if err := json.Unmarshal(params, &configParams); err != nil {
+			return nil, err
+		}
+		result := make([]any, len(configParams.Items))
+		for i := range result {
+			result[i] = s.settings
+		}
+		return result, nil
+	})
+	return s, nil
+}
+
+// Initialize sends the initialize request and the initialized notification.
+func (s *server) Initialize(ctx context.Context) error {
+	if s.initialized.Load() {
+		return errors.New("client already initialized")
+	}
+
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]any{
+			"name":    "brush",
+			"version": "0.1.0",
+		},
+		"locale":                "en-us",
+		"rootPath":              strings.TrimPrefix(s.rootURI, "file://"),
+		"rootUri":               s.rootURI,
+		"capabilities":          clientCapabilities(),
+		"workspaceFolders":      s.workspaceFolders,
+		"initializationOptions": s.initOptions,
+		"trace":                 "off",
+	}
+	var result protocol.InitializeResult
+	if err := s.conn.Call(ctx, "initialize", params, &result); err != nil {
+		return fmt.Errorf("initialize request failed: %w", err)
+	}
+	s.capabilities = result.Capabilities
+
+	if err := s.conn.Notify(ctx, "initialized", map[string]any{}); err != nil {
+		return fmt.Errorf("initialized notification failed: %w", err)
+	}
+	s.initialized.Store(true)
+
+	// gopls only sets up its workspace views once it has its settings and
+	// has been told about the workspace.
+	if strings.Contains(s.command, "gopls") {
+		_ = s.conn.Notify(ctx, "workspace/didChangeConfiguration", map[string]any{"settings": s.settings})
+		if s.rootURI != "" {
+			_ = s.conn.Notify(ctx, "workspace/didChangeWatchedFiles", protocol.DidChangeWatchedFilesParams{
+				Changes: []protocol.FileEvent{{URI: protocol.DocumentURI(s.rootURI), Type: protocol.Created}},
+			})
+		}
+	}
+	return nil
+}
+
+// Capabilities returns the capabilities the server replied with.
+func (s *server) Capabilities() protocol.ServerCapabilities {
+	return s.capabilities
+}
+
+// IsRunning reports whether the server is initialized and connected.
+func (s *server) IsRunning() bool {
+	return s.conn.IsConnected() && s.initialized.Load() && !s.shutdown.Load()
+}
+
+// Call sends a request to the server and decodes its result.
+func (s *server) Call(ctx context.Context, method string, params, result any) error {
+	if !s.initialized.Load() {
+		return errors.New("client not initialized")
+	}
+	if err := s.conn.Call(ctx, method, params, result); err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	return nil
+}
+
+// Notify sends a notification to the server.
+func (s *server) Notify(ctx context.Context, method string, params any) error {
+	if !s.initialized.Load() {
+		return errors.New("client not initialized")
+	}
+	return s.conn.Notify(ctx, method, params)
+}
+
+// RegisterHandler registers a handler for requests from the server.
+func (s *server) RegisterHandler(method string, handler transport.Handler) {
+	s.conn.RegisterHandler(method, handler)
+}
+
+// RegisterNotificationHandler registers a handler for notifications from the
+// server.
+func (s *server) RegisterNotificationHandler(method string, handler transport.NotificationHandler) {
+	s.conn.RegisterNotificationHandler(method, handler)
+}
+
+// Shutdown asks the server to shut down.
+func (s *server) Shutdown(ctx context.Context) error {
+	if s.shutdown.Load() {
+		return nil
+	}
+	if err := s.conn.Call(ctx, "shutdown", nil, nil); err != nil {
+		return fmt.Errorf("shutdown request failed: %w", err)
+	}
+	s.shutdown.Store(true)
+	return nil
+}
+
+// Exit tells the server to exit and stops the process.
+func (s *server) Exit() error {
+	defer s.cancel()
+	if err := s.conn.Notify(context.Background(), "exit", nil); err != nil {
+		return fmt.Errorf("exit notification failed: %w", err)
+	}
+	return nil
+}
+
+// startServerProcess starts the language server process and returns a
+// stream over its stdin and stdout. Its stderr is logged.
+func startServerProcess(ctx context.Context, cfg serverConfig) (io.ReadWriteCloser, error) {
+	cmd := exec.CommandContext(ctx, cfg.Command, cfg.Args...) //nolint:gosec
+	if cfg.Env != nil {
+		cmd.Env = os.Environ()
+		for k, v := range cfg.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := stderr.Read(buf)
+			if n > 0 {
+				slog.Error("Language server stderr", "command", cfg.Command, "output", string(buf[:n]))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	closer := &processCloser{cmd: cmd, closers: []io.Closer{stdin, stdout, stderr}}
+	return transport.NewStreamTransport(stdout, stdin, closer), nil
+}
+
+// processCloser closes the pipes of a process and waits for it to exit,
+// killing it if it doesn't.
+type processCloser struct {
+	cmd     *exec.Cmd
+	closers []io.Closer
+	once    sync.Once
+	err     error
+}
+
+func (p *processCloser) Close() error {
+	p.once.Do(func() {
+		var errs []error
+		for _, c := range p.closers {
+			errs = append(errs, c.Close())
+		}
+		done := make(chan error, 1)
+		go func() { done <- p.cmd.Wait() }()
+		select {
+		case err := <-done:
+			errs = append(errs, err)
+		case <-time.After(5 * time.Second):
+			errs = append(errs, p.cmd.Process.Kill())
+			<-done
+		}
+		p.err = errors.Join(errs...)
+	})
+	return p.err
+}
+
+// clientCapabilities returns the capabilities of the client, for the
+// requests brush sends and the ones it handles.
+func clientCapabilities() map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{
+			"synchronization": map[string]any{
+				"dynamicRegistration": true,
+				"didSave":             true,
+			},
+			"hover": map[string]any{
+				"dynamicRegistration": true,
+				"contentFormat":       []string{"markdown", "plaintext"},
+			},
+			"definition": map[string]any{
+				"dynamicRegistration": true,
+				"linkSupport":         true,
+			},
+			"implementation": map[string]any{
+				"dynamicRegistration": true,
+				"linkSupport":         true,
+			},
+			"references": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"documentSymbol": map[string]any{
+				"dynamicRegistration":               true,
+				"hierarchicalDocumentSymbolSupport": true,
+			},
+			"callHierarchy": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"formatting": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"rename": map[string]any{
+				"dynamicRegistration": true,
+				"prepareSupport":      true,
+			},
+			"publishDiagnostics": map[string]any{
+				"relatedInformation":     true,
+				"versionSupport":         true,
+				"tagSupport":             map[string]any{"valueSet": []int{1, 2}},
+				"codeDescriptionSupport": true,
+				"dataSupport":            true,
+			},
+			"codeAction": map[string]any{
+				"dynamicRegistration": true,
+				"codeActionLiteralSupport": map[string]any{
+					"codeActionKind": map[string]any{
+						"valueSet": []string{
+							"quickfix",
+							"refactor",
+							"refactor.extract",
+							"refactor.inline",
+							"refactor.rewrite",
+							"source",
+							"source.organizeImports",
+						},
+					},
+				},
+				"isPreferredSupport": true,
+				"dataSupport":        true,
+				"resolveSupport": map[string]any{
+					"properties": []string{"edit"},
+				},
+			},
+		},
+		"workspace": map[string]any{
+			"applyEdit": true,
+			"workspaceEdit": map[string]any{
+				"documentChanges":       true,
+				"resourceOperations":    []string{"create", "rename", "delete"},
+				"failureHandling":       "textOnlyTransactional",
+				"normalizesLineEndings": true,
+			},
+			"didChangeConfiguration": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"didChangeWatchedFiles": map[string]any{
+				"dynamicRegistration":    true,
+				"relativePatternSupport": true,
+			},
+			"symbol": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"executeCommand": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"configuration":    true,
+			"workspaceFolders": true,
+		},
+		"window": map[string]any{
+			"workDoneProgress": true,
+			"showMessage": map[string]any{
+				"messageActionItem": map[string]any{
+					"additionalPropertiesSupport": true,
+				},
+			},
+		},
+		"general": map[string]any{
+			"positionEncodings": []string{"utf-16"},
+		},
+	}
+}
@@ -59,6 +59,9 @@ func (ReasoningContent) isPart() {}
 
 type TextContent struct {
 	Text string `json:"text"`
+	// HookContext is the context hooks added to a prompt. It's sent to the
+	// model along with the text, but not shown.
+	HookContext string `json:"hook_context,omitempty"`
 }
 
 func (tc TextContent) String() string {
@@ -135,6 +138,8 @@ type Message struct {
 	CreatedAt        int64
 	UpdatedAt        int64
 	IsSummaryMessage bool
+	// Retries is the number of times the provider request was retried.
+	Retries int
 }
 
 func (m *Message) Content() TextContent {
@@ -460,6 +465,14 @@ func PromptWithTextAttachments(prompt string, attachments []Attachment) string {
 	return sb.String()
 }
 
+// PromptWithHookContext appends the context added by hooks to the prompt.
+func PromptWithHookContext(prompt, hookContext string) string {
+	if hookContext == "" {
+		return prompt
+	}
+	return prompt + "\n<hook_context>\n" + hookContext + "\n</hook_context>\n"
+}
+
 func (m *Message) ToAIMessage() []fantasy.Message {
 	var messages []fantasy.Message
 	switch m.Role {
@@ -477,7 +490,7 @@ func (m *Message) ToAIMessage() []fantasy.Message {
 				Content:  content.Data,
 			})
 		}
-		text = PromptWithTextAttachments(text, textAttachments)
+		text = PromptWithHookContext(PromptWithTextAttachments(text, textAttachments), m.Content().HookContext)
 		if text != "" {
 			parts = append(parts, fantasy.TextPart{Text: text})
 		}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
 )
 
 func makeTestAttachments(n int, contentSize int) []Attachment {
@@ -43,3 +46,13 @@ func BenchmarkPromptWithTextAttachments(b *testing.B) {
 		})
 	}
 }
+
+func TestToAIMessageHookContext(t *testing.T) {
+	t.Parallel()
+
+	msg := Message{Role: User, Parts: []ContentPart{TextContent{Text: "hello", HookContext: "it's monday"}}}
+	aiMsgs := msg.ToAIMessage()
+	require.Len(t, aiMsgs, 1)
+	require.Equal(t, fantasy.TextPart{Text: "hello\n<hook_context>\nit's monday\n</hook_context>\n"}, aiMsgs[0].Content[0])
+	require.Equal(t, "hello", msg.Content().Text)
+}
@@ -21,9 +21,12 @@ type CreateMessageParams struct {
 }
 
 type Service interface {
-	pubsub.Subscriber[Message]
+	pubsub.LosslessSubscriber[Message]
 	Create(ctx context.Context, sessionID string, params CreateMessageParams) (Message, error)
 	Update(ctx context.Context, message Message) error
+	// Stream returns a write-behind buffer for the updates of a message
+	// being streamed.
+	Stream(ctx context.Context, message Message) *Stream
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
 	Delete(ctx context.Context, id string) error
@@ -110,6 +113,18 @@ func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) e
 }
 
 func (s *service) Update(ctx context.Context, message Message) error {
+	if err := s.write(ctx, message); err != nil {
+		return err
+	}
+	message.UpdatedAt = time.Now().Unix()
+	// Clone the message before publishing to avoid race conditions with
+	// concurrent modifications to the Parts slice.
+	s.Publish(pubsub.UpdatedEvent, message.Clone())
+	return nil
+}
+
+// write writes the message to the database without publishing it.
+func (s *service) write(ctx context.Context, message Message) error {
 	parts, err := marshalParts(message.Parts)
 	if err != nil {
 		return err
@@ -119,19 +134,12 @@ func (s *service) Update(ctx context.Context, message Message) error {
 		finishedAt.Int64 = f.Time
 		finishedAt.Valid = true
 	}
-	err = s.q.UpdateMessage(ctx, db.UpdateMessageParams{
+	return s.q.UpdateMessage(ctx, db.UpdateMessageParams{
 		ID:         message.ID,
 		Parts:      string(parts),
 		FinishedAt: finishedAt,
+		Retries:    int64(message.Retries),
 	})
-	if err != nil {
-		return err
-	}
-	message.UpdatedAt = time.Now().Unix()
-	// Clone the message before publishing to avoid race conditions with
-	// concurrent modifications to the Parts slice.
-	s.Publish(pubsub.UpdatedEvent, message.Clone())
-	return nil
 }
 
 func (s *service) Get(ctx context.Context, id string) (Message, error) {
@@ -172,9 +180,72 @@ func (s *service) fromDBItem(item db.Message) (Message, error) {
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 		IsSummaryMessage: item.IsSummaryMessage != 0,
+		Retries:          int(item.Retries),
 	}, nil
 }
 
+type messageJSON struct {
+	ID               string          `json:"id"`
+	Role             MessageRole     `json:"role"`
+	SessionID        string          `json:"session_id"`
+	Parts            json.RawMessage `json:"parts"`
+	Model            string          `json:"model,omitempty"`
+	Provider         string          `json:"provider,omitempty"`
+	CreatedAt        int64           `json:"created_at"`
+	UpdatedAt        int64           `json:"updated_at"`
+	IsSummaryMessage bool            `json:"is_summary_message,omitempty"`
+	Retries          int             `json:"retries,omitempty"`
+}
+
+// MarshalJSON encodes the message with its parts tagged by type, the same
+// way they are stored in the database.
+func (m Message) MarshalJSON() ([]byte, error) {
+	parts, err := marshalParts(m.Parts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(messageJSON{
+		ID:               m.ID,
+		Role:             m.Role,
+		SessionID:        m.SessionID,
+		Parts:            parts,
+		Model:            m.Model,
+		Provider:         m.Provider,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+		IsSummaryMessage: m.IsSummaryMessage,
+		Retries:          m.Retries,
+	})
+}
+
+// UnmarshalJSON decodes a message encoded by [Message.MarshalJSON].
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var item messageJSON
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+	var parts []ContentPart
+	if len(item.Parts) > 0 && string(item.Parts) != "null" {
+		var err error
+		if parts, err = unmarshalParts(item.Parts); err != nil {
+			return err
+		}
+	}
+	*m = Message{
+		ID:               item.ID,
+		Role:             item.Role,
+		SessionID:        item.SessionID,
+		Parts:            parts,
+		Model:            item.Model,
+		Provider:         item.Provider,
+		CreatedAt:        item.CreatedAt,
+		UpdatedAt:        item.UpdatedAt,
+		IsSummaryMessage: item.IsSummaryMessage,
+		Retries:          item.Retries,
+	}
+	return nil
+}
+
 type partType string
 
 const (
@@ -192,6 +263,11 @@ type partWrapper struct {
 	Data ContentPart `json:"data"`
 }
 
+// MarshalParts encodes parts the way they are stored in the database.
+func MarshalParts(parts []ContentPart) ([]byte, error) {
+	return marshalParts(parts)
+}
+
 func marshalParts(parts []ContentPart) ([]byte, error) {
 	wrappedParts := make([]partWrapper, len(parts))
 
@@ -0,0 +1,168 @@
+package message
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/pubsub"
+)
+
+const (
+	// streamPublishInterval is how often the updates of a streamed message
+	// are published, about the frame rate of the UI.
+	streamPublishInterval = time.Second / 60
+	// streamFlushInterval is how often the updates of a streamed message are
+	// written to the database.
+	streamFlushInterval = time.Second
+)
+
+// Stream is a write-behind buffer for a message being streamed. Instead of
+// writing the whole message for every token, updates are published at frame
+// rate and written on an interval, or right away with [Stream.Flush].
+type Stream struct {
+	service         *service
+	ctx             context.Context
+	publishInterval time.Duration
+	flushInterval   time.Duration
+
+	mu          sync.Mutex
+	message     Message
+	published   bool
+	saved       bool
+	closed      bool
+	lastPublish time.Time
+	lastFlush   time.Time
+	timer       *time.Timer
+	err         error
+}
+
+// Stream returns a write-behind buffer for the updates of the message. The
+// stream must be closed once the message is complete.
+func (s *service) Stream(ctx context.Context, message Message) *Stream {
+	return &Stream{
+		service: s,
+		// Pending updates are written even if the request is canceled.
+		ctx:             context.WithoutCancel(ctx),
+		publishInterval: streamPublishInterval,
+		flushInterval:   streamFlushInterval,
+		message:         message.Clone(),
+		published:       true,
+		saved:           true,
+		lastFlush:       time.Now(),
+	}
+}
+
+// Update buffers the new state of the message. It's published and written
+// later, unless it's been long enough since the last time.
+func (s *Stream) Update(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.err; err != nil {
+		s.err = nil
+		return err
+	}
+	s.message = message.Clone()
+	s.published, s.saved = false, false
+	if s.closed || time.Since(s.lastFlush) >= s.flushInterval {
+		return s.flush(ctx)
+	}
+	if time.Since(s.lastPublish) >= s.publishInterval {
+		s.publish()
+	}
+	s.schedule(s.publishInterval)
+	return nil
+}
+
+// Flush writes the new state of the message right away, along with any
+// buffered update, e.g. at the end of a step.
+func (s *Stream) Flush(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.message = message.Clone()
+	s.published, s.saved = false, false
+	s.err = nil
+	return s.flush(ctx)
+}
+
+// Close writes any buffered update and stops the stream. Updates after
+// Close are written right away.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.saved {
+		err := s.err
+		s.err = nil
+		return err
+	}
+	return s.flush(s.ctx)
+}
+
+// flush writes and publishes the buffered message. It must be called with
+// the lock held.
+func (s *Stream) flush(ctx context.Context) error {
+	if s.saved {
+		return nil
+	}
+	if err := s.service.write(ctx, s.message); err != nil {
+		return err
+	}
+	s.saved = true
+	s.lastFlush = time.Now()
+	s.publish()
+	return nil
+}
+
+// publish publishes the buffered message. It must be called with the lock
+// held.
+func (s *Stream) publish() {
+	if s.published {
+		return
+	}
+	message := s.message.Clone()
+	message.UpdatedAt = time.Now().Unix()
+	s.service.Publish(pubsub.UpdatedEvent, message)
+	s.published = true
+	s.lastPublish = time.Now()
+}
+
+// schedule arms the timer that publishes and writes buffered updates when
+// the stream goes quiet. It must be called with the lock held.
+func (s *Stream) schedule(delay time.Duration) {
+	if s.timer != nil || s.closed {
+		return
+	}
+	s.timer = time.AfterFunc(delay, s.tick)
+}
+
+func (s *Stream) tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timer = nil
+	if s.closed {
+		return
+	}
+	s.publish()
+	if !s.saved && time.Since(s.lastFlush) >= s.flushInterval {
+		if err := s.flush(s.ctx); err != nil {
+			slog.Error("Failed to write streamed message", "message_id", s.message.ID, "error", err)
+			// Report it on the next update, and keep the flush from being
+			// retried on every tick.
+			s.err = err
+			s.lastFlush = time.Now()
+		}
+	}
+	if !s.saved {
+		s.schedule(time.Until(s.lastFlush.Add(s.flushInterval)))
+	}
+}
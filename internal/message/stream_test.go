@@ -0,0 +1,114 @@
+package message
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+// countingQuerier counts the writes of messages.
+type countingQuerier struct {
+	db.Querier
+	updates atomic.Int64
+}
+
+func (q *countingQuerier) UpdateMessage(ctx context.Context, arg db.UpdateMessageParams) error {
+	q.updates.Add(1)
+	return q.Querier.UpdateMessage(ctx, arg)
+}
+
+func newTestStream(t *testing.T) (*service, *countingQuerier, Message) {
+	t.Helper()
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := &countingQuerier{Querier: db.New(conn)}
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "session"})
+	require.NoError(t, err)
+
+	s := NewService(q).(*service)
+	msg, err := s.Create(ctx, "session", CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	return s, q, msg
+}
+
+func storedText(t *testing.T, s *service, id string) string {
+	t.Helper()
+	msg, err := s.Get(t.Context(), id)
+	require.NoError(t, err)
+	return msg.Content().Text
+}
+
+func TestStream_BuffersDeltas(t *testing.T) {
+	t.Parallel()
+
+	s, q, msg := newTestStream(t)
+	events := s.Subscribe(t.Context())
+	stream := s.Stream(t.Context(), msg)
+
+	for range 1000 {
+		msg.AppendContent("x")
+		require.NoError(t, stream.Update(t.Context(), msg))
+	}
+	require.Zero(t, q.updates.Load())
+
+	require.NoError(t, stream.Close())
+	require.EqualValues(t, 1, q.updates.Load())
+	require.Len(t, storedText(t, s, msg.ID), 1000)
+
+	// The last event has the whole message.
+	var last Message
+	for len(events) > 0 {
+		last = (<-events).Payload
+	}
+	require.Len(t, last.Content().Text, 1000)
+}
+
+func TestStream_FlushesWhenQuiet(t *testing.T) {
+	t.Parallel()
+
+	s, q, msg := newTestStream(t)
+	stream := s.Stream(t.Context(), msg)
+	stream.publishInterval = time.Millisecond
+	stream.flushInterval = 20 * time.Millisecond
+	t.Cleanup(func() { stream.Close() })
+
+	msg.AppendContent("hello")
+	require.NoError(t, stream.Update(t.Context(), msg))
+	require.Eventually(t, func() bool {
+		return q.updates.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, "hello", storedText(t, s, msg.ID))
+}
+
+func TestStream_Flush(t *testing.T) {
+	t.Parallel()
+
+	s, q, msg := newTestStream(t)
+	stream := s.Stream(t.Context(), msg)
+
+	msg.AppendContent("hello")
+	require.NoError(t, stream.Update(t.Context(), msg))
+	msg.AddFinish(FinishReasonEndTurn, "", "")
+
+	// A failed flush keeps the update buffered for the next one.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.Error(t, stream.Flush(ctx, msg))
+	require.NoError(t, stream.Flush(t.Context(), msg))
+	writes := q.updates.Load()
+
+	// Nothing is left to write when the stream is closed.
+	require.NoError(t, stream.Close())
+	require.Equal(t, writes, q.updates.Load())
+
+	stored, err := s.Get(t.Context(), msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "hello", stored.Content().Text)
+	require.NotNil(t, stored.FinishPart())
+}
@@ -0,0 +1,130 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/google/uuid"
+)
+
+// ErrGrantNotFound is returned when revoking a grant that doesn't exist.
+var ErrGrantNotFound = errors.New("permission grant not found")
+
+// Grant is a remembered "allow" decision for a tool action on a path. Grants
+// without a session ID apply to every session of the project.
+type Grant struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id,omitempty"`
+	ToolName  string `json:"tool_name"`
+	Action    string `json:"action"`
+	Path      string `json:"path"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func (g Grant) matches(p PermissionRequest) bool {
+	if g.SessionID != "" && g.SessionID != p.SessionID {
+		return false
+	}
+	return g.ToolName == p.ToolName && g.Action == p.Action && g.Path == p.Path
+}
+
+func grantFromDB(item db.PermissionGrant) Grant {
+	return Grant{
+		ID:        item.ID,
+		SessionID: item.SessionID.String,
+		ToolName:  item.ToolName,
+		Action:    item.Action,
+		Path:      item.Path,
+		CreatedAt: item.CreatedAt,
+	}
+}
+
+func (s *permissionService) addGrant(permission PermissionRequest, sessionID string) {
+	grant := Grant{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Path:      permission.Path,
+	}
+
+	if s.q != nil {
+		_, err := s.q.CreatePermissionGrant(context.Background(), db.CreatePermissionGrantParams{
+			ID:        grant.ID,
+			SessionID: sql.NullString{String: sessionID, Valid: sessionID != ""},
+			ToolName:  grant.ToolName,
+			Action:    grant.Action,
+			Path:      grant.Path,
+		})
+		if err == nil {
+			return
+		}
+		// Keep the grant for the lifetime of the process even if it could not
+		// be stored.
+		slog.Error("Failed to persist permission grant", "error", err, "tool", grant.ToolName)
+	}
+
+	s.grantsMu.Lock()
+	s.grants = append(s.grants, grant)
+	s.grantsMu.Unlock()
+}
+
+// hasGrant reports whether a stored grant covers the request. Grants are read
+// from the database every time so revocations from other processes, e.g. the
+// CLI, take effect immediately.
+func (s *permissionService) hasGrant(ctx context.Context, permission PermissionRequest) bool {
+	grants, err := s.ListGrants(ctx)
+	if err != nil {
+		slog.Error("Failed to list permission grants", "error", err)
+	}
+	for _, g := range grants {
+		if g.matches(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *permissionService) ListGrants(ctx context.Context) ([]Grant, error) {
+	s.grantsMu.RLock()
+	grants := slices.Clone(s.grants)
+	s.grantsMu.RUnlock()
+
+	if s.q == nil {
+		return grants, nil
+	}
+	items, err := s.q.ListPermissionGrants(ctx)
+	if err != nil {
+		return grants, err
+	}
+	for _, item := range items {
+		grants = append(grants, grantFromDB(item))
+	}
+	return grants, nil
+}
+
+func (s *permissionService) RevokeGrant(ctx context.Context, id string) error {
+	s.grantsMu.Lock()
+	n := len(s.grants)
+	s.grants = slices.DeleteFunc(s.grants, func(g Grant) bool {
+		return g.ID == id
+	})
+	revoked := len(s.grants) < n
+	s.grantsMu.Unlock()
+
+	if s.q != nil {
+		deleted, err := s.q.DeletePermissionGrant(ctx, id)
+		if err != nil {
+			return err
+		}
+		revoked = revoked || deleted > 0
+	}
+	if !revoked {
+		return ErrGrantNotFound
+	}
+	return nil
+}
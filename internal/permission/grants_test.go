@@ -0,0 +1,65 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionService_GrantsSurviveRestart(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	req := PermissionRequest{
+		ID:       "req-1",
+		ToolName: "bash",
+		Action:   "execute",
+		Path:     "/tmp",
+	}
+
+	service := NewPermissionService("/tmp", false, nil, nil, q)
+	service.GrantAlways(req)
+
+	// A fresh service backed by the same database sees the grant.
+	restarted := NewPermissionService("/tmp", false, nil, nil, q).(*permissionService)
+	other := req
+	other.SessionID = "another-session"
+	require.True(t, restarted.hasGrant(t.Context(), other))
+
+	grants, err := restarted.ListGrants(t.Context())
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	require.Empty(t, grants[0].SessionID)
+
+	require.NoError(t, restarted.RevokeGrant(t.Context(), grants[0].ID))
+	require.False(t, service.(*permissionService).hasGrant(t.Context(), other))
+
+	// Revoking it again, or an unknown grant, fails.
+	require.ErrorIs(t, restarted.RevokeGrant(t.Context(), grants[0].ID), ErrGrantNotFound)
+	require.ErrorIs(t, restarted.RevokeGrant(t.Context(), "unknown"), ErrGrantNotFound)
+}
+
+func TestPermissionService_SessionGrantsAreScoped(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, nil, nil, nil).(*permissionService)
+	req := PermissionRequest{
+		ID:        "req-1",
+		SessionID: "s1",
+		ToolName:  "edit",
+		Action:    "write",
+		Path:      "/tmp",
+	}
+	service.GrantPersistent(req)
+
+	require.True(t, service.hasGrant(t.Context(), req))
+
+	other := req
+	other.SessionID = "s2"
+	require.False(t, service.hasGrant(t.Context(), other))
+}
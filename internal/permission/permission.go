@@ -8,7 +8,9 @@ import (
 	"slices"
 	"sync"
 
+	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/db"
 	"github.com/charmbracelet/brush/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -23,6 +25,22 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// FilePath is the exact file the tool operates on, if any. It is used to
+	// match path rules since Path may be collapsed to the working directory.
+	FilePath string `json:"file_path,omitempty"`
+	// FilePaths are the files the tool operates on, if it operates on
+	// several. Path rules are matched against each of them.
+	FilePaths []string `json:"file_paths,omitempty"`
+	// Command is the shell command being executed, if any.
+	Command string `json:"command,omitempty"`
+	// MCPName is the name of the MCP server providing the tool, if any.
+	MCPName string `json:"mcp_name,omitempty"`
+	// AlwaysAsk prompts even if the tool is allowed or was granted before.
+	AlwaysAsk bool `json:"always_ask,omitempty"`
+	// Preapproved is set for requests the tool deems safe to run without
+	// asking, like read-only commands. Only policy rules deny them or ask
+	// for them.
+	Preapproved bool `json:"preapproved,omitempty"`
 }
 
 type PermissionNotification struct {
@@ -44,14 +62,24 @@ type PermissionRequest struct {
 
 type Service interface {
 	pubsub.Subscriber[PermissionRequest]
+	// GrantPersistent allows the request and remembers the decision for the
+	// rest of the session, including after a restart.
 	GrantPersistent(permission PermissionRequest)
+	// GrantAlways allows the request and remembers the decision for every
+	// session of the project.
+	GrantAlways(permission PermissionRequest)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(ctx context.Context, opts CreatePermissionRequest) (bool, error)
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
+	// SetNonInteractive tells whether there is no one to prompt, in which
+	// case requests that would prompt are denied instead.
+	SetNonInteractive(nonInteractive bool)
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
+	ListGrants(ctx context.Context) ([]Grant, error)
+	RevokeGrant(ctx context.Context, id string) error
 }
 
 type permissionService struct {
@@ -59,13 +87,16 @@ type permissionService struct {
 
 	notificationBroker    *pubsub.Broker[PermissionNotification]
 	workingDir            string
-	sessionPermissions    []PermissionRequest
-	sessionPermissionsMu  sync.RWMutex
+	q                     *db.Queries
+	grants                []Grant // grants that could not be persisted
+	grantsMu              sync.RWMutex
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
+	nonInteractive        bool
 	allowedTools          []string
+	policy                *Policy
 
 	// used to make sure we only process one request at a time
 	requestMu       sync.Mutex
@@ -74,6 +105,14 @@ type permissionService struct {
 }
 
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
+	s.grantAndRemember(permission, permission.SessionID)
+}
+
+func (s *permissionService) GrantAlways(permission PermissionRequest) {
+	s.grantAndRemember(permission, "")
+}
+
+func (s *permissionService) grantAndRemember(permission PermissionRequest, sessionID string) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
@@ -83,9 +122,7 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	s.sessionPermissionsMu.Lock()
-	s.sessionPermissions = append(s.sessionPermissions, permission)
-	s.sessionPermissionsMu.Unlock()
+	s.addGrant(permission, sessionID)
 
 	s.activeRequestMu.Lock()
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
@@ -130,10 +167,28 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) (bool, error) {
+	decision, reason, matched := s.policy.Evaluate(opts)
+	if matched && decision == config.PermissionDeny {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Denied:     true,
+		})
+		return false, &DeniedError{Reason: reason}
+	}
+
 	if s.skip {
 		return true, nil
 	}
 
+	// An "ask" rule always prompts, regardless of allowlists and earlier
+	// grants, and so do tools that ask for it, even if an "allow" rule
+	// matches.
+	alwaysAsk := opts.AlwaysAsk || (matched && decision == config.PermissionAsk)
+
+	if opts.Preapproved && !alwaysAsk {
+		return true, nil
+	}
+
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: opts.ToolCallID,
@@ -141,9 +196,17 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	s.requestMu.Lock()
 	defer s.requestMu.Unlock()
 
+	if matched && decision == config.PermissionAllow && !alwaysAsk {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Granted:    true,
+		})
+		return true, nil
+	}
+
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if !alwaysAsk && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
 		return true, nil
 	}
 
@@ -151,7 +214,7 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
-	if autoApprove {
+	if autoApprove && !alwaysAsk {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -159,6 +222,21 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		return true, nil
 	}
 
+	// Nobody can answer a prompt in a non-interactive run, so deny what would
+	// prompt, e.g. "ask" rules or requests outside the run's session, instead
+	// of waiting forever.
+	if s.nonInteractive {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Denied:     true,
+		})
+		denied := "this needs approval, which a non-interactive run cannot give"
+		if reason != "" {
+			denied += ": " + reason
+		}
+		return false, &DeniedError{Reason: denied}
+	}
+
 	fileInfo, err := os.Stat(opts.Path)
 	dir := opts.Path
 	if err == nil {
@@ -183,18 +261,13 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		Params:      opts.Params,
 	}
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
-				ToolCallID: opts.ToolCallID,
-				Granted:    true,
-			})
-			return true, nil
-		}
+	if !alwaysAsk && s.hasGrant(ctx, permission) {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Granted:    true,
+		})
+		return true, nil
 	}
-	s.sessionPermissionsMu.RUnlock()
 
 	s.activeRequestMu.Lock()
 	s.activeRequest = &permission
@@ -233,15 +306,23 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
+func (s *permissionService) SetNonInteractive(nonInteractive bool) {
+	s.nonInteractive = nonInteractive
+}
+
+// NewPermissionService creates a permission service. Remembered grants are
+// stored through q; if q is nil they only last for the lifetime of the
+// process.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, policy *Policy, q *db.Queries) Service {
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
 		workingDir:          workingDir,
-		sessionPermissions:  make([]PermissionRequest, 0),
+		q:                   q,
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
+		policy:              policy,
 		pendingRequests:     csync.NewMap[string, chan bool](),
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/charmbracelet/brush/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,7 +56,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			service := NewPermissionService("/tmp", false, tt.allowedTools)
+			service := NewPermissionService("/tmp", false, tt.allowedTools, nil, nil)
 
 			// Create a channel to capture the permission request
 			// Since we're testing the allowlist logic, we need to simulate the request
@@ -80,7 +81,7 @@ func TestPermissionService_AllowedCommands(t *testing.T) {
 }
 
 func TestPermissionService_SkipMode(t *testing.T) {
-	service := NewPermissionService("/tmp", true, []string{})
+	service := NewPermissionService("/tmp", true, []string{}, nil, nil)
 
 	result, err := service.Request(t.Context(), CreatePermissionRequest{
 		SessionID:   "test-session",
@@ -99,7 +100,7 @@ func TestPermissionService_SkipMode(t *testing.T) {
 
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil)
 
 		req1 := CreatePermissionRequest{
 			SessionID:   "session1",
@@ -144,7 +145,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result2, "Second request should be auto-approved")
 	})
 	t.Run("Sequential requests with temporary grants", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil)
 
 		req := CreatePermissionRequest{
 			SessionID:   "session2",
@@ -184,7 +185,7 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.False(t, result2, "Second request should be denied")
 	})
 	t.Run("Concurrent requests with different outcomes", func(t *testing.T) {
-		service := NewPermissionService("/tmp", false, []string{})
+		service := NewPermissionService("/tmp", false, []string{}, nil, nil)
 
 		events := service.Subscribe(t.Context())
 
@@ -251,3 +252,34 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result, "Repeated request should be auto-approved due to persistent permission")
 	})
 }
+
+func TestPermissionService_AlwaysAsk(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy("/tmp", []config.PermissionRule{{Decision: config.PermissionAllow, Tool: "bash"}})
+	require.NoError(t, err)
+	service := NewPermissionService("/tmp", false, []string{"bash"}, policy, nil)
+	service.AutoApproveSession("s1")
+	events := service.Subscribe(t.Context())
+
+	var granted bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		granted, _ = service.Request(t.Context(), CreatePermissionRequest{
+			SessionID: "s1",
+			ToolName:  "bash",
+			Action:    "execute",
+			Command:   "git push",
+			Path:      "/tmp",
+			AlwaysAsk: true,
+		})
+	})
+
+	// The request prompts despite the allow rule, the allowlist and the
+	// auto-approved session.
+	event := <-events
+	require.Equal(t, "bash", event.Payload.ToolName)
+	service.Deny(event.Payload)
+	wg.Wait()
+	require.False(t, granted)
+}
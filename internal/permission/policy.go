@@ -0,0 +1,256 @@
+package permission
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/fsext"
+	"github.com/charmbracelet/brush/internal/shell"
+)
+
+// DeniedError is returned by [Service.Request] when a policy rule denies a
+// tool call. It carries the reason so it can be relayed to the model.
+type DeniedError struct {
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return "permission denied by policy"
+	}
+	return "permission denied by policy: " + e.Reason
+}
+
+// Is makes [DeniedError] match [ErrorPermissionDenied].
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrorPermissionDenied
+}
+
+type rule struct {
+	config.PermissionRule
+	commandRegex *regexp.Regexp
+}
+
+// Policy is an ordered list of permission rules. The first rule that matches a
+// request decides its outcome.
+type Policy struct {
+	workingDir string
+	rules      []rule
+}
+
+// NewPolicy validates and compiles the given rules.
+func NewPolicy(workingDir string, rules []config.PermissionRule) (*Policy, error) {
+	p := &Policy{
+		workingDir: workingDir,
+		rules:      make([]rule, 0, len(rules)),
+	}
+	for i, r := range rules {
+		switch r.Decision {
+		case config.PermissionAllow, config.PermissionDeny, config.PermissionAsk:
+		default:
+			return nil, fmt.Errorf("permission rule %d: invalid decision %q", i, r.Decision)
+		}
+		switch r.Location {
+		case "", config.PathInsideWorkingDir, config.PathOutsideWorkingDir:
+		default:
+			return nil, fmt.Errorf("permission rule %d: invalid location %q", i, r.Location)
+		}
+		if r.Tool != "" {
+			if _, err := path.Match(r.Tool, ""); err != nil {
+				return nil, fmt.Errorf("permission rule %d: invalid tool pattern %q: %w", i, r.Tool, err)
+			}
+		}
+		for _, pattern := range r.Paths {
+			if !doublestar.ValidatePattern(filepath.ToSlash(pattern)) {
+				return nil, fmt.Errorf("permission rule %d: invalid path pattern %q", i, pattern)
+			}
+		}
+		compiled := rule{PermissionRule: r}
+		if r.CommandRegex != "" {
+			re, err := regexp.Compile(r.CommandRegex)
+			if err != nil {
+				return nil, fmt.Errorf("permission rule %d: invalid command regex: %w", i, err)
+			}
+			compiled.commandRegex = re
+		}
+		p.rules = append(p.rules, compiled)
+	}
+	return p, nil
+}
+
+// Evaluate returns the decision of the first rule matching the request, along
+// with the rule's reason. It returns false if no rule matches. A request for
+// several files is evaluated for each of them and gets the strictest
+// decision: it's only allowed if every file is.
+func (p *Policy) Evaluate(opts CreatePermissionRequest) (config.PermissionDecision, string, bool) {
+	if p == nil {
+		return "", "", false
+	}
+	if len(opts.FilePaths) == 0 {
+		return p.evaluate(opts)
+	}
+	// Stricter decisions rank higher, with no decision between allow and ask.
+	rank := func(decision config.PermissionDecision, matched bool) int {
+		switch {
+		case !matched:
+			return 1
+		case decision == config.PermissionAllow:
+			return 0
+		case decision == config.PermissionAsk:
+			return 2
+		default:
+			return 3
+		}
+	}
+	var decision config.PermissionDecision
+	var reason string
+	matched := false
+	for i, filePath := range opts.FilePaths {
+		file := opts
+		file.FilePath = filePath
+		file.FilePaths = nil
+		d, r, m := p.evaluate(file)
+		if i == 0 || rank(d, m) > rank(decision, matched) {
+			decision, reason, matched = d, r, m
+		}
+	}
+	return decision, reason, matched
+}
+
+func (p *Policy) evaluate(opts CreatePermissionRequest) (config.PermissionDecision, string, bool) {
+	for _, r := range p.rules {
+		if p.matches(r, opts) {
+			return r.Decision, r.Reason, true
+		}
+	}
+	return "", "", false
+}
+
+func (p *Policy) matches(r rule, opts CreatePermissionRequest) bool {
+	if r.Tool != "" {
+		if ok, _ := path.Match(r.Tool, opts.ToolName); !ok {
+			return false
+		}
+	}
+	if r.Action != "" && r.Action != opts.Action {
+		return false
+	}
+	if r.MCP != "" && r.MCP != opts.MCPName {
+		return false
+	}
+	if r.Location != "" || len(r.Paths) > 0 {
+		target := opts.FilePath
+		if target == "" {
+			target = opts.Path
+		}
+		if target == "" {
+			return false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(p.workingDir, target)
+		}
+		inside := fsext.HasPrefix(target, p.workingDir)
+		switch r.Location {
+		case config.PathInsideWorkingDir:
+			if !inside {
+				return false
+			}
+		case config.PathOutsideWorkingDir:
+			if inside {
+				return false
+			}
+		}
+		if len(r.Paths) > 0 && !p.matchesPath(r.Paths, target, inside) {
+			return false
+		}
+	}
+	if len(r.CommandPrefix) > 0 || r.commandRegex != nil {
+		command := strings.TrimSpace(opts.Command)
+		if command == "" {
+			return false
+		}
+		if len(r.CommandPrefix) > 0 && !matchesCommandPrefix(r.CommandPrefix, command, r.Decision == config.PermissionAllow) {
+			return false
+		}
+		if r.commandRegex != nil && !r.commandRegex.MatchString(command) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) matchesPath(patterns []string, target string, inside bool) bool {
+	abs := filepath.ToSlash(target)
+	rel := ""
+	if inside {
+		if r, err := filepath.Rel(p.workingDir, target); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+	}
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(pattern)
+		if path.IsAbs(pattern) || filepath.IsAbs(pattern) {
+			if ok, _ := doublestar.Match(pattern, abs); ok {
+				return true
+			}
+			continue
+		}
+		if !inside {
+			continue
+		}
+		if ok, _ := doublestar.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesCommandPrefix reports whether the simple commands of the script
+// start with any of the given prefixes. With all, every simple command must
+// match and be made of literal words, as for allow rules, otherwise one
+// matching command is enough, so a deny rule for "git push" also matches
+// "cd repo && git push".
+func matchesCommandPrefix(prefixes []string, command string, all bool) bool {
+	commands, literal, err := shell.SimpleCommands(command)
+	if err != nil {
+		// Without knowing the commands, only the start of the script can
+		// be matched.
+		return !all && hasCommandPrefix(prefixes, strings.Fields(command))
+	}
+	if !all {
+		return slices.ContainsFunc(commands, func(args []string) bool {
+			return hasCommandPrefix(prefixes, args)
+		})
+	}
+	if !literal || len(commands) == 0 {
+		return false
+	}
+	for _, args := range commands {
+		if !hasCommandPrefix(prefixes, args) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCommandPrefix reports whether the arguments start with any of the given
+// prefixes on a word boundary, so "git push" matches "git push origin" but
+// not "git pushall".
+func hasCommandPrefix(prefixes []string, args []string) bool {
+	for _, prefix := range prefixes {
+		want := strings.Fields(prefix)
+		if len(want) == 0 || len(want) > len(args) {
+			continue
+		}
+		if slices.Equal(args[:len(want)], want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy("/work", []config.PermissionRule{
+		{Decision: config.PermissionDeny, Tool: "bash", CommandPrefix: []string{"git push"}, Reason: "pushing is not allowed"},
+		{Decision: config.PermissionAllow, Tool: "bash", CommandPrefix: []string{"go test"}},
+		{Decision: config.PermissionAllow, Tool: "bash", CommandRegex: `^make\s+test\b`},
+		{Decision: config.PermissionDeny, Action: "write", Location: config.PathOutsideWorkingDir},
+		{Decision: config.PermissionAsk, Tool: "edit", Paths: []string{"**/*.sql"}},
+		{Decision: config.PermissionAllow, Tool: "edit", Paths: []string{"internal/**"}},
+		{Decision: config.PermissionDeny, MCP: "github", Tool: "mcp_github_delete_*"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		req      CreatePermissionRequest
+		decision config.PermissionDecision
+		matched  bool
+	}{
+		{
+			name:     "command prefix",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "git push origin main"},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:    "command prefix respects word boundaries",
+			req:     CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "git pushall"},
+			matched: false,
+		},
+		{
+			name:     "command prefix after another command",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "cd repo && git push"},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:     "command prefix after a semicolon",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "echo; git push --force"},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:     "allowed command prefix",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "go test ./..."},
+			decision: config.PermissionAllow,
+			matched:  true,
+		},
+		{
+			name:     "allowed command prefix for every command",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "go test ./... && go test -race ./..."},
+			decision: config.PermissionAllow,
+			matched:  true,
+		},
+		{
+			name:    "allowed command prefix with another command",
+			req:     CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "go test ./... && rm -rf /"},
+			matched: false,
+		},
+		{
+			name:    "allowed command prefix with a variable",
+			req:     CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "go test $PKG"},
+			matched: false,
+		},
+		{
+			name:    "allowed command prefix with an environment variable",
+			req:     CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "LD_PRELOAD=/tmp/x.so go test ./..."},
+			matched: false,
+		},
+		{
+			name:     "command regex",
+			req:      CreatePermissionRequest{ToolName: "bash", Action: "execute", Command: "make test ./..."},
+			decision: config.PermissionAllow,
+			matched:  true,
+		},
+		{
+			name:     "write outside working dir",
+			req:      CreatePermissionRequest{ToolName: "write", Action: "write", Path: "/work", FilePath: "/etc/hosts"},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:     "first matching rule wins",
+			req:      CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePath: "/work/internal/db/schema.sql"},
+			decision: config.PermissionAsk,
+			matched:  true,
+		},
+		{
+			name:     "relative path glob",
+			req:      CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePath: "/work/internal/app/app.go"},
+			decision: config.PermissionAllow,
+			matched:  true,
+		},
+		{
+			name:    "relative glob does not match other dirs",
+			req:     CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePath: "/work/main.go"},
+			matched: false,
+		},
+		{
+			name:     "every file allowed",
+			req:      CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePaths: []string{"/work/internal/a.go", "/work/internal/b.go"}},
+			decision: config.PermissionAllow,
+			matched:  true,
+		},
+		{
+			name:    "one file without a rule",
+			req:     CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePaths: []string{"/work/internal/a.go", "/work/main.go"}},
+			matched: false,
+		},
+		{
+			name:     "strictest decision of the files",
+			req:      CreatePermissionRequest{ToolName: "edit", Action: "write", Path: "/work", FilePath: "/work/internal/a.go", FilePaths: []string{"/work/internal/a.go", "/work/db/schema.sql", "/etc/hosts"}},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:     "mcp server and tool glob",
+			req:      CreatePermissionRequest{ToolName: "mcp_github_delete_repo", Action: "execute", MCPName: "github"},
+			decision: config.PermissionDeny,
+			matched:  true,
+		},
+		{
+			name:    "mcp server mismatch",
+			req:     CreatePermissionRequest{ToolName: "mcp_github_delete_repo", Action: "execute", MCPName: "other"},
+			matched: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			decision, _, matched := policy.Evaluate(tt.req)
+			require.Equal(t, tt.matched, matched)
+			require.Equal(t, tt.decision, decision)
+		})
+	}
+}
+
+func TestNewPolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewPolicy("/work", []config.PermissionRule{{Decision: "maybe"}})
+	require.Error(t, err)
+
+	_, err = NewPolicy("/work", []config.PermissionRule{{Decision: config.PermissionDeny, CommandRegex: "("}})
+	require.Error(t, err)
+
+	_, err = NewPolicy("/work", []config.PermissionRule{{Decision: config.PermissionDeny, Location: "elsewhere"}})
+	require.Error(t, err)
+}
+
+func TestPermissionService_PolicyDeny(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy("/tmp", []config.PermissionRule{
+		{Decision: config.PermissionDeny, Tool: "bash", CommandPrefix: []string{"rm"}, Reason: "use the trash command"},
+	})
+	require.NoError(t, err)
+
+	// Deny rules apply even when requests are skipped.
+	service := NewPermissionService("/tmp", true, []string{"bash"}, policy, nil)
+	granted, err := service.Request(context.Background(), CreatePermissionRequest{
+		SessionID: "s1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Command:   "rm -rf build",
+		Path:      "/tmp",
+	})
+	require.False(t, granted)
+	require.ErrorIs(t, err, ErrorPermissionDenied)
+
+	var denied *DeniedError
+	require.True(t, errors.As(err, &denied))
+	require.Equal(t, "use the trash command", denied.Reason)
+}
+
+func TestPermissionService_PolicyAllow(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy("/tmp", []config.PermissionRule{
+		{Decision: config.PermissionAllow, Tool: "bash", CommandPrefix: []string{"go test"}},
+	})
+	require.NoError(t, err)
+
+	service := NewPermissionService("/tmp", false, nil, policy, nil)
+	granted, err := service.Request(context.Background(), CreatePermissionRequest{
+		SessionID: "s1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Command:   "go test ./...",
+		Path:      "/tmp",
+	})
+	require.NoError(t, err)
+	require.True(t, granted)
+}
+
+func TestPermissionService_PolicyAskNonInteractive(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy("/tmp", []config.PermissionRule{
+		{Decision: config.PermissionAsk, Tool: "bash", CommandPrefix: []string{"git push"}, Reason: "pushes are reviewed"},
+	})
+	require.NoError(t, err)
+
+	// Without anyone to prompt, the request is denied instead of hanging.
+	service := NewPermissionService("/tmp", false, nil, policy, nil)
+	service.AutoApproveSession("s1")
+	service.SetNonInteractive(true)
+	granted, err := service.Request(t.Context(), CreatePermissionRequest{
+		SessionID: "s1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Command:   "git push origin main",
+		Path:      "/tmp",
+	})
+	require.False(t, granted)
+	var denied *DeniedError
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, "this needs approval, which a non-interactive run cannot give: pushes are reviewed", denied.Reason)
+
+	// Neither is a request outside the approved session, e.g. a sampling
+	// request of an MCP server.
+	granted, err = service.Request(t.Context(), CreatePermissionRequest{
+		ToolName: "sampling",
+		Action:   "sample",
+		Path:     "/tmp",
+	})
+	require.False(t, granted)
+	require.ErrorAs(t, err, &denied)
+	require.Equal(t, "this needs approval, which a non-interactive run cannot give", denied.Reason)
+
+	// Other requests of the session are still approved.
+	granted, err = service.Request(t.Context(), CreatePermissionRequest{
+		SessionID: "s1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Command:   "git status",
+		Path:      "/tmp",
+	})
+	require.NoError(t, err)
+	require.True(t, granted)
+}
@@ -2,17 +2,67 @@ package pubsub
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const bufferSize = 64
 
 type Broker[T any] struct {
-	subs      map[chan Event[T]]struct{}
+	subs      map[*subscription[T]]struct{}
 	mu        sync.RWMutex
 	done      chan struct{}
 	subCount  int
 	maxEvents int
+
+	// dropped counts the events lossy subscribers missed.
+	dropped atomic.Uint64
+	// maxQueued is the longest queue a lossless subscriber had.
+	maxQueued atomic.Int64
+	// overflowed counts the lossless subscriptions ended because their
+	// queue hit its limit.
+	overflowed atomic.Uint64
+}
+
+// subscription is the delivery state of a subscriber.
+type subscription[T any] struct {
+	ch chan Event[T]
+
+	// dropped counts the events missed because ch was full.
+	dropped atomic.Uint64
+
+	// Lossless subscriptions queue events that don't fit in ch, and a
+	// goroutine feeds them to ch in order. If limit is set, the subscription
+	// ends once that many events are queued.
+	lossless   bool
+	limit      int
+	mu         sync.Mutex
+	queue      []Event[T]
+	maxQueued  int
+	overflowed bool
+	cancel     context.CancelFunc
+	notify     chan struct{}
+	stop       chan struct{}
+}
+
+// Stats are the delivery counters of a broker.
+type Stats struct {
+	// Subscribers is the number of active subscribers.
+	Subscribers int
+	// Dropped is the number of events lossy subscribers missed because they
+	// fell behind.
+	Dropped uint64
+	// Queued is the number of events waiting to be delivered to lossless
+	// subscribers.
+	Queued int
+	// MaxQueued is the longest a lossless subscriber's queue has been.
+	MaxQueued int
+	// Overflowed is the number of lossless subscriptions ended because they
+	// fell too far behind.
+	Overflowed uint64
 }
 
 func NewBroker[T any]() *Broker[T] {
@@ -21,7 +71,7 @@ func NewBroker[T any]() *Broker[T] {
 
 func NewBrokerWithOptions[T any](channelBufferSize, maxEvents int) *Broker[T] {
 	return &Broker[T]{
-		subs:      make(map[chan Event[T]]struct{}),
+		subs:      make(map[*subscription[T]]struct{}),
 		done:      make(chan struct{}),
 		maxEvents: maxEvents,
 	}
@@ -38,15 +88,37 @@ func (b *Broker[T]) Shutdown() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	for ch := range b.subs {
-		delete(b.subs, ch)
-		close(ch)
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		b.close(sub)
 	}
 
 	b.subCount = 0
 }
 
+// Subscribe returns a channel that receives the events published until ctx
+// is done. Events are dropped when the subscriber falls more than a few dozen
+// events behind, so publishers never block.
 func (b *Broker[T]) Subscribe(ctx context.Context) <-chan Event[T] {
+	return b.subscribe(ctx, false, 0)
+}
+
+// SubscribeLossless is like [Broker.Subscribe], but every event is delivered,
+// in order, however far behind the subscriber falls. Events that don't fit in
+// the channel are queued in memory, so the subscriber must keep reading.
+func (b *Broker[T]) SubscribeLossless(ctx context.Context) <-chan Event[T] {
+	return b.subscribe(ctx, true, 0)
+}
+
+// SubscribeLosslessLimit is like [Broker.SubscribeLossless], but once limit
+// events are queued the subscription ends and the channel is closed, without
+// delivering the rest, so a subscriber that stopped reading can't hold on to
+// unbounded memory.
+func (b *Broker[T]) SubscribeLosslessLimit(ctx context.Context, limit int) <-chan Event[T] {
+	return b.subscribe(ctx, true, limit)
+}
+
+func (b *Broker[T]) subscribe(ctx context.Context, lossless bool, limit int) <-chan Event[T] {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -58,12 +130,25 @@ func (b *Broker[T]) Subscribe(ctx context.Context) <-chan Event[T] {
 	default:
 	}
 
-	sub := make(chan Event[T], bufferSize)
+	// The subscription also ends when a lossless subscriber overflows, but
+	// delivery carries on as long as the subscriber reads.
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &subscription[T]{
+		ch:       make(chan Event[T], bufferSize),
+		lossless: lossless,
+		limit:    limit,
+		cancel:   cancel,
+	}
+	if lossless {
+		sub.notify = make(chan struct{}, 1)
+		sub.stop = make(chan struct{})
+		go b.deliver(ctx, sub)
+	}
 	b.subs[sub] = struct{}{}
 	b.subCount++
 
 	go func() {
-		<-ctx.Done()
+		<-subCtx.Done()
 
 		b.mu.Lock()
 		defer b.mu.Unlock()
@@ -75,11 +160,26 @@ func (b *Broker[T]) Subscribe(ctx context.Context) <-chan Event[T] {
 		}
 
 		delete(b.subs, sub)
-		close(sub)
+		b.close(sub)
 		b.subCount--
 	}()
 
-	return sub
+	return sub.ch
+}
+
+// close ends the subscription. It must be called with the lock held.
+func (b *Broker[T]) close(sub *subscription[T]) {
+	sub.cancel()
+	if sub.lossless {
+		// The delivery goroutine closes the channel once the queue is
+		// drained.
+		close(sub.stop)
+	} else {
+		close(sub.ch)
+	}
+	if dropped := sub.dropped.Load(); dropped > 0 {
+		slog.Debug("Subscriber missed events", "events", eventName[T](), "dropped", dropped)
+	}
 }
 
 func (b *Broker[T]) GetSubscriberCount() int {
@@ -88,6 +188,72 @@ func (b *Broker[T]) GetSubscriberCount() int {
 	return b.subCount
 }
 
+// Stats returns the delivery counters of the broker.
+func (b *Broker[T]) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := Stats{
+		Subscribers: b.subCount,
+		Dropped:     b.dropped.Load(),
+		MaxQueued:   int(b.maxQueued.Load()),
+		Overflowed:  b.overflowed.Load(),
+	}
+	for sub := range b.subs {
+		if sub.lossless {
+			sub.mu.Lock()
+			stats.Queued += len(sub.queue)
+			sub.mu.Unlock()
+		}
+	}
+	return stats
+}
+
+// StatsReporter is implemented by brokers, and the services embedding them.
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// StatsFunc adapts a function to [StatsReporter].
+type StatsFunc func() Stats
+
+// Stats implements [StatsReporter].
+func (f StatsFunc) Stats() Stats {
+	return f()
+}
+
+// LogStats logs the stats of the given reporters at debug level every
+// interval, when they changed, until ctx is done.
+func LogStats(ctx context.Context, interval time.Duration, reporters map[string]StatsReporter) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := make(map[string]Stats, len(reporters))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for name, r := range reporters {
+			stats := r.Stats()
+			if stats == last[name] {
+				continue
+			}
+			last[name] = stats
+			slog.Debug(
+				"Event delivery stats",
+				"events", name,
+				"subscribers", stats.Subscribers,
+				"dropped", stats.Dropped,
+				"queued", stats.Queued,
+				"max_queued", stats.MaxQueued,
+				"overflowed", stats.Overflowed,
+			)
+		}
+	}
+}
+
 func (b *Broker[T]) Publish(t EventType, payload T) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -101,11 +267,111 @@ func (b *Broker[T]) Publish(t EventType, payload T) {
 	event := Event[T]{Type: t, Payload: payload}
 
 	for sub := range b.subs {
+		if sub.lossless {
+			b.enqueue(sub, event)
+			continue
+		}
 		select {
-		case sub <- event:
+		case sub.ch <- event:
 		default:
 			// Channel is full, subscriber is slow - skip this event
 			// This prevents blocking the publisher
+			b.dropped.Add(1)
+			if dropped := sub.dropped.Add(1); isPowerOfTwo(dropped) {
+				slog.Debug("Subscriber is falling behind, dropping events", "events", eventName[T](), "dropped", dropped)
+			}
+		}
+	}
+}
+
+// enqueue adds the event to the queue of a lossless subscriber.
+func (b *Broker[T]) enqueue(sub *subscription[T], event Event[T]) {
+	sub.mu.Lock()
+	if sub.overflowed {
+		sub.mu.Unlock()
+		return
+	}
+	if sub.limit > 0 && len(sub.queue) >= sub.limit {
+		sub.overflowed = true
+		sub.queue = nil
+		sub.mu.Unlock()
+		b.overflowed.Add(1)
+		slog.Warn("Subscriber fell too far behind, ending subscription", "events", eventName[T](), "limit", sub.limit)
+		sub.cancel()
+		return
+	}
+	sub.queue = append(sub.queue, event)
+	queued := len(sub.queue)
+	grew := queued > sub.maxQueued
+	if grew {
+		sub.maxQueued = queued
+	}
+	sub.mu.Unlock()
+
+	if grew {
+		if int64(queued) > b.maxQueued.Load() {
+			b.maxQueued.Store(int64(queued))
 		}
+		if queued >= bufferSize && isPowerOfTwo(uint64(queued)) {
+			slog.Debug("Subscriber is falling behind, queueing events", "events", eventName[T](), "queued", queued)
+		}
+	}
+
+	select {
+	case sub.notify <- struct{}{}:
+	default:
 	}
 }
+
+// deliver feeds the queued events of a lossless subscriber to its channel
+// until the subscription ends, then closes the channel.
+func (b *Broker[T]) deliver(ctx context.Context, sub *subscription[T]) {
+	defer close(sub.ch)
+	for {
+		select {
+		case <-sub.notify:
+		case <-sub.stop:
+			// Deliver what was published before the subscription ended, as
+			// long as the subscriber is still reading.
+			b.drain(ctx, sub)
+			return
+		}
+		if !b.drain(ctx, sub) {
+			return
+		}
+	}
+}
+
+// drain sends the queued events to the subscriber's channel. It returns false
+// if ctx is done before they're all sent.
+func (b *Broker[T]) drain(ctx context.Context, sub *subscription[T]) bool {
+	for {
+		sub.mu.Lock()
+		if len(sub.queue) == 0 {
+			// Release the memory of a queue that grew large.
+			sub.queue = nil
+			sub.mu.Unlock()
+			return true
+		}
+		event := sub.queue[0]
+		sub.queue[0] = Event[T]{}
+		sub.queue = sub.queue[1:]
+		sub.mu.Unlock()
+
+		select {
+		case sub.ch <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// eventName returns the name of the event payload type for logs.
+func eventName[T any]() string {
+	var payload T
+	return fmt.Sprintf("%T", payload)
+}
+
+func isPowerOfTwo(n uint64) bool {
+	return n&(n-1) == 0
+}
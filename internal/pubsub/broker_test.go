@@ -0,0 +1,114 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_SubscribeDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker[int]()
+	events := b.Subscribe(t.Context())
+
+	const published = bufferSize + 10
+	for i := range published {
+		b.Publish(CreatedEvent, i)
+	}
+
+	stats := b.Stats()
+	require.Equal(t, 1, stats.Subscribers)
+	require.EqualValues(t, published-bufferSize, stats.Dropped)
+	require.Len(t, events, bufferSize)
+}
+
+func TestBroker_SubscribeLossless(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker[int]()
+	events := b.SubscribeLossless(t.Context())
+
+	// Publishing never blocks, however far behind the subscriber is.
+	const published = bufferSize * 10
+	for i := range published {
+		b.Publish(CreatedEvent, i)
+	}
+	stats := b.Stats()
+	require.Zero(t, stats.Dropped)
+	require.Greater(t, stats.MaxQueued, bufferSize)
+
+	for i := range published {
+		select {
+		case event := <-events:
+			require.Equal(t, i, event.Payload)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	require.Zero(t, b.Stats().Queued)
+}
+
+func TestBroker_SubscribeLosslessClose(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shutdown delivers queued events", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewBroker[int]()
+		events := b.SubscribeLossless(t.Context())
+		for i := range bufferSize * 2 {
+			b.Publish(CreatedEvent, i)
+		}
+		b.Shutdown()
+
+		var received int
+		for range events {
+			received++
+		}
+		require.Equal(t, bufferSize*2, received)
+	})
+
+	t.Run("cancel closes the channel", func(t *testing.T) {
+		t.Parallel()
+
+		b := NewBroker[int]()
+		ctx, cancel := context.WithCancel(t.Context())
+		events := b.SubscribeLossless(ctx)
+		b.Publish(CreatedEvent, 1)
+		cancel()
+
+		require.Eventually(t, func() bool {
+			select {
+			case _, ok := <-events:
+				return !ok
+			default:
+				return false
+			}
+		}, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool { return b.GetSubscriberCount() == 0 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestBroker_SubscribeLosslessLimit(t *testing.T) {
+	t.Parallel()
+
+	b := NewBroker[int]()
+	events := b.SubscribeLosslessLimit(t.Context(), 10)
+
+	// The subscriber doesn't read, so the queue fills up past the channel.
+	for i := range bufferSize * 2 {
+		b.Publish(CreatedEvent, i)
+	}
+	require.EqualValues(t, 1, b.Stats().Overflowed)
+
+	// Buffered events are still read before the channel is closed.
+	var received int
+	for range events {
+		received++
+	}
+	require.Less(t, received, bufferSize*2)
+	require.Eventually(t, func() bool { return b.GetSubscriberCount() == 0 }, time.Second, time.Millisecond)
+}
@@ -12,6 +12,13 @@ type Subscriber[T any] interface {
 	Subscribe(context.Context) <-chan Event[T]
 }
 
+// LosslessSubscriber is a [Subscriber] that can also deliver every event, in
+// order, to subscribers that can't afford to miss any.
+type LosslessSubscriber[T any] interface {
+	Subscriber[T]
+	SubscribeLossless(context.Context) <-chan Event[T]
+}
+
 type (
 	// EventType identifies the type of event
 	EventType string
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/charmbracelet/brush/internal/session"
+)
+
+// EventName is the name of a server-sent event.
+type EventName string
+
+const (
+	EventSession                EventName = "session"
+	EventMessage                EventName = "message"
+	EventPermissionRequest      EventName = "permission_request"
+	EventPermissionNotification EventName = "permission_notification"
+	EventMCP                    EventName = "mcp"
+	EventError                  EventName = "error"
+)
+
+const (
+	// heartbeatInterval is how often a comment is sent on idle event streams
+	// so proxies and clients don't time out.
+	heartbeatInterval = 15 * time.Second
+
+	// maxQueuedEvents is how many events may wait for a client before it's
+	// disconnected for falling behind.
+	maxQueuedEvents = 4096
+
+	// statsInterval is how often the event delivery stats are logged.
+	statsInterval = time.Minute
+)
+
+// Event is a single server-sent event.
+type Event struct {
+	Name EventName
+	Data any
+}
+
+// eventData is the body of events that mirror a service event.
+type eventData struct {
+	Type    pubsub.EventType `json:"type"`
+	Payload any              `json:"payload"`
+}
+
+type errorEvent struct {
+	SessionID string `json:"session_id"`
+	Error     string `json:"error"`
+}
+
+// mcpState is the JSON form of an MCP client state.
+type mcpState struct {
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Error     string `json:"error,omitempty"`
+	Tools     int    `json:"tools"`
+	Prompts   int    `json:"prompts"`
+	Resources int    `json:"resources"`
+}
+
+func newMCPState(name string, state mcp.State, err error, counts mcp.Counts) mcpState {
+	s := mcpState{
+		Name:      name,
+		State:     state.String(),
+		Tools:     counts.Tools,
+		Prompts:   counts.Prompts,
+		Resources: counts.Resources,
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// toEvent converts an application event to a server-sent event, tracking
+// pending permission requests along the way.
+func (s *Server) toEvent(msg tea.Msg) (Event, bool) {
+	switch e := msg.(type) {
+	case pubsub.Event[session.Session]:
+		return Event{Name: EventSession, Data: eventData{Type: e.Type, Payload: e.Payload}}, true
+	case pubsub.Event[message.Message]:
+		return Event{Name: EventMessage, Data: eventData{Type: e.Type, Payload: e.Payload}}, true
+	case pubsub.Event[permission.PermissionRequest]:
+		s.pending.Set(e.Payload.ID, e.Payload)
+		return Event{Name: EventPermissionRequest, Data: eventData{Type: e.Type, Payload: e.Payload}}, true
+	case pubsub.Event[permission.PermissionNotification]:
+		if e.Payload.Granted || e.Payload.Denied {
+			s.forgetToolCall(e.Payload.ToolCallID)
+		}
+		return Event{Name: EventPermissionNotification, Data: eventData{Type: e.Type, Payload: e.Payload}}, true
+	case pubsub.Event[mcp.Event]:
+		payload := newMCPState(e.Payload.Name, e.Payload.State, e.Payload.Error, e.Payload.Counts)
+		return Event{Name: EventMCP, Data: eventData{Type: e.Type, Payload: payload}}, true
+	}
+	return Event{}, false
+}
+
+// forgetToolCall drops pending requests that were answered elsewhere, e.g. by
+// an auto-approved session or a persisted grant.
+func (s *Server) forgetToolCall(toolCallID string) {
+	for id, req := range s.pending.Seq2() {
+		if req.ToolCallID == toolCallID {
+			s.pending.Del(id)
+		}
+	}
+}
+
+// handleEvents streams events to the client until it disconnects.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	// Clients may be waiting for a specific event, like a permission request,
+	// so none can be dropped. A client that falls too far behind is
+	// disconnected instead, and can reconnect and reload what it missed.
+	events := s.events.SubscribeLosslessLimit(r.Context(), maxQueuedEvents)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event.Payload); err != nil {
+				slog.Debug("Event stream closed", "error", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Name, data)
+	return err
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/brush/internal/agent"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/charmbracelet/brush/internal/session"
+)
+
+const defaultSessionTitle = "New Session"
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.app.Sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if sessions == nil {
+		sessions = []session.Session{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+type createSessionRequest struct {
+	Title string `json:"title"`
+}
+
+func (s *Server) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if r.ContentLength != 0 {
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		req.Title = defaultSessionTitle
+	}
+	sess, err := s.app.Sessions.Create(r.Context(), req.Title)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) (session.Session, bool) {
+	sess, err := s.app.Sessions.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %q not found", r.PathValue("id")))
+		return sess, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return sess, false
+	}
+	return sess, true
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	if sess, ok := s.getSession(w, r); ok {
+		writeJSON(w, http.StatusOK, sess)
+	}
+}
+
+func (s *Server) handleListMessages(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.getSession(w, r)
+	if !ok {
+		return
+	}
+	msgs, err := s.app.Messages.List(r.Context(), sess.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, msgs)
+}
+
+type promptRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+type promptResponse struct {
+	SessionID string `json:"session_id"`
+	// Queued is true if the session was busy and the prompt was queued.
+	Queued bool `json:"queued"`
+}
+
+// handlePrompt starts the agent in the background. Clients follow its
+// progress through the event stream.
+func (s *Server) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	if s.app.AgentCoordinator == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("no providers configured"))
+		return
+	}
+	sess, ok := s.getSession(w, r)
+	if !ok {
+		return
+	}
+	var req promptRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, agent.ErrEmptyPrompt)
+		return
+	}
+
+	queued := s.app.AgentCoordinator.IsSessionBusy(sess.ID)
+	go func() {
+		// The run outlives the request, so don't use its context.
+		if _, err := s.app.AgentCoordinator.Run(context.Background(), sess.ID, req.Prompt); err != nil {
+			slog.Error("Agent run failed", "session_id", sess.ID, "error", err)
+			s.events.Publish(pubsub.CreatedEvent, Event{
+				Name: EventError,
+				Data: errorEvent{SessionID: sess.ID, Error: err.Error()},
+			})
+		}
+	}()
+	writeJSON(w, http.StatusAccepted, promptResponse{SessionID: sess.ID, Queued: queued})
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if s.app.AgentCoordinator == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("no providers configured"))
+		return
+	}
+	sess, ok := s.getSession(w, r)
+	if !ok {
+		return
+	}
+	s.app.AgentCoordinator.Cancel(sess.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListPermissions(w http.ResponseWriter, _ *http.Request) {
+	pending := []permission.PermissionRequest{}
+	for _, req := range s.pending.Seq2() {
+		pending = append(pending, req)
+	}
+	slices.SortFunc(pending, func(a, b permission.PermissionRequest) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	writeJSON(w, http.StatusOK, pending)
+}
+
+// Permission decisions accepted by POST /v1/permissions/{id}.
+const (
+	decisionAllow        = "allow"
+	decisionAllowSession = "allow_session"
+	decisionAllowProject = "allow_project"
+	decisionDeny         = "deny"
+)
+
+type answerPermissionRequest struct {
+	Decision string `json:"decision"`
+}
+
+func (s *Server) handleAnswerPermission(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.pending.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no pending permission request %q", r.PathValue("id")))
+		return
+	}
+	var answer answerPermissionRequest
+	if err := readJSON(r, &answer); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch answer.Decision {
+	case decisionAllow:
+		s.app.Permissions.Grant(req)
+	case decisionAllowSession:
+		s.app.Permissions.GrantPersistent(req)
+	case decisionAllowProject:
+		s.app.Permissions.GrantAlways(req)
+	case decisionDeny:
+		s.app.Permissions.Deny(req)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid decision %q: must be one of allow, allow_session, allow_project, deny", answer.Decision))
+		return
+	}
+	s.pending.Del(req.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMCPStates(w http.ResponseWriter, _ *http.Request) {
+	states := []mcpState{}
+	for _, info := range mcp.GetStates() {
+		states = append(states, newMCPState(info.Name, info.State, info.Error, info.Counts))
+	}
+	slices.SortFunc(states, func(a, b mcpState) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	writeJSON(w, http.StatusOK, states)
+}
@@ -0,0 +1,200 @@
+// Package server exposes the application over a local HTTP API so editors
+// and other tools can drive the agent without the TUI.
+//
+// Requests and responses are JSON. Events from the sessions, messages,
+// permissions and MCP services are streamed with server-sent events from
+// GET /v1/events.
+//
+// Over TCP, requests must carry the token the server was started with as a
+// bearer token and be addressed to a loopback host.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/app"
+	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
+)
+
+// Server serves the HTTP API of an [app.App].
+type Server struct {
+	app     *app.App
+	mux     *http.ServeMux
+	events  *pubsub.Broker[Event]
+	pending *csync.Map[string, permission.PermissionRequest]
+	token   string
+	// loopback only accepts requests for a loopback host, so pages a
+	// browser loads can't reach a TCP listener through DNS rebinding.
+	loopback bool
+}
+
+// New creates a server for the given application. If token isn't empty,
+// requests must send it as a bearer token.
+func New(a *app.App, token string) *Server {
+	s := &Server{
+		app:     a,
+		mux:     http.NewServeMux(),
+		events:  pubsub.NewBroker[Event](),
+		pending: csync.NewMap[string, permission.PermissionRequest](),
+		token:   token,
+	}
+	s.routes()
+	return s
+}
+
+// ServeHTTP implements [http.Handler].
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkRequest(r); err != nil {
+		writeError(w, err.status, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Serve accepts connections on l until ctx is done.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	s.loopback = l.Addr().Network() != "unix"
+	go s.forwardEvents(ctx)
+	go pubsub.LogStats(ctx, statsInterval, map[string]pubsub.StatsReporter{"server": s.events})
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.events.Shutdown()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// forwardEvents reads the application events and publishes the ones clients
+// care about to the event stream.
+func (s *Server) forwardEvents(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-s.app.Events():
+			if !ok {
+				return
+			}
+			event, ok := s.toEvent(msg)
+			if !ok {
+				continue
+			}
+			s.events.Publish(pubsub.CreatedEvent, event)
+		}
+	}
+}
+
+// requestError is an error refusing a request with an HTTP status.
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// checkRequest refuses requests that don't carry the token, come from a web
+// page of another origin, or send a body that isn't JSON.
+func (s *Server) checkRequest(r *http.Request) *requestError {
+	if s.loopback && !isLoopbackHost(r.Host) {
+		return &requestError{http.StatusForbidden, "host is not a loopback address"}
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return &requestError{http.StatusForbidden, "cross-origin requests are not allowed"}
+		}
+	}
+	if s.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			return &requestError{http.StatusUnauthorized, "missing or invalid token"}
+		}
+	}
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			return &requestError{http.StatusUnsupportedMediaType, "content type must be application/json"}
+		}
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether the host of a Host header is localhost or
+// a loopback address.
+func isLoopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /v1/sessions", s.handleListSessions)
+	s.mux.HandleFunc("POST /v1/sessions", s.handleCreateSession)
+	s.mux.HandleFunc("GET /v1/sessions/{id}", s.handleGetSession)
+	s.mux.HandleFunc("GET /v1/sessions/{id}/messages", s.handleListMessages)
+	s.mux.HandleFunc("POST /v1/sessions/{id}/prompt", s.handlePrompt)
+	s.mux.HandleFunc("POST /v1/sessions/{id}/cancel", s.handleCancel)
+	s.mux.HandleFunc("GET /v1/permissions", s.handleListPermissions)
+	s.mux.HandleFunc("POST /v1/permissions/{id}", s.handleAnswerPermission)
+	s.mux.HandleFunc("GET /v1/mcp", s.handleMCPStates)
+	s.mux.HandleFunc("GET /v1/events", s.handleEvents)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func readJSON(r *http.Request, v any) error {
+	if r.Body == nil {
+		return errors.New("request body is required")
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
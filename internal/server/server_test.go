@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingPermissions(t *testing.T) {
+	t.Parallel()
+
+	s := New(nil, "")
+	req := permission.PermissionRequest{ID: "p1", ToolCallID: "t1", ToolName: "bash", Action: "execute"}
+
+	event, ok := s.toEvent(pubsub.Event[permission.PermissionRequest]{Type: pubsub.CreatedEvent, Payload: req})
+	require.True(t, ok)
+	require.Equal(t, EventPermissionRequest, event.Name)
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/permissions", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var pending []permission.PermissionRequest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pending))
+	require.Equal(t, []permission.PermissionRequest{req}, pending)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, jsonRequest(http.MethodPost, "/v1/permissions/p1", `{"decision":"maybe"}`))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, jsonRequest(http.MethodPost, "/v1/permissions/nope", `{"decision":"allow"}`))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Answered from somewhere else, e.g. the request matched a grant.
+	_, ok = s.toEvent(pubsub.Event[permission.PermissionNotification]{Payload: permission.PermissionNotification{ToolCallID: "t1", Granted: true}})
+	require.True(t, ok)
+	require.Zero(t, s.pending.Len())
+}
+
+func TestCheckRequest(t *testing.T) {
+	t.Parallel()
+
+	s := New(nil, "secret")
+	s.loopback = true
+
+	tests := []struct {
+		name   string
+		host   string
+		header http.Header
+		body   string
+		status int
+	}{
+		{
+			name:   "authorized",
+			host:   "127.0.0.1:7777",
+			header: http.Header{"Authorization": {"Bearer secret"}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "localhost",
+			host:   "localhost:7777",
+			header: http.Header{"Authorization": {"Bearer secret"}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "missing token",
+			host:   "127.0.0.1:7777",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "wrong token",
+			host:   "127.0.0.1:7777",
+			header: http.Header{"Authorization": {"Bearer guess"}},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "rebound host",
+			host:   "attacker.example:7777",
+			header: http.Header{"Authorization": {"Bearer secret"}},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "cross origin",
+			host:   "127.0.0.1:7777",
+			header: http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://attacker.example"}},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "not json",
+			host:   "127.0.0.1:7777",
+			header: http.Header{"Authorization": {"Bearer secret"}, "Content-Type": {"text/plain"}},
+			body:   `{"decision":"allow"}`,
+			status: http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			method := http.MethodGet
+			if tt.body != "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/v1/permissions", strings.NewReader(tt.body))
+			req.Host = tt.host
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestToEventIgnoresUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, ok := New(nil, "").toEvent("not an event")
+	require.False(t, ok)
+}
+
+func TestEventStream(t *testing.T) {
+	t.Parallel()
+
+	s := New(nil, "")
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/v1/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool { return s.events.GetSubscriberCount() == 1 }, time.Second, 10*time.Millisecond)
+	event, ok := s.toEvent(pubsub.Event[mcp.Event]{
+		Type:    pubsub.UpdatedEvent,
+		Payload: mcp.Event{Name: "github", State: mcp.StateConnected, Counts: mcp.Counts{Tools: 3}},
+	})
+	require.True(t, ok)
+	s.events.Publish(pubsub.CreatedEvent, event)
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	require.Equal(t, "event: mcp", scanner.Text())
+	require.True(t, scanner.Scan())
+	require.JSONEq(t,
+		`{"type":"updated","payload":{"name":"github","state":"connected","tools":3,"prompts":0,"resources":0}}`,
+		strings.TrimPrefix(scanner.Text(), "data: "),
+	)
+}
+
+func jsonRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/event"
+	"github.com/charmbracelet/brush/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+// Fork copies the messages of the session up to and including upToMessageID
+// into a new session that links back to it. The file history recorded until
+// then, the checkpoints of the copied messages and the sub-agent sessions of
+// the copied tool calls are copied too.
+func (s *service) Fork(ctx context.Context, sessionID, upToMessageID string) (Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := s.q.WithTx(tx)
+
+	source, err := qtx.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	msgs, err := qtx.ListMessagesBySession(ctx, sessionID)
+	if err != nil {
+		return Session{}, fmt.Errorf("listing messages: %w", err)
+	}
+	end := len(msgs)
+	if upToMessageID != "" {
+		idx := slices.IndexFunc(msgs, func(m db.Message) bool { return m.ID == upToMessageID })
+		if idx < 0 {
+			return Session{}, fmt.Errorf("message %s not found in session %s", upToMessageID, sessionID)
+		}
+		end = idx + 1
+		// Keep the results of the message's tool calls.
+		for end < len(msgs) && msgs[end].Role == "tool" {
+			end++
+		}
+	}
+
+	// The token counts are the size of the context after the last message,
+	// so they only hold when the whole session is copied. The next turn of
+	// a shorter fork sets them again.
+	promptTokens, completionTokens := source.PromptTokens, source.CompletionTokens
+	if end < len(msgs) {
+		promptTokens, completionTokens = 0, 0
+	}
+
+	dbFork, err := qtx.CreateSession(ctx, db.CreateSessionParams{
+		ID:                  uuid.New().String(),
+		Title:               source.Title,
+		PromptTokens:        promptTokens,
+		CompletionTokens:    completionTokens,
+		ForkedFromSessionID: sql.NullString{String: sessionID, Valid: true},
+	})
+	if err != nil {
+		return Session{}, fmt.Errorf("creating session: %w", err)
+	}
+
+	messageIDs, err := copyMessages(ctx, qtx, msgs[:end], dbFork.ID)
+	if err != nil {
+		return Session{}, err
+	}
+	if err := s.copyAgentToolSessions(ctx, qtx, sessionID, dbFork.ID, messageIDs); err != nil {
+		return Session{}, err
+	}
+	fileIDs, err := copyFiles(ctx, qtx, sessionID, dbFork.ID, msgs[end:])
+	if err != nil {
+		return Session{}, err
+	}
+	if err := copyCheckpoints(ctx, qtx, msgs[:end], dbFork.ID, messageIDs, fileIDs); err != nil {
+		return Session{}, err
+	}
+
+	if summaryID, ok := messageIDs[source.SummaryMessageID.String]; ok {
+		dbFork, err = qtx.UpdateSession(ctx, db.UpdateSessionParams{
+			ID:               dbFork.ID,
+			Title:            dbFork.Title,
+			PromptTokens:     dbFork.PromptTokens,
+			CompletionTokens: dbFork.CompletionTokens,
+			SummaryMessageID: sql.NullString{String: summaryID, Valid: true},
+			Cost:             dbFork.Cost,
+		})
+		if err != nil {
+			return Session{}, fmt.Errorf("updating session: %w", err)
+		}
+	} else if dbFork, err = qtx.GetSessionByID(ctx, dbFork.ID); err != nil {
+		return Session{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Session{}, fmt.Errorf("committing transaction: %w", err)
+	}
+	fork := s.fromDBItem(dbFork)
+	s.Publish(pubsub.CreatedEvent, fork)
+	event.SessionCreated()
+	return fork, nil
+}
+
+// copyMessages copies msgs into the session, returning the IDs of the
+// copies keyed by the ID of the originals.
+func copyMessages(ctx context.Context, q *db.Queries, msgs []db.Message, sessionID string) (map[string]string, error) {
+	ids := make(map[string]string, len(msgs))
+	for _, m := range msgs {
+		copied, err := q.CopyMessage(ctx, db.CopyMessageParams{
+			ID:        uuid.New().String(),
+			SessionID: sessionID,
+			SourceID:  m.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copying message: %w", err)
+		}
+		ids[m.ID] = copied.ID
+	}
+	return ids, nil
+}
+
+// copyAgentToolSessions copies the sub-agent sessions of the copied
+// messages' tool calls, so they are still shown nested in the fork.
+func (s *service) copyAgentToolSessions(ctx context.Context, q *db.Queries, sessionID, forkID string, messageIDs map[string]string) error {
+	children, err := q.ListChildSessions(ctx, sql.NullString{String: sessionID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("listing child sessions: %w", err)
+	}
+	for _, child := range children {
+		messageID, toolCallID, ok := s.ParseAgentToolSessionID(child.ID)
+		if !ok {
+			continue
+		}
+		newMessageID, ok := messageIDs[messageID]
+		if !ok {
+			continue
+		}
+		if _, err := q.CreateSession(ctx, db.CreateSessionParams{
+			ID:               s.CreateAgentToolSessionID(newMessageID, toolCallID),
+			ParentSessionID:  sql.NullString{String: forkID, Valid: true},
+			Title:            child.Title,
+			PromptTokens:     child.PromptTokens,
+			CompletionTokens: child.CompletionTokens,
+		}); err != nil {
+			return fmt.Errorf("creating sub-agent session: %w", err)
+		}
+		msgs, err := q.ListMessagesBySession(ctx, child.ID)
+		if err != nil {
+			return fmt.Errorf("listing messages: %w", err)
+		}
+		if _, err := copyMessages(ctx, q, msgs, s.CreateAgentToolSessionID(newMessageID, toolCallID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFiles copies the file history of the session and its sub-agents into
+// the fork, up to the checkpoint of the first user message left out of it.
+// It returns the IDs of the copies keyed by the ID of the originals.
+func copyFiles(ctx context.Context, q *db.Queries, sessionID, forkID string, rest []db.Message) (map[string]string, error) {
+	files, err := q.ListFilesBySessionTree(ctx, db.ListFilesBySessionTreeParams{
+		SessionID:       sessionID,
+		ParentSessionID: sql.NullString{String: sessionID, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+	byID := make(map[string]db.File, len(files))
+	for _, f := range files {
+		byID[f.ID] = f
+	}
+
+	// Without a checkpoint to stop at, the whole history is copied.
+	var limits map[string]int64
+	if i := slices.IndexFunc(rest, func(m db.Message) bool { return m.Role == "user" }); i >= 0 {
+		checkpoint, err := q.GetCheckpointByMessage(ctx, rest[i].ID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return nil, fmt.Errorf("getting checkpoint: %w", err)
+		default:
+			checkpointFiles, err := q.ListCheckpointFiles(ctx, checkpoint.ID)
+			if err != nil {
+				return nil, fmt.Errorf("listing checkpoint files: %w", err)
+			}
+			limits = make(map[string]int64, len(checkpointFiles))
+			for _, cf := range checkpointFiles {
+				limits[cf.Path] = byID[cf.FileID].Version
+			}
+		}
+	}
+
+	type pathVersion struct {
+		path    string
+		version int64
+	}
+	ids := make(map[string]string, len(files))
+	copied := make(map[pathVersion]string, len(files))
+	for _, f := range files {
+		if limits != nil {
+			if limit, ok := limits[f.Path]; !ok || f.Version > limit {
+				continue
+			}
+		}
+		// Sub-agents record their own initial version of files that were
+		// already edited, which the fork can only have once.
+		key := pathVersion{f.Path, f.Version}
+		if id, ok := copied[key]; ok {
+			ids[f.ID] = id
+			continue
+		}
+		dbFile, err := q.CreateFile(ctx, db.CreateFileParams{
+			ID:        uuid.New().String(),
+			SessionID: forkID,
+			Path:      f.Path,
+			Content:   f.Content,
+			Version:   f.Version,
+			IsNew:     f.IsNew,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copying file: %w", err)
+		}
+		ids[f.ID] = dbFile.ID
+		copied[key] = dbFile.ID
+	}
+	return ids, nil
+}
+
+// copyCheckpoints copies the checkpoints of the copied user messages, so the
+// fork can be rewound too.
+func copyCheckpoints(ctx context.Context, q *db.Queries, msgs []db.Message, forkID string, messageIDs, fileIDs map[string]string) error {
+	for _, m := range msgs {
+		if m.Role != "user" {
+			continue
+		}
+		checkpoint, err := q.GetCheckpointByMessage(ctx, m.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("getting checkpoint: %w", err)
+		}
+		checkpointFiles, err := q.ListCheckpointFiles(ctx, checkpoint.ID)
+		if err != nil {
+			return fmt.Errorf("listing checkpoint files: %w", err)
+		}
+		copied, err := q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+			ID:        uuid.New().String(),
+			SessionID: forkID,
+			MessageID: messageIDs[m.ID],
+		})
+		if err != nil {
+			return fmt.Errorf("copying checkpoint: %w", err)
+		}
+		for _, cf := range checkpointFiles {
+			fileID, ok := fileIDs[cf.FileID]
+			if !ok {
+				continue
+			}
+			if err := q.CreateCheckpointFile(ctx, db.CreateCheckpointFileParams{
+				CheckpointID: copied.ID,
+				Path:         cf.Path,
+				FileID:       fileID,
+			}); err != nil {
+				return fmt.Errorf("copying checkpoint: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Lineage orders sessions so that every fork directly follows the session it
+// was forked from, keeping the order of sessions otherwise. It returns the
+// fork depth of each session, 0 for sessions that aren't forks of one of the
+// given sessions.
+func Lineage(sessions []Session) ([]Session, []int) {
+	known := make(map[string]bool, len(sessions))
+	for _, sess := range sessions {
+		known[sess.ID] = true
+	}
+	forks := make(map[string][]Session)
+	var roots []Session
+	for _, sess := range sessions {
+		if known[sess.ForkedFromSessionID] {
+			forks[sess.ForkedFromSessionID] = append(forks[sess.ForkedFromSessionID], sess)
+		} else {
+			roots = append(roots, sess)
+		}
+	}
+
+	ordered := make([]Session, 0, len(sessions))
+	depths := make([]int, 0, len(sessions))
+	var visit func(sess Session, depth int)
+	visit = func(sess Session, depth int) {
+		ordered = append(ordered, sess)
+		depths = append(depths, depth)
+		for _, fork := range forks[sess.ID] {
+			visit(fork, depth+1)
+		}
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+	return ordered, depths
+}
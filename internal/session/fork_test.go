@@ -0,0 +1,130 @@
+package session
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFork(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := NewService(q, conn)
+
+	source, err := sessions.Create(ctx, "source")
+	require.NoError(t, err)
+	source.PromptTokens = 150_000
+	source.CompletionTokens = 2_000
+	source, err = sessions.Save(ctx, source)
+	require.NoError(t, err)
+	var ids []string
+	for _, role := range []string{"user", "assistant", "tool", "user", "assistant"} {
+		m, err := q.CreateMessage(ctx, db.CreateMessageParams{
+			ID:        role + "-" + string(rune('0'+len(ids))),
+			SessionID: source.ID,
+			Role:      role,
+			Parts:     "[]",
+		})
+		require.NoError(t, err)
+		ids = append(ids, m.ID)
+	}
+
+	// main.go was edited in the first turn, other.go in the second.
+	createFile := func(path string, version int64) db.File {
+		f, err := q.CreateFile(ctx, db.CreateFileParams{
+			ID:        path + string(rune('0'+version)),
+			SessionID: source.ID,
+			Path:      path,
+			Content:   path,
+			Version:   version,
+		})
+		require.NoError(t, err)
+		return f
+	}
+	createFile("main.go", 0)
+	edited := createFile("main.go", 1)
+	createFile("other.go", 0)
+	createFile("other.go", 2)
+	checkpoint, err := q.CreateCheckpoint(ctx, db.CreateCheckpointParams{ID: "cp", SessionID: source.ID, MessageID: ids[3]})
+	require.NoError(t, err)
+	require.NoError(t, q.CreateCheckpointFile(ctx, db.CreateCheckpointFileParams{CheckpointID: checkpoint.ID, Path: "main.go", FileID: edited.ID}))
+
+	// Forking from the assistant message keeps its tool results.
+	fork, err := sessions.Fork(ctx, source.ID, ids[1])
+	require.NoError(t, err)
+	require.Equal(t, source.ID, fork.ForkedFromSessionID)
+	require.Equal(t, source.Title, fork.Title)
+	require.EqualValues(t, 3, fork.MessageCount)
+	// The context of the source's last turn isn't the fork's.
+	require.Zero(t, fork.PromptTokens)
+	require.Zero(t, fork.CompletionTokens)
+
+	msgs, err := q.ListMessagesBySession(ctx, fork.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	for i, m := range msgs {
+		require.NotEqual(t, ids[i], m.ID)
+	}
+	require.Equal(t, []string{"user", "assistant", "tool"}, []string{msgs[0].Role, msgs[1].Role, msgs[2].Role})
+
+	files, err := q.ListFilesBySession(ctx, fork.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, f := range files {
+		require.Equal(t, "main.go", f.Path)
+	}
+
+	whole, err := sessions.Fork(ctx, source.ID, "")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, whole.MessageCount)
+	require.Equal(t, source.PromptTokens, whole.PromptTokens)
+	require.Equal(t, source.CompletionTokens, whole.CompletionTokens)
+	files, err = q.ListFilesBySession(ctx, whole.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	// The copied user message keeps its checkpoint.
+	msgs, err = q.ListMessagesBySession(ctx, whole.ID)
+	require.NoError(t, err)
+	copied, err := q.GetCheckpointByMessage(ctx, msgs[3].ID)
+	require.NoError(t, err)
+	checkpointFiles, err := q.ListCheckpointFiles(ctx, copied.ID)
+	require.NoError(t, err)
+	require.Len(t, checkpointFiles, 1)
+	require.NotEqual(t, edited.ID, checkpointFiles[0].FileID)
+
+	_, err = sessions.Fork(ctx, source.ID, "missing")
+	require.Error(t, err)
+
+	// Deleting the source keeps its forks.
+	require.NoError(t, sessions.Delete(ctx, source.ID))
+	dbFork, err := q.GetSessionByID(ctx, fork.ID)
+	require.NoError(t, err)
+	require.Equal(t, sql.NullString{}, dbFork.ForkedFromSessionID)
+}
+
+func TestLineage(t *testing.T) {
+	t.Parallel()
+
+	sessions := []Session{
+		{ID: "fork-of-fork", ForkedFromSessionID: "fork"},
+		{ID: "b"},
+		{ID: "fork", ForkedFromSessionID: "a"},
+		{ID: "a"},
+		{ID: "orphan", ForkedFromSessionID: "deleted"},
+	}
+	ordered, depths := Lineage(sessions)
+	var ids []string
+	for _, s := range ordered {
+		ids = append(ids, s.ID)
+	}
+	require.Equal(t, []string{"b", "a", "fork", "fork-of-fork", "orphan"}, ids)
+	require.Equal(t, []int{0, 0, 1, 2, 0}, depths)
+}
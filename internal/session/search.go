@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/brush/internal/db"
+)
+
+// Markers the database puts around the matched terms of a snippet.
+const (
+	snippetMatchStart = '\x02'
+	snippetMatchEnd   = '\x03'
+)
+
+// SearchResult is a session title or message that matches a search.
+type SearchResult struct {
+	SessionID    string `json:"session_id"`
+	SessionTitle string `json:"session_title"`
+	// MessageID is the matching message, or empty when the session title
+	// matches.
+	MessageID string `json:"message_id,omitempty"`
+	// Snippet is the text around the match, on a single line.
+	Snippet string `json:"snippet"`
+	// Matches are the byte ranges of the matched terms in the snippet.
+	Matches   [][2]int `json:"matches,omitempty"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+// Search returns the session titles and messages, including tool calls,
+// that contain every word of the query, best matches first. Words match as
+// prefixes, so results show up while the query is being typed.
+func (s *service) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := searchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := s.q.SearchMessages(ctx, db.SearchMessagesParams{
+		Query: match,
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search sessions: %w", err)
+	}
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		snippet, matches := parseSnippet(row.Snippet)
+		results[i] = SearchResult{
+			SessionID:    row.SessionID,
+			SessionTitle: row.SessionTitle,
+			MessageID:    row.MessageID,
+			Snippet:      snippet,
+			Matches:      matches,
+			UpdatedAt:    row.SessionUpdatedAt,
+		}
+	}
+	return results, nil
+}
+
+// searchQuery turns the words of a query into an FTS5 query, so punctuation
+// and operators typed by the user can't make it invalid.
+func searchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+// parseSnippet removes the match markers and line breaks from a snippet, and
+// returns where the matches are.
+func parseSnippet(snippet string) (string, [][2]int) {
+	var b strings.Builder
+	var matches [][2]int
+	start := -1
+	space := false
+	for _, r := range snippet {
+		switch {
+		case r == snippetMatchStart:
+			start = b.Len()
+			continue
+		case r == snippetMatchEnd:
+			if start >= 0 {
+				matches = append(matches, [2]int{start, b.Len()})
+				start = -1
+			}
+			continue
+		case unicode.IsSpace(r):
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return strings.TrimRight(b.String(), " "), matches
+}
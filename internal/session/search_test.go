@@ -0,0 +1,100 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := NewService(q, conn)
+
+	sess, err := sessions.Create(ctx, "Fix the migration bug")
+	require.NoError(t, err)
+	_, err = q.CreateMessage(ctx, db.CreateMessageParams{
+		ID:        "text",
+		SessionID: sess.ID,
+		Role:      "user",
+		Parts:     `[{"type":"text","data":{"text":"The goose migrations fail on a fresh database."}}]`,
+	})
+	require.NoError(t, err)
+	_, err = q.CreateMessage(ctx, db.CreateMessageParams{
+		ID:        "tool",
+		SessionID: sess.ID,
+		Role:      "assistant",
+		Parts:     `[{"type":"tool_call","data":{"id":"call","name":"grep","input":"{\"pattern\":\"StatementBegin\"}"}}]`,
+	})
+	require.NoError(t, err)
+
+	search := func(query string) []SearchResult {
+		t.Helper()
+		results, err := sessions.Search(ctx, query, 10)
+		require.NoError(t, err)
+		return results
+	}
+
+	t.Run("title and messages", func(t *testing.T) {
+		results := search("migration")
+		require.Len(t, results, 2)
+		for _, result := range results {
+			require.Equal(t, sess.ID, result.SessionID)
+			require.Equal(t, sess.Title, result.SessionTitle)
+			require.NotEmpty(t, result.Matches)
+			match := result.Matches[0]
+			require.Contains(t, result.Snippet[match[0]:match[1]], "igration")
+		}
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		results := search("statementbeg")
+		require.Len(t, results, 1)
+		require.Equal(t, "tool", results[0].MessageID)
+	})
+
+	t.Run("operators are words", func(t *testing.T) {
+		results := search(`fresh "database" OR`)
+		require.Empty(t, results)
+		results = search(`fresh "database"`)
+		require.Len(t, results, 1)
+		require.Equal(t, "text", results[0].MessageID)
+		require.Empty(t, search("  -- "))
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		sess.Title = "Rename the config"
+		_, err := sessions.Save(ctx, sess)
+		require.NoError(t, err)
+		require.NoError(t, q.UpdateMessage(ctx, db.UpdateMessageParams{
+			ID:    "text",
+			Parts: `[{"type":"text","data":{"text":"Rename the option"}}]`,
+		}))
+		require.Empty(t, search("migration"))
+		require.Len(t, search("rename"), 2)
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		require.NoError(t, sessions.Delete(ctx, sess.ID))
+		require.Empty(t, search("rename"))
+		require.Empty(t, search("statementbegin"))
+
+		var documents int
+		require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM search_documents").Scan(&documents))
+		require.Zero(t, documents)
+	})
+}
+
+func TestParseSnippet(t *testing.T) {
+	t.Parallel()
+
+	snippet, matches := parseSnippet("…fix the\n\n  \x02migration\x03 \x02bug\x03\n")
+	require.Equal(t, "…fix the migration bug", snippet)
+	require.Equal(t, [][2]int{{11, 20}, {21, 24}}, matches)
+}
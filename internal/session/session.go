@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/brush/internal/db"
 	"github.com/charmbracelet/brush/internal/event"
@@ -29,17 +30,18 @@ type Todo struct {
 }
 
 type Session struct {
-	ID               string
-	ParentSessionID  string
-	Title            string
-	MessageCount     int64
-	PromptTokens     int64
-	CompletionTokens int64
-	SummaryMessageID string
-	Cost             float64
-	Todos            []Todo
-	CreatedAt        int64
-	UpdatedAt        int64
+	ID                  string  `json:"id"`
+	ParentSessionID     string  `json:"parent_session_id,omitempty"`
+	Title               string  `json:"title"`
+	MessageCount        int64   `json:"message_count"`
+	PromptTokens        int64   `json:"prompt_tokens"`
+	CompletionTokens    int64   `json:"completion_tokens"`
+	SummaryMessageID    string  `json:"summary_message_id,omitempty"`
+	Cost                float64 `json:"cost"`
+	Todos               []Todo  `json:"todos,omitempty"`
+	ForkedFromSessionID string  `json:"forked_from_session_id,omitempty"`
+	CreatedAt           int64   `json:"created_at"`
+	UpdatedAt           int64   `json:"updated_at"`
 }
 
 type Service interface {
@@ -47,11 +49,23 @@ type Service interface {
 	Create(ctx context.Context, title string) (Session, error)
 	CreateTitleSession(ctx context.Context, parentSessionID string) (Session, error)
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
+	// Fork copies the session up to and including the given message into a
+	// new session. An empty message ID forks the whole session.
+	Fork(ctx context.Context, sessionID, upToMessageID string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
 	Delete(ctx context.Context, id string) error
+	// Search finds the sessions and messages matching the words of the query.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	// RecordUsage records what the session just spent.
+	RecordUsage(ctx context.Context, sessionID string, usage Usage) error
+	// UsageSince returns what all the sessions spent since the given time.
+	UsageSince(ctx context.Context, since time.Time) (Usage, error)
+	// SessionUsageSince returns what the session and its sub-agent sessions
+	// spent since the given time.
+	SessionUsageSince(ctx context.Context, sessionID string, since time.Time) (Usage, error)
 
 	// Agent tool session management
 	CreateAgentToolSessionID(messageID, toolCallID string) string
@@ -179,13 +193,17 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 // UpdateTitleAndUsage updates only the title and usage fields atomically.
 // This is safer than fetching, modifying, and saving the entire session.
 func (s *service) UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error {
-	return s.q.UpdateSessionTitleAndUsage(ctx, db.UpdateSessionTitleAndUsageParams{
+	err := s.q.UpdateSessionTitleAndUsage(ctx, db.UpdateSessionTitleAndUsageParams{
 		ID:               sessionID,
 		Title:            title,
 		PromptTokens:     promptTokens,
 		CompletionTokens: completionTokens,
 		Cost:             cost,
 	})
+	if err != nil || (cost == 0 && promptTokens+completionTokens == 0) {
+		return err
+	}
+	return s.RecordUsage(ctx, sessionID, Usage{Cost: cost, Tokens: promptTokens + completionTokens})
 }
 
 func (s *service) List(ctx context.Context) ([]Session, error) {
@@ -206,17 +224,18 @@ func (s service) fromDBItem(item db.Session) Session {
 		slog.Error("failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		Todos:            todos,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                  item.ID,
+		ParentSessionID:     item.ParentSessionID.String,
+		Title:               item.Title,
+		MessageCount:        item.MessageCount,
+		PromptTokens:        item.PromptTokens,
+		CompletionTokens:    item.CompletionTokens,
+		SummaryMessageID:    item.SummaryMessageID.String,
+		Cost:                item.Cost,
+		Todos:               todos,
+		ForkedFromSessionID: item.ForkedFromSessionID.String,
+		CreatedAt:           item.CreatedAt,
+		UpdatedAt:           item.UpdatedAt,
 	}
 }
 
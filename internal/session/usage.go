@@ -0,0 +1,55 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/db"
+)
+
+// Usage is what a set of sessions spent. Tokens are the prompt and
+// completion tokens of every request, not the size of the context.
+type Usage struct {
+	Cost   float64 `json:"cost"`
+	Tokens int64   `json:"tokens"`
+}
+
+// RecordUsage records what the session just spent, to be counted by
+// [service.UsageSince] and [service.SessionUsageSince].
+func (s *service) RecordUsage(ctx context.Context, sessionID string, usage Usage) error {
+	err := s.q.CreateSessionUsage(ctx, db.CreateSessionUsageParams{
+		SessionID: sessionID,
+		Cost:      usage.Cost,
+		Tokens:    usage.Tokens,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// UsageSince returns what all the sessions spent since the given time,
+// including sessions that were deleted since.
+func (s *service) UsageSince(ctx context.Context, since time.Time) (Usage, error) {
+	row, err := s.q.GetUsageSince(ctx, since.Unix())
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return Usage{Cost: row.Cost, Tokens: row.Tokens}, nil
+}
+
+// SessionUsageSince returns what the session and its sub-agent sessions spent
+// since the given time.
+func (s *service) SessionUsageSince(ctx context.Context, sessionID string, since time.Time) (Usage, error) {
+	row, err := s.q.GetSessionUsageSince(ctx, db.GetSessionUsageSinceParams{
+		CreatedAt:       since.Unix(),
+		SessionID:       sessionID,
+		ParentSessionID: sql.NullString{String: sessionID, Valid: true},
+	})
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to get session usage: %w", err)
+	}
+	return Usage{Cost: row.Cost, Tokens: row.Tokens}, nil
+}
@@ -1,15 +1,19 @@
 package shell
 
 import (
-	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/charmbracelet/brush/internal/csync"
+	"github.com/charmbracelet/brush/internal/pubsub"
 )
 
 const (
@@ -19,41 +23,22 @@ const (
 	CompletedJobRetentionMinutes = 8 * 60
 )
 
-// syncBuffer is a thread-safe wrapper around bytes.Buffer.
-type syncBuffer struct {
-	buf bytes.Buffer
-	mu  sync.RWMutex
-}
-
-func (sb *syncBuffer) Write(p []byte) (n int, err error) {
-	sb.mu.Lock()
-	defer sb.mu.Unlock()
-	return sb.buf.Write(p)
-}
-
-func (sb *syncBuffer) WriteString(s string) (n int, err error) {
-	sb.mu.Lock()
-	defer sb.mu.Unlock()
-	return sb.buf.WriteString(s)
-}
-
-func (sb *syncBuffer) String() string {
-	sb.mu.RLock()
-	defer sb.mu.RUnlock()
-	return sb.buf.String()
-}
-
 // BackgroundShell represents a shell running in the background.
 type BackgroundShell struct {
 	ID          string
+	SessionID   string // The session that started the job, if any
 	Command     string
 	Description string
 	Shell       *Shell
 	WorkingDir  string
+	StartedAt   time.Time
+	blockFuncs  []BlockFunc
 	ctx         context.Context
 	cancel      context.CancelFunc
-	stdout      *syncBuffer
-	stderr      *syncBuffer
+	stdout      *jobLog
+	stderr      *jobLog
+	output      *jobLog // stdout and stderr interleaved, spilled to disk
+	logPath     string
 	done        chan struct{}
 	exitErr     error
 	completedAt int64 // Unix timestamp when job completed (0 if still running)
@@ -61,7 +46,10 @@ type BackgroundShell struct {
 
 // BackgroundShellManager manages background shell instances.
 type BackgroundShellManager struct {
-	shells *csync.Map[string, *BackgroundShell]
+	shells  *csync.Map[string, *BackgroundShell]
+	events  *pubsub.Broker[BackgroundShellInfo]
+	logDir  atomic.Pointer[string]
+	sandbox atomic.Pointer[Sandbox]
 }
 
 var (
@@ -74,6 +62,7 @@ var (
 func newBackgroundShellManager() *BackgroundShellManager {
 	return &BackgroundShellManager{
 		shells: csync.NewMap[string, *BackgroundShell](),
+		events: pubsub.NewBroker[BackgroundShellInfo](),
 	}
 }
 
@@ -85,32 +74,101 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 	return backgroundManager
 }
 
-// Start creates and starts a new background shell with the given command.
-func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+// SubscribeEvents returns a channel for events of the background shells
+// being started, completed, restarted and removed.
+func (m *BackgroundShellManager) SubscribeEvents(ctx context.Context) <-chan pubsub.Event[BackgroundShellInfo] {
+	return m.events.Subscribe(ctx)
+}
+
+// SetLogDir sets the directory the output of new background shells is
+// written to, and removes the logs left there that are older than the
+// retention period. Without it, output is only kept in memory.
+func (m *BackgroundShellManager) SetLogDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create job log directory: %w", err)
+	}
+	m.logDir.Store(&dir)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read job log directory: %w", err)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		if time.Since(info.ModTime()) > CompletedJobRetentionMinutes*time.Minute {
+			_ = os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return nil
+}
+
+// SetSandbox runs the commands of new background shells in the sandbox, or
+// without one if sb is nil. It fails if the sandbox isn't supported.
+func (m *BackgroundShellManager) SetSandbox(sb *Sandbox) error {
+	if sb != nil {
+		if err := sb.Check(); err != nil {
+			return fmt.Errorf("sandbox: %w", err)
+		}
+	}
+	m.sandbox.Store(sb)
+	return nil
+}
+
+// Start creates and starts a new background shell with the given command,
+// owned by the given session.
+func (m *BackgroundShellManager) Start(ctx context.Context, sessionID, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
 	}
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
+	bgShell, err := m.start(ctx, id, sessionID, workingDir, blockFuncs, command, description)
+	if err != nil {
+		return nil, err
+	}
+	m.events.Publish(pubsub.CreatedEvent, bgShell.Info())
+	return bgShell, nil
+}
+
+func (m *BackgroundShellManager) start(ctx context.Context, id, sessionID, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	var logPath string
+	if dir := m.logDir.Load(); dir != nil {
+		logPath = filepath.Join(*dir, fmt.Sprintf("%d-%s.log", os.Getpid(), id))
+	}
+	output, err := newJobLog(logPath)
+	if err != nil {
+		return nil, err
+	}
+	stdout, _ := newJobLog("")
+	stderr, _ := newJobLog("")
 
 	shell := NewShell(&Options{
 		WorkingDir: workingDir,
 		BlockFuncs: blockFuncs,
+		Sandbox:    m.sandbox.Load(),
 	})
 
 	shellCtx, cancel := context.WithCancel(ctx)
 
 	bgShell := &BackgroundShell{
 		ID:          id,
+		SessionID:   sessionID,
 		Command:     command,
 		Description: description,
 		WorkingDir:  workingDir,
 		Shell:       shell,
+		StartedAt:   time.Now(),
+		blockFuncs:  blockFuncs,
 		ctx:         shellCtx,
 		cancel:      cancel,
-		stdout:      &syncBuffer{},
-		stderr:      &syncBuffer{},
+		stdout:      stdout,
+		stderr:      stderr,
+		output:      output,
+		logPath:     logPath,
 		done:        make(chan struct{}),
 	}
 
@@ -119,15 +177,39 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 	go func() {
 		defer close(bgShell.done)
 
-		err := shell.ExecStream(shellCtx, command, bgShell.stdout, bgShell.stderr)
+		err := shell.ExecStream(shellCtx, command, io.MultiWriter(bgShell.stdout, bgShell.output), io.MultiWriter(bgShell.stderr, bgShell.output))
 
 		bgShell.exitErr = err
 		atomic.StoreInt64(&bgShell.completedAt, time.Now().Unix())
+		if current, ok := m.shells.Get(id); ok && current == bgShell {
+			m.events.Publish(pubsub.UpdatedEvent, bgShell.Info())
+		}
 	}()
 
 	return bgShell, nil
 }
 
+// Restart terminates a background shell, if it's still running, and starts
+// its command again with the same ID.
+func (m *BackgroundShellManager) Restart(id string) (*BackgroundShell, error) {
+	old, ok := m.shells.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("background shell not found: %s", id)
+	}
+	old.cancel()
+	<-old.done
+	old.close()
+
+	bgShell, err := m.start(context.Background(), id, old.SessionID, old.WorkingDir, old.blockFuncs, old.Command, old.Description)
+	if err != nil {
+		m.shells.Del(id)
+		m.events.Publish(pubsub.DeletedEvent, old.Info())
+		return nil, err
+	}
+	m.events.Publish(pubsub.UpdatedEvent, bgShell.Info())
+	return bgShell, nil
+}
+
 // Get retrieves a background shell by ID.
 func (m *BackgroundShellManager) Get(id string) (*BackgroundShell, bool) {
 	return m.shells.Get(id)
@@ -136,10 +218,12 @@ func (m *BackgroundShellManager) Get(id string) (*BackgroundShell, bool) {
 // Remove removes a background shell from the manager without terminating it.
 // This is useful when a shell has already completed and you just want to clean up tracking.
 func (m *BackgroundShellManager) Remove(id string) error {
-	_, ok := m.shells.Take(id)
+	shell, ok := m.shells.Take(id)
 	if !ok {
 		return fmt.Errorf("background shell not found: %s", id)
 	}
+	shell.close()
+	m.events.Publish(pubsub.DeletedEvent, shell.Info())
 	return nil
 }
 
@@ -152,14 +236,38 @@ func (m *BackgroundShellManager) Kill(id string) error {
 
 	shell.cancel()
 	<-shell.done
+	shell.close()
+	m.events.Publish(pubsub.DeletedEvent, shell.Info())
 	return nil
 }
 
 // BackgroundShellInfo contains information about a background shell.
 type BackgroundShellInfo struct {
 	ID          string
+	SessionID   string
 	Command     string
 	Description string
+	WorkingDir  string
+	LogPath     string
+	StartedAt   time.Time
+	Done        bool
+	ExitCode    int
+}
+
+// Jobs returns the information of the background shells owned by the
+// session, or of all of them if sessionID is empty, in the order they were
+// started.
+func (m *BackgroundShellManager) Jobs(sessionID string) []BackgroundShellInfo {
+	var jobs []BackgroundShellInfo
+	for shell := range m.shells.Seq() {
+		if sessionID == "" || shell.SessionID == sessionID {
+			jobs = append(jobs, shell.Info())
+		}
+	}
+	slices.SortFunc(jobs, func(a, b BackgroundShellInfo) int {
+		return cmp.Or(a.StartedAt.Compare(b.StartedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return jobs
 }
 
 // List returns all background shell IDs.
@@ -202,6 +310,7 @@ func (m *BackgroundShellManager) KillAll() {
 			wg.Go(func() {
 				shell.cancel()
 				<-shell.done
+				shell.close()
 			})
 		}
 		wg.Wait()
@@ -226,6 +335,60 @@ func (bs *BackgroundShell) GetOutput() (stdout string, stderr string, done bool,
 	}
 }
 
+// Info returns the information of the background shell.
+func (bs *BackgroundShell) Info() BackgroundShellInfo {
+	info := BackgroundShellInfo{
+		ID:          bs.ID,
+		SessionID:   bs.SessionID,
+		Command:     bs.Command,
+		Description: bs.Description,
+		WorkingDir:  bs.WorkingDir,
+		LogPath:     bs.logPath,
+		StartedAt:   bs.StartedAt,
+	}
+	select {
+	case <-bs.done:
+		info.Done = true
+		info.ExitCode = ExitCode(bs.exitErr)
+	default:
+	}
+	return info
+}
+
+// ReadOutput returns up to limit bytes of the interleaved stdout and stderr
+// of the background shell from offset, and the offset the returned output
+// starts at. The returned offset is past the requested one if that part of
+// the output was dropped.
+func (bs *BackgroundShell) ReadOutput(offset int64, limit int) (string, int64) {
+	data, start := bs.output.Read(offset, limit)
+	return string(data), start
+}
+
+// OutputSize returns the number of bytes of output written so far.
+func (bs *BackgroundShell) OutputSize() int64 {
+	return bs.output.Size()
+}
+
+// OutputChanged returns a channel that is closed when more output is
+// written.
+func (bs *BackgroundShell) OutputChanged() <-chan struct{} {
+	return bs.output.Changed()
+}
+
+// Done returns a channel that is closed when the background shell completes.
+func (bs *BackgroundShell) Done() <-chan struct{} {
+	return bs.done
+}
+
+// close releases the log of a completed background shell and removes its
+// file.
+func (bs *BackgroundShell) close() {
+	_ = bs.output.Close()
+	if bs.logPath != "" {
+		_ = os.Remove(bs.logPath)
+	}
+}
+
 // IsDone checks if the background shell has finished execution.
 func (bs *BackgroundShell) IsDone() bool {
 	select {
@@ -2,10 +2,14 @@ package shell
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/charmbracelet/brush/internal/pubsub"
 )
 
 func TestBackgroundShellManager_Start(t *testing.T) {
@@ -16,7 +20,7 @@ func TestBackgroundShellManager_Start(t *testing.T) {
 	workingDir := t.TempDir()
 	manager := newBackgroundShellManager()
 
-	bgShell, err := manager.Start(ctx, workingDir, nil, "echo 'hello world'", "")
+	bgShell, err := manager.Start(ctx, "", workingDir, nil, "echo 'hello world'", "")
 	if err != nil {
 		t.Fatalf("failed to start background shell: %v", err)
 	}
@@ -53,7 +57,7 @@ func TestBackgroundShellManager_Get(t *testing.T) {
 	workingDir := t.TempDir()
 	manager := newBackgroundShellManager()
 
-	bgShell, err := manager.Start(ctx, workingDir, nil, "echo 'test'", "")
+	bgShell, err := manager.Start(ctx, "", workingDir, nil, "echo 'test'", "")
 	if err != nil {
 		t.Fatalf("failed to start background shell: %v", err)
 	}
@@ -80,7 +84,7 @@ func TestBackgroundShellManager_Kill(t *testing.T) {
 	manager := newBackgroundShellManager()
 
 	// Start a long-running command
-	bgShell, err := manager.Start(ctx, workingDir, nil, "sleep 10", "")
+	bgShell, err := manager.Start(ctx, "", workingDir, nil, "sleep 10", "")
 	if err != nil {
 		t.Fatalf("failed to start background shell: %v", err)
 	}
@@ -121,7 +125,7 @@ func TestBackgroundShell_IsDone(t *testing.T) {
 	workingDir := t.TempDir()
 	manager := newBackgroundShellManager()
 
-	bgShell, err := manager.Start(ctx, workingDir, nil, "echo 'quick'", "")
+	bgShell, err := manager.Start(ctx, "", workingDir, nil, "echo 'quick'", "")
 	if err != nil {
 		t.Fatalf("failed to start background shell: %v", err)
 	}
@@ -148,7 +152,7 @@ func TestBackgroundShell_WithBlockFuncs(t *testing.T) {
 		CommandsBlocker([]string{"curl", "wget"}),
 	}
 
-	bgShell, err := manager.Start(ctx, workingDir, blockFuncs, "curl example.com", "")
+	bgShell, err := manager.Start(ctx, "", workingDir, blockFuncs, "curl example.com", "")
 	if err != nil {
 		t.Fatalf("failed to start background shell: %v", err)
 	}
@@ -183,12 +187,12 @@ func TestBackgroundShellManager_List(t *testing.T) {
 	manager := newBackgroundShellManager()
 
 	// Start two shells
-	bgShell1, err := manager.Start(ctx, workingDir, nil, "sleep 1", "")
+	bgShell1, err := manager.Start(ctx, "", workingDir, nil, "sleep 1", "")
 	if err != nil {
 		t.Fatalf("failed to start first background shell: %v", err)
 	}
 
-	bgShell2, err := manager.Start(ctx, workingDir, nil, "sleep 1", "")
+	bgShell2, err := manager.Start(ctx, "", workingDir, nil, "sleep 1", "")
 	if err != nil {
 		t.Fatalf("failed to start second background shell: %v", err)
 	}
@@ -227,17 +231,17 @@ func TestBackgroundShellManager_KillAll(t *testing.T) {
 	manager := newBackgroundShellManager()
 
 	// Start multiple long-running shells
-	shell1, err := manager.Start(ctx, workingDir, nil, "sleep 10", "")
+	shell1, err := manager.Start(ctx, "", workingDir, nil, "sleep 10", "")
 	if err != nil {
 		t.Fatalf("failed to start shell 1: %v", err)
 	}
 
-	shell2, err := manager.Start(ctx, workingDir, nil, "sleep 10", "")
+	shell2, err := manager.Start(ctx, "", workingDir, nil, "sleep 10", "")
 	if err != nil {
 		t.Fatalf("failed to start shell 2: %v", err)
 	}
 
-	shell3, err := manager.Start(ctx, workingDir, nil, "sleep 10", "")
+	shell3, err := manager.Start(ctx, "", workingDir, nil, "sleep 10", "")
 	if err != nil {
 		t.Fatalf("failed to start shell 3: %v", err)
 	}
@@ -280,3 +284,67 @@ func TestBackgroundShellManager_KillAll(t *testing.T) {
 		}
 	}
 }
+
+func TestBackgroundShellManager_LogsAndRestart(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	workingDir := t.TempDir()
+	logDir := filepath.Join(t.TempDir(), "jobs")
+	manager := newBackgroundShellManager()
+	if err := manager.SetLogDir(logDir); err != nil {
+		t.Fatalf("failed to set log dir: %v", err)
+	}
+
+	events := manager.SubscribeEvents(ctx)
+
+	bgShell, err := manager.Start(ctx, "session-1", workingDir, nil, "echo out && echo err >&2", "")
+	if err != nil {
+		t.Fatalf("failed to start background shell: %v", err)
+	}
+	other, err := manager.Start(ctx, "session-2", workingDir, nil, "true", "")
+	if err != nil {
+		t.Fatalf("failed to start background shell: %v", err)
+	}
+	defer manager.Kill(other.ID)
+	bgShell.Wait()
+
+	if event := <-events; event.Type != pubsub.CreatedEvent || event.Payload.ID != bgShell.ID {
+		t.Errorf("expected created event for %s, got %v", bgShell.ID, event)
+	}
+
+	jobs := manager.Jobs("session-1")
+	if len(jobs) != 1 || jobs[0].ID != bgShell.ID || !jobs[0].Done {
+		t.Fatalf("expected the completed job of session-1, got %+v", jobs)
+	}
+	logPath := jobs[0].LogPath
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read job log: %v", err)
+	}
+	if string(content) != "out\nerr\n" {
+		t.Errorf("expected interleaved output in the log, got %q", content)
+	}
+	if output, start := bgShell.ReadOutput(4, 100); output != "err\n" || start != 4 {
+		t.Errorf("expected output from offset 4, got %q at %d", output, start)
+	}
+
+	restarted, err := manager.Restart(bgShell.ID)
+	if err != nil {
+		t.Fatalf("failed to restart background shell: %v", err)
+	}
+	if restarted.ID != bgShell.ID || restarted.SessionID != "session-1" {
+		t.Errorf("expected restarted job to keep ID and owner, got %s of %s", restarted.ID, restarted.SessionID)
+	}
+	restarted.Wait()
+	if output, _ := restarted.ReadOutput(0, 100); output != "out\nerr\n" {
+		t.Errorf("expected fresh output after restart, got %q", output)
+	}
+
+	if err := manager.Remove(bgShell.ID); err != nil {
+		t.Fatalf("failed to remove background shell: %v", err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("expected job log to be removed, got %v", err)
+	}
+}
@@ -374,3 +374,67 @@ func TestSplitArgsFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		args    []string
+		want    bool
+	}{
+		{"curl", []string{"curl", "-s", "https://example.com"}, true},
+		{"curl", []string{"curlie"}, false},
+		{"git push", []string{"git", "push", "origin", "main"}, true},
+		{"git push", []string{"git", "pull"}, false},
+		{"git push --force", []string{"git", "push", "--force", "origin"}, true},
+		{"git push --force", []string{"git", "push", "origin"}, false},
+		{"make test", []string{"make", "test"}, true},
+		{"make test", []string{"make", "install"}, false},
+		{"", []string{"ls"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+strings.Join(tt.args, " "), func(t *testing.T) {
+			require.Equal(t, tt.want, CommandPattern(tt.pattern)(tt.args))
+		})
+	}
+}
+
+func TestSimpleCommands(t *testing.T) {
+	commands, literal, err := SimpleCommands(`cd dir && git "status" -s; (make 'test') | tee out.log`)
+	require.NoError(t, err)
+	require.True(t, literal)
+	require.Equal(t, [][]string{
+		{"cd", "dir"},
+		{"git", "status", "-s"},
+		{"make", "test"},
+		{"tee", "out.log"},
+	}, commands)
+
+	commands, literal, err = SimpleCommands(`echo $(rm -rf build) "$HOME"`)
+	require.NoError(t, err)
+	require.False(t, literal)
+	require.Len(t, commands, 2)
+	require.Equal(t, []string{"rm", "-rf", "build"}, commands[1])
+
+	for script, want := range map[string]bool{
+		`echo x > ~/.bashrc`:                false,
+		`(echo x >> out.log)`:               false,
+		`ls &> "$LOG"`:                      false,
+		`ls >&out.log`:                      false,
+		`go test ./... 2>&1 | tail`:         true,
+		`ls 2> /dev/null`:                   true,
+		`git status < input.txt >/dev/null`: true,
+		`LD_PRELOAD=/tmp/x.so git status`:   false,
+		`PATH=/tmp/evil:$PATH git status`:   false,
+		`GIT_DIR=/tmp/repo git log`:         false,
+		`BASH_ENV=/tmp/x; git status`:       false,
+		`export PATH=/tmp/evil; git status`: false,
+		`git log --grep=PATH=x`:             true,
+	} {
+		_, literal, err := SimpleCommands(script)
+		require.NoError(t, err)
+		require.Equal(t, want, literal, script)
+	}
+
+	_, _, err = SimpleCommands(`echo "unterminated`)
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	// jobLogHeadBytes is how much of the start of a job's output is kept in
+	// memory.
+	jobLogHeadBytes = 32 * 1024
+	// jobLogTailBytes is how much of the end of a job's output is kept in
+	// memory.
+	jobLogTailBytes = 64 * 1024
+)
+
+// jobLog is the output of a background job. It keeps the start and the end of
+// the output in memory, and all of it in a file if it has one.
+type jobLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	head    []byte
+	tail    []byte
+	changed chan struct{}
+}
+
+// newJobLog creates a job log that writes to the file at path, or only keeps
+// the output in memory if path is empty.
+func newJobLog(path string) (*jobLog, error) {
+	l := &jobLog{changed: make(chan struct{})}
+	if path == "" {
+		return l, nil
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Write implements [io.Writer].
+func (l *jobLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		if _, err := l.file.Write(p); err != nil {
+			// Keep the output in memory if the disk fails us.
+			l.file.Close()
+			l.file = nil
+		}
+	}
+	l.size += int64(len(p))
+
+	rest := p
+	if n := min(len(rest), jobLogHeadBytes-len(l.head)); n > 0 {
+		l.head = append(l.head, rest[:n]...)
+		rest = rest[n:]
+	}
+	if len(rest) > 0 {
+		l.tail = append(l.tail, rest...)
+		if over := len(l.tail) - jobLogTailBytes; over > 0 {
+			l.tail = append(l.tail[:0], l.tail[over:]...)
+		}
+	}
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+	return len(p), nil
+}
+
+// String returns the output kept in memory. Output that was dropped in
+// between is replaced with a note.
+func (l *jobLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	omitted := l.size - int64(len(l.head)+len(l.tail))
+	if omitted == 0 {
+		return string(l.head) + string(l.tail)
+	}
+	return fmt.Sprintf("%s\n\n... [%d bytes truncated] ...\n\n%s", l.head, omitted, l.tail)
+}
+
+// Size returns the number of bytes written to the log.
+func (l *jobLog) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Changed returns a channel that is closed on the next write.
+func (l *jobLog) Changed() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.changed
+}
+
+// Read returns up to limit bytes of output from offset, and the offset the
+// returned output starts at. Without a file, output that is no longer in
+// memory is skipped, so the returned offset can be past the requested one.
+func (l *jobLog) Read(offset int64, limit int) ([]byte, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	offset = max(0, min(offset, l.size))
+	n := int(min(int64(limit), l.size-offset))
+	if n <= 0 {
+		return nil, offset
+	}
+	if l.file != nil {
+		buf := make([]byte, n)
+		read, _ := l.file.ReadAt(buf, offset)
+		if read == n {
+			return buf, offset
+		}
+	}
+
+	if offset < int64(len(l.head)) {
+		data := l.head[offset:min(int(offset)+n, len(l.head))]
+		return append([]byte(nil), data...), offset
+	}
+	tailStart := l.size - int64(len(l.tail))
+	offset = max(offset, tailStart)
+	data := l.tail[offset-tailStart : min(offset-tailStart+int64(n), int64(len(l.tail)))]
+	return append([]byte(nil), data...), offset
+}
+
+// Close closes the file of the log. The output kept in memory is still
+// available.
+func (l *jobLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobLog_Truncation(t *testing.T) {
+	t.Parallel()
+
+	l, err := newJobLog("")
+	if err != nil {
+		t.Fatalf("failed to create job log: %v", err)
+	}
+	head := strings.Repeat("h", jobLogHeadBytes)
+	middle := strings.Repeat("m", 1000)
+	tail := strings.Repeat("t", jobLogTailBytes)
+	for _, s := range []string{head, middle, tail} {
+		if _, err := l.Write([]byte(s)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	if got, want := l.Size(), int64(len(head)+len(middle)+len(tail)); got != want {
+		t.Errorf("expected size %d, got %d", want, got)
+	}
+	want := head + "\n\n... [1000 bytes truncated] ...\n\n" + tail
+	if got := l.String(); got != want {
+		t.Errorf("expected head and tail with truncation note, got %d bytes", len(got))
+	}
+
+	// Without a file, reading the dropped middle skips to the tail.
+	data, start := l.Read(int64(len(head)), 10)
+	if wantStart := int64(len(head) + len(middle)); start != wantStart {
+		t.Errorf("expected read to start at %d, got %d", wantStart, start)
+	}
+	if string(data) != "tttttttttt" {
+		t.Errorf("expected tail data, got %q", data)
+	}
+}
+
+func TestJobLog_File(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "job.log")
+	l, err := newJobLog(path)
+	if err != nil {
+		t.Fatalf("failed to create job log: %v", err)
+	}
+	defer l.Close()
+
+	changed := l.Changed()
+	output := strings.Repeat("0123456789", jobLogHeadBytes+jobLogTailBytes)
+	if _, err := l.Write([]byte(output)); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Error("expected changed channel to be closed after a write")
+	}
+
+	offset := int64(jobLogHeadBytes + 5)
+	data, start := l.Read(offset, 8)
+	if start != offset {
+		t.Errorf("expected read to start at %d, got %d", offset, start)
+	}
+	if want := output[offset : offset+8]; string(data) != want {
+		t.Errorf("expected data from the file, got %q", data)
+	}
+
+	data, start = l.Read(l.Size(), 8)
+	if len(data) != 0 || start != l.Size() {
+		t.Errorf("expected no data at the end, got %q at %d", data, start)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if string(content) != output {
+		t.Errorf("expected the log file to have all the output, got %d bytes", len(content))
+	}
+}
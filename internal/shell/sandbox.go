@@ -0,0 +1,184 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// sandboxHelperArg is the first argument of the process that sets up the
+// sandbox for a command and then runs it. See [RunSandboxHelper].
+const sandboxHelperArg = "__brush_sandbox"
+
+// Sandbox restricts what the commands run by a shell can do. Commands can
+// read anything, but only write to the writable paths, and can't use the
+// network unless allowed.
+type Sandbox struct {
+	// WritablePaths are the directories and files commands can write to.
+	WritablePaths []string `json:"writable_paths"`
+	// AllowNetwork allows commands to open network connections.
+	AllowNetwork bool `json:"allow_network"`
+}
+
+// NewSandbox returns a sandbox that allows writes to the working directory,
+// the temp directory and the extra paths.
+func NewSandbox(workingDir string, extraPaths []string, allowNetwork bool) *Sandbox {
+	paths := []string{workingDir, os.TempDir()}
+	if os.TempDir() != "/tmp" {
+		paths = append(paths, "/tmp")
+	}
+	return &Sandbox{
+		WritablePaths: append(paths, extraPaths...),
+		AllowNetwork:  allowNetwork,
+	}
+}
+
+// Check returns an error explaining why the sandbox can't be used on this
+// system, if it can't.
+func (sb *Sandbox) Check() error {
+	return checkSandbox(sb)
+}
+
+// Describe returns a sentence describing what the sandbox allows, for errors
+// and hints.
+func (sb *Sandbox) Describe() string {
+	network := "Network access is blocked."
+	if sb.AllowNetwork {
+		network = "Network access is allowed."
+	}
+	return fmt.Sprintf("Commands run in a sandbox that only allows writing to %s. %s", strings.Join(sb.WritablePaths, ", "), network)
+}
+
+// Hint returns a note about the sandbox to append to the output of a failed
+// command, if the output looks like the sandbox blocked it.
+func (sb *Sandbox) Hint(output string) string {
+	if sb == nil {
+		return ""
+	}
+	for _, msg := range []string{
+		"Permission denied",
+		"Operation not permitted",
+		"Read-only file system",
+		"Network is unreachable",
+		"sandbox:",
+		"EACCES",
+		"EPERM",
+	} {
+		if strings.Contains(output, msg) {
+			return sb.Describe() + " If the command needs more, ask the user to change tools.bash.sandbox in the config."
+		}
+	}
+	return ""
+}
+
+// allowsWrite reports whether the sandbox allows writing to the path.
+func (sb *Sandbox) allowsWrite(path string) bool {
+	path = resolvePath(path)
+	for _, allowed := range sb.WritablePaths {
+		allowed = resolvePath(allowed)
+		if path == allowed || strings.HasPrefix(path, strings.TrimSuffix(allowed, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return slices.Contains(sandboxDevices, path)
+}
+
+// sandboxDevices are the devices commands can always write to.
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/tty", "/dev/random", "/dev/urandom"}
+
+// resolvePath returns the absolute path with the symlinks of its longest
+// existing prefix resolved, so that files that don't exist yet resolve to
+// where they would be created.
+func resolvePath(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	var rest []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		if dir == filepath.Dir(dir) {
+			return path
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// sandboxExecHandler runs commands through the sandbox helper, which sets
+// up the sandbox before running them.
+func (s *Shell) sandboxExecHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return next(ctx, args)
+			}
+			self, err := os.Executable()
+			if err != nil {
+				return fmt.Errorf("sandbox: could not find the brush executable: %w", err)
+			}
+			config, err := json.Marshal(s.sandbox)
+			if err != nil {
+				return fmt.Errorf("sandbox: %w", err)
+			}
+			return next(ctx, append([]string{self, sandboxHelperArg, string(config)}, args...))
+		}
+	}
+}
+
+// sandboxOpenHandler blocks the redirections of the shell itself from
+// writing outside of the sandbox.
+func (s *Shell) sandboxOpenHandler() interp.OpenHandlerFunc {
+	open := interp.DefaultOpenHandler()
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+			abs := path
+			if !filepath.IsAbs(abs) {
+				abs = filepath.Join(interp.HandlerCtx(ctx).Dir, abs)
+			}
+			if !s.sandbox.allowsWrite(abs) {
+				return nil, fmt.Errorf("sandbox: writing to %s is not allowed. %s", abs, s.sandbox.Describe())
+			}
+		}
+		return open(ctx, path, flag, perm)
+	}
+}
+
+// RunSandboxHelper sets up the sandbox and runs the command if the process
+// was started as the sandbox helper, and returns right away otherwise. It
+// must be called at the very start of main.
+func RunSandboxHelper() {
+	if len(os.Args) < 4 || os.Args[1] != sandboxHelperArg {
+		return
+	}
+
+	var sb Sandbox
+	if err := json.Unmarshal([]byte(os.Args[2]), &sb); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid configuration: %v\n", err)
+		os.Exit(126)
+	}
+	args := os.Args[3:]
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "%q: executable file not found in $PATH\n", args[0])
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(127)
+	}
+	if err := execSandboxed(&sb, path, args); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
@@ -0,0 +1,203 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkSandbox checks that the kernel supports Landlock, which the sandbox
+// relies on to restrict writes.
+func checkSandbox(*Sandbox) error {
+	if _, err := seccompArch(); err != nil {
+		return err
+	}
+	abi, err := landlockABI()
+	if err != nil {
+		return fmt.Errorf("the sandbox requires Landlock, which is not available: %w. Enable it with the lsm=landlock kernel parameter, or disable the sandbox", err)
+	}
+	if abi < 1 {
+		return errors.New("the sandbox requires Landlock, which is disabled in this kernel")
+	}
+	return nil
+}
+
+// execSandboxed restricts the current process to the sandbox and replaces it
+// with the command.
+func execSandboxed(sb *Sandbox, path string, args []string) error {
+	// Landlock and seccomp only restrict the calling thread, which then execs
+	// the command.
+	runtime.LockOSThread()
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("could not set no_new_privs: %w", err)
+	}
+	if err := restrictWrites(sb.WritablePaths); err != nil {
+		return err
+	}
+	if !sb.AllowNetwork {
+		if err := blockNetwork(); err != nil {
+			return err
+		}
+	}
+	if err := unix.Exec(path, args, os.Environ()); err != nil {
+		return fmt.Errorf("could not run %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// landlockABI returns the version of Landlock supported by the kernel.
+func landlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(abi), nil
+}
+
+// restrictWrites uses Landlock to deny creating, changing and removing files
+// outside of the writable paths. Reading and executing is left alone.
+func restrictWrites(writablePaths []string) error {
+	abi, err := landlockABI()
+	if err != nil {
+		return fmt.Errorf("landlock is not available: %w", err)
+	}
+
+	const fileAccess = unix.LANDLOCK_ACCESS_FS_WRITE_FILE
+	dirAccess := uint64(fileAccess |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	truncate := uint64(0)
+	if abi >= 2 {
+		dirAccess |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		truncate = unix.LANDLOCK_ACCESS_FS_TRUNCATE
+		dirAccess |= truncate
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: dirAccess}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("could not create landlock ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	for _, path := range writablePaths {
+		if err := addLandlockRule(ruleset, path, dirAccess, fileAccess|truncate); err != nil {
+			return err
+		}
+	}
+	for _, path := range sandboxDevices {
+		if err := addLandlockRule(ruleset, path, dirAccess, fileAccess|truncate); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("could not enforce landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule allows writing beneath the path. Paths that don't exist are
+// skipped, and files only get the access rights that apply to files.
+func addLandlockRule(ruleset int, path string, dirAccess, fileAccess uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if errors.Is(err, unix.ENOENT) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open writable path %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("could not stat writable path %s: %w", path, err)
+	}
+	access := dirAccess
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access = fileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("could not allow writing to %s: %w", path, errno)
+	}
+	return nil
+}
+
+// seccompArch returns the audit architecture of the seccomp filter.
+func seccompArch() (uint32, error) {
+	switch runtime.GOARCH {
+	case "amd64":
+		return unix.AUDIT_ARCH_X86_64, nil
+	case "arm64":
+		return unix.AUDIT_ARCH_AARCH64, nil
+	default:
+		return 0, fmt.Errorf("the sandbox is not supported on %s", runtime.GOARCH)
+	}
+}
+
+// blockNetwork installs a seccomp filter that only allows Unix sockets, so
+// commands can't reach the network. Syscalls of other architectures and
+// io_uring, which would get around the filter, are denied too.
+func blockNetwork() error {
+	arch, err := seccompArch()
+	if err != nil {
+		return err
+	}
+
+	const (
+		offsetNr   = 0
+		offsetArch = 4
+		offsetArg0 = 16 // low half of the first argument on little endian
+		x32Bit     = 0x40000000
+	)
+	load := func(offset uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: offset}
+	}
+	jump := func(op uint16, k uint32, jt, jf uint8) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_JMP | op | unix.BPF_K, K: k, Jt: jt, Jf: jf}
+	}
+	ret := func(k uint32) unix.SockFilter {
+		return unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: k}
+	}
+	deny := func(errno unix.Errno) unix.SockFilter {
+		return ret(unix.SECCOMP_RET_ERRNO | uint32(errno)&unix.SECCOMP_RET_DATA)
+	}
+
+	filter := []unix.SockFilter{
+		load(offsetArch),
+		jump(unix.BPF_JEQ, arch, 1, 0),
+		deny(unix.EPERM),
+		load(offsetNr),
+		jump(unix.BPF_JGE, x32Bit, 0, 1),
+		deny(unix.EPERM),
+		jump(unix.BPF_JEQ, unix.SYS_SOCKET, 0, 3),
+		load(offsetArg0),
+		jump(unix.BPF_JEQ, unix.AF_UNIX, 3, 0),
+		deny(unix.EACCES),
+		jump(unix.BPF_JEQ, unix.SYS_IO_URING_SETUP, 0, 1),
+		deny(unix.ENOSYS),
+		ret(unix.SECCOMP_RET_ALLOW),
+	}
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("could not install seccomp filter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package shell
+
+import "errors"
+
+var errSandboxUnsupported = errors.New("the sandbox is only supported on Linux")
+
+func checkSandbox(*Sandbox) error {
+	return errSandboxUnsupported
+}
+
+func execSandboxed(*Sandbox, string, []string) error {
+	return errSandboxUnsupported
+}
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The sandboxed commands run through the test binary.
+	RunSandboxHelper()
+	os.Exit(m.Run())
+}
+
+func TestSandbox_AllowsWrite(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	sb := &Sandbox{WritablePaths: []string{dir}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{dir, true},
+		{filepath.Join(dir, "file.txt"), true},
+		{filepath.Join(dir, "missing", "dir", "file.txt"), true},
+		{filepath.Join(dir, "..", filepath.Base(other), "file.txt"), false},
+		{dir + "-sibling", false},
+		{filepath.Join(other, "file.txt"), false},
+		{"/dev/null", true},
+	}
+	for _, tt := range tests {
+		if got := sb.allowsWrite(tt.path); got != tt.want {
+			t.Errorf("allowsWrite(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(other, link); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if sb.allowsWrite(filepath.Join(link, "file.txt")) {
+		t.Errorf("allowsWrite followed a symlink out of the sandbox")
+	}
+}
+
+func TestShell_SandboxRedirect(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	sh := NewShell(&Options{
+		WorkingDir: dir,
+		Sandbox:    &Sandbox{WritablePaths: []string{dir}},
+	})
+
+	if _, _, err := sh.Exec(t.Context(), "echo hi > inside.txt"); err != nil {
+		t.Fatalf("writing inside the sandbox failed: %v", err)
+	}
+	_, _, err := sh.Exec(t.Context(), "echo hi > "+filepath.Join(other, "outside.txt"))
+	if err == nil || !strings.Contains(err.Error(), "sandbox: writing to") {
+		t.Fatalf("expected a sandbox error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(other, "outside.txt")); !os.IsNotExist(err) {
+		t.Errorf("file outside the sandbox was created")
+	}
+}
+
+func TestShell_SandboxCommands(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	sb := &Sandbox{WritablePaths: []string{dir}}
+	if err := sb.Check(); err != nil {
+		t.Skipf("sandbox not supported: %v", err)
+	}
+	sh := NewShell(&Options{WorkingDir: dir, Sandbox: sb})
+
+	if _, stderr, err := sh.Exec(t.Context(), "touch inside.txt"); err != nil {
+		t.Fatalf("writing inside the sandbox failed: %v: %s", err, stderr)
+	}
+	_, stderr, err := sh.Exec(t.Context(), "touch "+filepath.Join(other, "outside.txt"))
+	if err == nil {
+		t.Fatalf("writing outside the sandbox succeeded")
+	}
+	if sb.Hint(stderr) == "" {
+		t.Errorf("expected a sandbox hint for %q", stderr)
+	}
+	if _, stderr, err := sh.Exec(t.Context(), "cat /etc/hostname > /dev/null"); err != nil {
+		t.Errorf("reading outside the sandbox failed: %v: %s", err, stderr)
+	}
+	if _, _, err := sh.Exec(t.Context(), "missing-command-for-sandbox-test"); ExitCode(err) != 127 {
+		t.Errorf("expected exit code 127 for a missing command, got %v", err)
+	}
+}
@@ -56,6 +56,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	sandbox    *Sandbox
 }
 
 // Options for creating a new shell
@@ -64,6 +65,8 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// Sandbox, if set, restricts what the commands run by the shell can do.
+	Sandbox *Sandbox
 }
 
 // NewShell creates a new shell instance with the given options
@@ -92,6 +95,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		sandbox:    opts.Sandbox,
 	}
 }
 
@@ -111,6 +115,17 @@ func (s *Shell) ExecStream(ctx context.Context, command string, stdout, stderr i
 	return s.execStream(ctx, command, stdout, stderr)
 }
 
+// ExecInput executes a command in the shell with stdin read from the provided
+// reader
+func (s *Shell) ExecInput(ctx context.Context, command string, stdin io.Reader) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	err := s.execCommon(ctx, command, stdin, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
 // GetWorkingDir returns the current working directory
 func (s *Shell) GetWorkingDir() string {
 	s.mu.Lock()
@@ -165,6 +180,11 @@ func (s *Shell) SetBlockFuncs(blockFuncs []BlockFunc) {
 	s.blockFuncs = blockFuncs
 }
 
+// Sandbox returns the sandbox of the shell, or nil if it has none.
+func (s *Shell) Sandbox() *Sandbox {
+	return s.sandbox
+}
+
 // CommandsBlocker creates a BlockFunc that blocks exact command matches
 func CommandsBlocker(cmds []string) BlockFunc {
 	bannedSet := make(map[string]struct{})
@@ -200,6 +220,113 @@ func ArgumentsBlocker(cmd string, args []string, flags []string) BlockFunc {
 	}
 }
 
+// CommandPattern creates a BlockFunc that matches commands like the pattern,
+// a command followed by the subcommands and flags to match, such as
+// "git push --force". It matches like [ArgumentsBlocker].
+func CommandPattern(pattern string) BlockFunc {
+	fields := strings.Fields(pattern)
+	if len(fields) == 0 {
+		return func([]string) bool { return false }
+	}
+	args, flags := splitArgsFlags(fields[1:])
+	return ArgumentsBlocker(fields[0], args, flags)
+}
+
+// SimpleCommands returns the arguments of every simple command in the
+// script, including those in subshells and command substitutions. The
+// boolean is false if any word isn't a literal, like a variable or a command
+// substitution, if the script writes to a file through a redirection, or if
+// it sets variables, like PATH=/tmp git status or export LD_PRELOAD=x.so, in
+// which case the arguments don't tell what will actually run.
+func SimpleCommands(script string) ([][]string, bool, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		return nil, false, fmt.Errorf("could not parse command: %w", err)
+	}
+
+	var commands [][]string
+	literal := true
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Stmt:
+			if slices.ContainsFunc(node.Redirs, writesFile) {
+				literal = false
+			}
+			return true
+		case *syntax.DeclClause:
+			literal = false
+			return true
+		}
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		if len(call.Assigns) > 0 {
+			literal = false
+		}
+		if len(call.Args) == 0 {
+			return true
+		}
+		args := make([]string, 0, len(call.Args))
+		for _, word := range call.Args {
+			lit := word.Lit()
+			if lit == "" {
+				if unquoted, ok := unquote(word); ok {
+					lit = unquoted
+				} else {
+					literal = false
+				}
+			}
+			args = append(args, lit)
+		}
+		commands = append(commands, args)
+		return true
+	})
+	return commands, literal, nil
+}
+
+// writesFile reports whether the redirection writes to a file, other than
+// the null device. Duplicating a file descriptor, like 2>&1, doesn't.
+func writesFile(redir *syntax.Redirect) bool {
+	switch redir.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+	case syntax.DplOut:
+		target := redir.Word.Lit()
+		if target == "-" || (target != "" && strings.Trim(target, "0123456789") == "") {
+			return false
+		}
+	default:
+		return false
+	}
+	target, ok := unquote(redir.Word)
+	return !ok || target != "/dev/null"
+}
+
+// unquote returns the value of a word made only of literals and quoted
+// literals, like "foo" or 'bar'baz.
+func unquote(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
 func splitArgsFlags(parts []string) (args []string, flags []string) {
 	args = make([]string, 0, len(parts))
 	flags = make([]string, 0, len(parts))
@@ -237,14 +364,18 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 }
 
 // newInterp creates a new interpreter with the current shell state
-func (s *Shell) newInterp(stdout, stderr io.Writer) (*interp.Runner, error) {
-	return interp.New(
-		interp.StdIO(nil, stdout, stderr),
+func (s *Shell) newInterp(stdin io.Reader, stdout, stderr io.Writer) (*interp.Runner, error) {
+	opts := []interp.RunnerOption{
+		interp.StdIO(stdin, stdout, stderr),
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(s.env...)),
 		interp.Dir(s.cwd),
 		interp.ExecHandlers(s.execHandlers()...),
-	)
+	}
+	if s.sandbox != nil {
+		opts = append(opts, interp.OpenHandler(s.sandboxOpenHandler()))
+	}
+	return interp.New(opts...)
 }
 
 // updateShellFromRunner updates the shell from the interpreter after execution.
@@ -259,13 +390,13 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 }
 
 // execCommon is the shared implementation for executing commands
-func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) error {
+func (s *Shell) execCommon(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
 		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	runner, err := s.newInterp(stdout, stderr)
+	runner, err := s.newInterp(stdin, stdout, stderr)
 	if err != nil {
 		return fmt.Errorf("could not run command: %w", err)
 	}
@@ -279,19 +410,23 @@ func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr i
 // exec executes commands using a cross-platform shell interpreter.
 func (s *Shell) exec(ctx context.Context, command string) (string, string, error) {
 	var stdout, stderr bytes.Buffer
-	err := s.execCommon(ctx, command, &stdout, &stderr)
+	err := s.execCommon(ctx, command, nil, &stdout, &stderr)
 	return stdout.String(), stderr.String(), err
 }
 
 // execStream executes commands using POSIX shell emulation with streaming output
 func (s *Shell) execStream(ctx context.Context, command string, stdout, stderr io.Writer) error {
-	return s.execCommon(ctx, command, stdout, stderr)
+	return s.execCommon(ctx, command, nil, stdout, stderr)
 }
 
 func (s *Shell) execHandlers() []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 	handlers := []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc{
 		s.blockHandler(),
 	}
+	if s.sandbox != nil {
+		// The Go coreutils run in-process, where the sandbox can't reach.
+		return append(handlers, s.sandboxExecHandler())
+	}
 	if useGoCoreUtils {
 		handlers = append(handlers, coreutils.ExecHandler)
 	}
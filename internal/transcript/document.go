@@ -0,0 +1,195 @@
+package transcript
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/diff"
+	"github.com/charmbracelet/brush/internal/message"
+)
+
+// maxResultLines is the number of lines of a tool result shown in Markdown
+// and HTML. The JSON format keeps the whole result.
+const maxResultLines = 100
+
+// Document is the readable form of a transcript, shared by the Markdown and
+// HTML renderers.
+type Document struct {
+	Title            string
+	SessionID        string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Models           []string
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+	Entries          []Entry
+	Changes          []Change
+}
+
+// Entry is a user or assistant message.
+type Entry struct {
+	ID          string
+	Role        message.MessageRole
+	Model       string
+	Time        time.Time
+	Summary     bool
+	Reasoning   string
+	Text        string
+	Attachments []string
+	ToolCalls   []ToolCall
+	// Error is the error the message finished with, if any.
+	Error string
+}
+
+// Heading returns the heading of the message: its role, and the model that
+// wrote it.
+func (e Entry) Heading() string {
+	heading := "User"
+	if e.Role == message.Assistant {
+		heading = "Assistant"
+		if e.Summary {
+			heading = "Summary"
+		}
+		if e.Model != "" {
+			heading += " · " + e.Model
+		}
+	}
+	return heading
+}
+
+// ToolCall is a tool call with its result.
+type ToolCall struct {
+	Name    string
+	Input   string
+	Result  string
+	IsError bool
+	// Omitted is the number of result lines left out.
+	Omitted int
+}
+
+// Change is the diff of a file from its first to its last version in the
+// session.
+type Change struct {
+	Path      string
+	Diff      string
+	Additions int
+	Removals  int
+}
+
+// NewDocument builds the readable form of a transcript.
+func NewDocument(t Transcript) Document {
+	d := Document{
+		Title:            t.Session.Title,
+		SessionID:        t.Session.ID,
+		CreatedAt:        time.Unix(t.Session.CreatedAt, 0),
+		UpdatedAt:        time.Unix(t.Session.UpdatedAt, 0),
+		PromptTokens:     t.Session.PromptTokens,
+		CompletionTokens: t.Session.CompletionTokens,
+		Cost:             t.Session.Cost,
+	}
+
+	results := make(map[string]message.ToolResult)
+	for _, msg := range t.Messages {
+		for _, tr := range msg.ToolResults() {
+			results[tr.ToolCallID] = tr
+		}
+	}
+
+	for _, msg := range t.Messages {
+		if msg.Role != message.User && msg.Role != message.Assistant {
+			continue
+		}
+		if msg.Model != "" && !slices.Contains(d.Models, msg.Model) {
+			d.Models = append(d.Models, msg.Model)
+		}
+		entry := Entry{
+			ID:        msg.ID,
+			Role:      msg.Role,
+			Model:     msg.Model,
+			Time:      time.Unix(msg.CreatedAt, 0),
+			Summary:   msg.IsSummaryMessage,
+			Reasoning: strings.TrimSpace(msg.ReasoningContent().Thinking),
+			Text:      strings.TrimSpace(msg.Content().Text),
+		}
+		for _, b := range msg.BinaryContent() {
+			entry.Attachments = append(entry.Attachments, cmp.Or(b.Path, b.MIMEType))
+		}
+		for _, tc := range msg.ToolCalls() {
+			call := ToolCall{Name: tc.Name, Input: indentJSON(tc.Input)}
+			if tr, ok := results[tc.ID]; ok {
+				call.Result, call.Omitted = truncateLines(strings.TrimSpace(tr.Content), maxResultLines)
+				call.IsError = tr.IsError
+			}
+			entry.ToolCalls = append(entry.ToolCalls, call)
+		}
+		if f := msg.FinishPart(); f != nil && f.Reason == message.FinishReasonError {
+			entry.Error = strings.TrimSpace(f.Message + "\n" + f.Details)
+		}
+		if entry.Text == "" && entry.Reasoning == "" && len(entry.ToolCalls) == 0 && len(entry.Attachments) == 0 && entry.Error == "" {
+			continue
+		}
+		d.Entries = append(d.Entries, entry)
+	}
+
+	d.Changes = changes(t.Files)
+	return d
+}
+
+// changes diffs the first and last versions of each file, in the order the
+// files were first edited.
+func changes(files []File) []Change {
+	var paths []string
+	first := make(map[string]File)
+	last := make(map[string]File)
+	for _, f := range files {
+		if prev, ok := first[f.Path]; !ok || f.Version < prev.Version {
+			if !ok {
+				paths = append(paths, f.Path)
+			}
+			first[f.Path] = f
+		}
+		if prev, ok := last[f.Path]; !ok || f.Version >= prev.Version {
+			last[f.Path] = f
+		}
+	}
+
+	var changes []Change
+	for _, path := range paths {
+		before, after := first[path], last[path]
+		if before.Content == after.Content {
+			continue
+		}
+		unified, additions, removals := diff.GenerateDiff(before.Content, after.Content, path)
+		changes = append(changes, Change{
+			Path:      path,
+			Diff:      strings.TrimRight(unified, "\n"),
+			Additions: additions,
+			Removals:  removals,
+		})
+	}
+	return changes
+}
+
+// indentJSON pretty-prints a tool call input, if it's JSON.
+func indentJSON(input string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(input), "", "  "); err != nil {
+		return input
+	}
+	return buf.String()
+}
+
+// truncateLines keeps the first n lines of s, and returns how many lines were
+// left out.
+func truncateLines(s string, n int) (string, int) {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s, 0
+	}
+	return strings.Join(lines[:n], "\n"), len(lines) - n
+}
@@ -0,0 +1,80 @@
+package transcript
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// timeFormat is how times are shown in Markdown.
+const timeFormat = "2006-01-02 15:04"
+
+// Markdown writes the transcript as a Markdown document.
+func Markdown(w io.Writer, t Transcript) error {
+	d := NewDocument(t)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", d.Title)
+	fmt.Fprintf(&b, "- Session: `%s`\n", d.SessionID)
+	fmt.Fprintf(&b, "- Started: %s\n", d.CreatedAt.Format(timeFormat))
+	if len(d.Models) > 0 {
+		fmt.Fprintf(&b, "- Models: %s\n", strings.Join(d.Models, ", "))
+	}
+	fmt.Fprintf(&b, "- Tokens: %d prompt, %d completion\n", d.PromptTokens, d.CompletionTokens)
+	fmt.Fprintf(&b, "- Cost: $%.4f\n", d.Cost)
+
+	for _, e := range d.Entries {
+		b.WriteString("\n## ")
+		b.WriteString(e.Heading())
+		b.WriteString("\n\n")
+		if e.Reasoning != "" {
+			fmt.Fprintf(&b, "<details>\n<summary>Reasoning</summary>\n\n%s\n\n</details>\n\n", e.Reasoning)
+		}
+		if e.Text != "" {
+			b.WriteString(e.Text)
+			b.WriteString("\n\n")
+		}
+		for _, a := range e.Attachments {
+			fmt.Fprintf(&b, "_Attachment: %s_\n\n", a)
+		}
+		for _, tc := range e.ToolCalls {
+			fmt.Fprintf(&b, "**Tool: %s**\n\n", tc.Name)
+			writeCodeBlock(&b, "json", tc.Input)
+			if tc.Result != "" {
+				if tc.IsError {
+					b.WriteString("Error:\n\n")
+				} else {
+					b.WriteString("Result:\n\n")
+				}
+				writeCodeBlock(&b, "", tc.Result)
+				if tc.Omitted > 0 {
+					fmt.Fprintf(&b, "_%d more lines_\n\n", tc.Omitted)
+				}
+			}
+		}
+		if e.Error != "" {
+			fmt.Fprintf(&b, "> **Error:** %s\n\n", strings.ReplaceAll(e.Error, "\n", "\n> "))
+		}
+	}
+
+	if len(d.Changes) > 0 {
+		b.WriteString("\n## Changes\n")
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "\n### %s (+%d -%d)\n\n", c.Path, c.Additions, c.Removals)
+			writeCodeBlock(&b, "diff", c.Diff)
+		}
+	}
+
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+// writeCodeBlock writes a fenced code block, with a fence longer than any run
+// of backticks in the code.
+func writeCodeBlock(b *strings.Builder, lang, code string) {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, code, fence)
+}
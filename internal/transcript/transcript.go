@@ -0,0 +1,229 @@
+// Package transcript exports sessions, with their messages and file history,
+// as JSON, Markdown or HTML, and imports them back from JSON.
+package transcript
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/google/uuid"
+)
+
+// Version is the version of the JSON format written by [Load].
+const Version = 1
+
+// Transcript is a session with everything needed to show it or to import it
+// elsewhere.
+type Transcript struct {
+	// Version and ExportedAt are only set on the top-level transcript.
+	Version    int               `json:"version,omitempty"`
+	ExportedAt int64             `json:"exported_at,omitempty"`
+	Session    session.Session   `json:"session"`
+	Messages   []message.Message `json:"messages"`
+	Files      []File            `json:"files,omitempty"`
+	// Agents are the sessions of the sub-agents, keyed by the ID of the tool
+	// call that ran them.
+	Agents map[string]Transcript `json:"agents,omitempty"`
+}
+
+// File is a version of a file edited in the session.
+type File struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Version   int64  `json:"version"`
+	IsNew     bool   `json:"is_new,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Load reads a session, its messages, file history and sub-agent sessions.
+func Load(ctx context.Context, sessions session.Service, messages message.Service, files history.Service, sessionID string) (Transcript, error) {
+	t, err := load(ctx, sessions, messages, files, sessionID)
+	if err != nil {
+		return Transcript{}, err
+	}
+	t.Version = Version
+	t.ExportedAt = time.Now().Unix()
+	return t, nil
+}
+
+func load(ctx context.Context, sessions session.Service, messages message.Service, files history.Service, sessionID string) (Transcript, error) {
+	sess, err := sessions.Get(ctx, sessionID)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("getting session %s: %w", sessionID, err)
+	}
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("listing messages: %w", err)
+	}
+	versions, err := files.ListBySession(ctx, sessionID)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("listing files: %w", err)
+	}
+
+	t := Transcript{Session: sess, Messages: msgs}
+	for _, f := range versions {
+		t.Files = append(t.Files, File{
+			Path:      f.Path,
+			Content:   f.Content,
+			Version:   f.Version,
+			IsNew:     f.IsNew,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+	for _, msg := range msgs {
+		for _, tc := range msg.ToolCalls() {
+			agent, err := load(ctx, sessions, messages, files, sessions.CreateAgentToolSessionID(msg.ID, tc.ID))
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return Transcript{}, err
+			}
+			if t.Agents == nil {
+				t.Agents = make(map[string]Transcript)
+			}
+			t.Agents[tc.ID] = agent
+		}
+	}
+	return t, nil
+}
+
+// Import creates a new session from a transcript, with new IDs for the
+// session and its messages.
+func Import(ctx context.Context, conn *sql.DB, t Transcript) (session.Session, error) {
+	if t.Version > Version {
+		return session.Session{}, fmt.Errorf("unsupported transcript version %d, expected at most %d", t.Version, Version)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+
+	id := uuid.New().String()
+	if err := importSession(ctx, q.WithTx(tx), sessions, t, id, ""); err != nil {
+		return session.Session{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return session.Session{}, fmt.Errorf("committing transaction: %w", err)
+	}
+	return sessions.Get(ctx, id)
+}
+
+func importSession(ctx context.Context, q *db.Queries, sessions session.Service, t Transcript, id, parentID string) error {
+	var todos string
+	if len(t.Session.Todos) > 0 {
+		data, err := json.Marshal(t.Session.Todos)
+		if err != nil {
+			return err
+		}
+		todos = string(data)
+	}
+	dbSession, err := q.ImportSession(ctx, db.ImportSessionParams{
+		ID:               id,
+		ParentSessionID:  sql.NullString{String: parentID, Valid: parentID != ""},
+		Title:            t.Session.Title,
+		PromptTokens:     t.Session.PromptTokens,
+		CompletionTokens: t.Session.CompletionTokens,
+		Cost:             t.Session.Cost,
+		Todos:            sql.NullString{String: todos, Valid: todos != ""},
+		CreatedAt:        t.Session.CreatedAt,
+		UpdatedAt:        t.Session.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("creating session: %w", err)
+	}
+
+	messageIDs := make(map[string]string, len(t.Messages))
+	// The messages that ran each tool call, to find the sub-agent sessions.
+	toolCallMessages := make(map[string]string)
+	for _, msg := range t.Messages {
+		parts, err := message.MarshalParts(msg.Parts)
+		if err != nil {
+			return err
+		}
+		finishedAt := sql.NullInt64{}
+		if f := msg.FinishPart(); f != nil {
+			finishedAt = sql.NullInt64{Int64: f.Time, Valid: true}
+		}
+		isSummary := int64(0)
+		if msg.IsSummaryMessage {
+			isSummary = 1
+		}
+		newID := uuid.New().String()
+		if err := q.ImportMessage(ctx, db.ImportMessageParams{
+			ID:               newID,
+			SessionID:        id,
+			Role:             string(msg.Role),
+			Parts:            string(parts),
+			Model:            sql.NullString{String: msg.Model, Valid: msg.Model != ""},
+			Provider:         sql.NullString{String: msg.Provider, Valid: msg.Provider != ""},
+			IsSummaryMessage: isSummary,
+			Retries:          int64(msg.Retries),
+			FinishedAt:       finishedAt,
+			CreatedAt:        msg.CreatedAt,
+			UpdatedAt:        msg.UpdatedAt,
+		}); err != nil {
+			return fmt.Errorf("creating message: %w", err)
+		}
+		messageIDs[msg.ID] = newID
+		for _, tc := range msg.ToolCalls() {
+			toolCallMessages[tc.ID] = newID
+		}
+	}
+
+	if summaryID, ok := messageIDs[t.Session.SummaryMessageID]; ok {
+		if _, err := q.UpdateSession(ctx, db.UpdateSessionParams{
+			ID:               id,
+			Title:            dbSession.Title,
+			PromptTokens:     dbSession.PromptTokens,
+			CompletionTokens: dbSession.CompletionTokens,
+			SummaryMessageID: sql.NullString{String: summaryID, Valid: true},
+			Cost:             dbSession.Cost,
+			Todos:            dbSession.Todos,
+		}); err != nil {
+			return fmt.Errorf("updating session: %w", err)
+		}
+	}
+
+	for _, f := range t.Files {
+		isNew := int64(0)
+		if f.IsNew {
+			isNew = 1
+		}
+		if err := q.ImportFile(ctx, db.ImportFileParams{
+			ID:        uuid.New().String(),
+			SessionID: id,
+			Path:      f.Path,
+			Content:   f.Content,
+			Version:   f.Version,
+			IsNew:     isNew,
+			CreatedAt: f.CreatedAt,
+			UpdatedAt: f.CreatedAt,
+		}); err != nil {
+			return fmt.Errorf("creating file: %w", err)
+		}
+	}
+
+	for toolCallID, agent := range t.Agents {
+		messageID, ok := toolCallMessages[toolCallID]
+		if !ok {
+			continue
+		}
+		if err := importSession(ctx, q, sessions, agent, sessions.CreateAgentToolSessionID(messageID, toolCallID), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package transcript
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/brush/internal/db"
+	"github.com/charmbracelet/brush/internal/history"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+type services struct {
+	conn     *sql.DB
+	sessions session.Service
+	messages message.Service
+	files    history.Service
+}
+
+func newServices(t *testing.T) services {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	return services{
+		conn:     conn,
+		sessions: session.NewService(q, conn),
+		messages: message.NewService(q),
+		files:    history.NewService(q, conn),
+	}
+}
+
+func (s services) load(t *testing.T, sessionID string) Transcript {
+	t.Helper()
+	tr, err := Load(t.Context(), s.sessions, s.messages, s.files, sessionID)
+	require.NoError(t, err)
+	return tr
+}
+
+// newSession creates a session with a prompt, an edit and a sub-agent.
+func newSession(t *testing.T, s services) session.Session {
+	t.Helper()
+	ctx := t.Context()
+
+	sess, err := s.sessions.Create(ctx, "Fix the migration")
+	require.NoError(t, err)
+	_, err = s.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "Fix the migration"}},
+	})
+	require.NoError(t, err)
+	_, err = s.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Model: "big",
+		Parts: []message.ContentPart{
+			message.ReasoningContent{Thinking: "Look at the SQL"},
+			message.TextContent{Text: "Editing it."},
+			message.ToolCall{ID: "edit", Name: "edit", Input: `{"file_path":"main.sql"}`, Finished: true},
+			message.ToolCall{ID: "agent", Name: "agent", Input: `{"prompt":"find it"}`, Finished: true},
+			message.Finish{Reason: message.FinishReasonToolUse},
+		},
+	})
+	require.NoError(t, err)
+	_, err = s.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role: message.Tool,
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "edit", Name: "edit", Content: "Edited main.sql"},
+			message.ToolResult{ToolCallID: "agent", Name: "agent", Content: "It's in main.sql", IsError: true},
+		},
+	})
+	require.NoError(t, err)
+
+	msgs, err := s.messages.List(ctx, sess.ID)
+	require.NoError(t, err)
+	agent, err := s.sessions.CreateTaskSession(ctx, s.sessions.CreateAgentToolSessionID(msgs[1].ID, "agent"), sess.ID, "find it")
+	require.NoError(t, err)
+	_, err = s.messages.Create(ctx, agent.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "find it"}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.files.Create(ctx, sess.ID, "main.sql", "SELECT 1;\n")
+	require.NoError(t, err)
+	_, err = s.files.CreateVersion(ctx, sess.ID, "main.sql", "SELECT 2;\n")
+	require.NoError(t, err)
+
+	sess.PromptTokens = 100
+	sess.CompletionTokens = 20
+	sess.Cost = 0.25
+	sess, err = s.sessions.Save(ctx, sess)
+	require.NoError(t, err)
+	return sess
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	src := newServices(t)
+	exported := src.load(t, newSession(t, src).ID)
+	require.Equal(t, Version, exported.Version)
+	require.Len(t, exported.Messages, 3)
+	require.Len(t, exported.Files, 2)
+	require.Len(t, exported.Agents["agent"].Messages, 1)
+
+	data, err := json.Marshal(exported)
+	require.NoError(t, err)
+	var decoded Transcript
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	dst := newServices(t)
+	imported, err := Import(t.Context(), dst.conn, decoded)
+	require.NoError(t, err)
+	require.NotEqual(t, exported.Session.ID, imported.ID)
+	require.Equal(t, exported.Session.Title, imported.Title)
+	require.Equal(t, exported.Session.Cost, imported.Cost)
+	require.Equal(t, exported.Session.CreatedAt, imported.CreatedAt)
+	require.EqualValues(t, 3, imported.MessageCount)
+
+	reloaded := dst.load(t, imported.ID)
+	require.Equal(t, exported.Files, reloaded.Files)
+	require.Len(t, reloaded.Messages, len(exported.Messages))
+	for i, msg := range reloaded.Messages {
+		require.Equal(t, exported.Messages[i].Role, msg.Role)
+		require.Equal(t, exported.Messages[i].Parts, msg.Parts)
+		require.Equal(t, exported.Messages[i].CreatedAt, msg.CreatedAt)
+	}
+	// The sub-agent session is found from the new message ID.
+	require.Len(t, reloaded.Agents["agent"].Messages, 1)
+	require.Equal(t, "find it", reloaded.Agents["agent"].Messages[0].Content().Text)
+
+	decoded.Version = Version + 1
+	_, err = Import(t.Context(), dst.conn, decoded)
+	require.Error(t, err)
+}
+
+func TestMarkdown(t *testing.T) {
+	t.Parallel()
+
+	s := newServices(t)
+	tr := s.load(t, newSession(t, s).ID)
+
+	var b strings.Builder
+	require.NoError(t, Markdown(&b, tr))
+	md := b.String()
+	for _, want := range []string{
+		"# Fix the migration\n",
+		"- Models: big\n",
+		"- Tokens: 100 prompt, 20 completion\n",
+		"- Cost: $0.2500\n",
+		"## User\n\nFix the migration\n",
+		"## Assistant · big\n",
+		"<summary>Reasoning</summary>\n\nLook at the SQL\n",
+		"**Tool: edit**\n\n```json\n{\n  \"file_path\": \"main.sql\"\n}\n```\n\nResult:\n\n```\nEdited main.sql\n```\n",
+		"Error:\n\n```\nIt's in main.sql\n```\n",
+		"### main.sql (+1 -1)\n\n```diff\n",
+		"-SELECT 1;\n+SELECT 2;\n",
+	} {
+		require.Contains(t, md, want)
+	}
+}
+
+func TestWriteCodeBlock(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	writeCodeBlock(&b, "md", "```go\n```")
+	require.Equal(t, "````md\n```go\n```\n````\n\n", b.String())
+}
@@ -49,6 +49,7 @@ type MessageListCmp interface {
 	layout.Help
 
 	SetSession(session.Session) tea.Cmd
+	Reload() tea.Cmd
 	GoToBottom() tea.Cmd
 	GetSelectedText() string
 	CopySelectedText(bool) tea.Cmd
@@ -682,6 +683,14 @@ func (m *messageListCmp) Bindings() []key.Binding {
 	return m.defaultListKeyMap.KeyBindings()
 }
 
+// Reload reloads the messages of the current session, e.g. after some were
+// removed by a rewind.
+func (m *messageListCmp) Reload() tea.Cmd {
+	sess := m.session
+	m.session = session.Session{}
+	return m.SetSession(sess)
+}
+
 func (m *messageListCmp) GoToBottom() tea.Cmd {
 	return m.listCmp.GoToBottom()
 }
@@ -29,6 +29,34 @@ import (
 // CopyKey is the key binding for copying message content to the clipboard.
 var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
 
+// RewindKey is the key binding for restoring the files edited after a user
+// message.
+var RewindKey = key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rewind to here"))
+
+// RewindChatKey is the key binding for restoring the files edited after a user
+// message and removing it and every message after it.
+var RewindChatKey = key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "rewind and edit"))
+
+// RewindMsg asks to rewind a session to one of its user messages.
+type RewindMsg struct {
+	SessionID string
+	MessageID string
+	// Truncate also removes the message and every message after it.
+	Truncate bool
+	// Confirmed is set once the user confirmed the rewind.
+	Confirmed bool
+}
+
+// ForkKey is the key binding for forking the session up to a message.
+var ForkKey = key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "fork from here"))
+
+// ForkMsg asks to fork a session into a new one, up to and including one of
+// its messages, or entirely if MessageID is empty.
+type ForkMsg struct {
+	SessionID string
+	MessageID string
+}
+
 // ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
 var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
 
@@ -114,6 +142,19 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				util.ReportInfo("Message copied to clipboard"),
 			)
 		}
+		if m.message.Role == message.User && key.Matches(msg, RewindKey, RewindChatKey) {
+			return m, util.CmdHandler(RewindMsg{
+				SessionID: m.message.SessionID,
+				MessageID: m.message.ID,
+				Truncate:  key.Matches(msg, RewindChatKey),
+			})
+		}
+		if key.Matches(msg, ForkKey) {
+			return m, util.CmdHandler(ForkMsg{
+				SessionID: m.message.SessionID,
+				MessageID: m.message.ID,
+			})
+		}
 	}
 	return m, nil
 }
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"slices"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
@@ -25,6 +27,7 @@ import (
 	"github.com/charmbracelet/brush/internal/tui/styles"
 	"github.com/charmbracelet/brush/internal/tui/util"
 	"github.com/charmbracelet/brush/internal/uicmd"
+	"github.com/pkg/browser"
 )
 
 const (
@@ -432,6 +435,19 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 
+	for _, m := range config.Get().MCP.Sorted() {
+		if mcp.NeedsLogin(m.Name) {
+			commands = append(commands, Command{
+				ID:          "mcp_login_" + m.Name,
+				Title:       "Log in to " + m.Name + " MCP",
+				Description: "Authorize with the MCP server in the browser",
+				Handler: func(cmd Command) tea.Cmd {
+					return tea.Batch(util.ReportInfo("Log in to "+m.Name+" in your browser"), mcpLogin(m.Name))
+				},
+			})
+		}
+	}
+
 	return append(commands, []Command{
 		{
 			ID:          "toggle_yolo",
@@ -476,6 +492,19 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 	}...)
 }
 
+// mcpLogin logs in to the MCP server in the browser. The agent picks up its
+// tools once it's connected.
+func mcpLogin(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := mcp.Login(ctx, config.Get(), name, browser.OpenURL); err != nil {
+			return util.ReportError(fmt.Errorf("failed to log in to %s: %w", name, err))()
+		}
+		return util.ReportInfo("Logged in to " + name)()
+	}
+}
+
 func (c *commandDialogCmp) ID() dialogs.DialogID {
 	return CommandsDialogID
 }
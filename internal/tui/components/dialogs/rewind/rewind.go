@@ -0,0 +1,139 @@
+package rewind
+
+import (
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/brush/internal/tui/components/chat/messages"
+	"github.com/charmbracelet/brush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/brush/internal/tui/styles"
+	"github.com/charmbracelet/brush/internal/tui/util"
+)
+
+const RewindDialogID dialogs.DialogID = "rewind"
+
+// RewindDialog represents a confirmation dialog for rewinding a session to
+// one of its user messages.
+type RewindDialog interface {
+	dialogs.DialogModel
+}
+
+type rewindDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	msg        messages.RewindMsg
+	selectedNo bool // true if "No" button is selected
+	keymap     KeyMap
+}
+
+// NewRewindDialog creates a new dialog confirming the given rewind.
+func NewRewindDialog(msg messages.RewindMsg) RewindDialog {
+	return &rewindDialogCmp{
+		msg:        msg,
+		selectedNo: true, // Default to "No" for safety
+		keymap:     DefaultKeymap(),
+	}
+}
+
+func (r *rewindDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles keyboard input for the rewind dialog.
+func (r *rewindDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keymap.LeftRight, r.keymap.Tab):
+			r.selectedNo = !r.selectedNo
+			return r, nil
+		case key.Matches(msg, r.keymap.EnterSpace):
+			if !r.selectedNo {
+				return r, r.confirm()
+			}
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, r.keymap.Yes):
+			return r, r.confirm()
+		case key.Matches(msg, r.keymap.No, r.keymap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return r, nil
+}
+
+func (r *rewindDialogCmp) confirm() tea.Cmd {
+	confirmed := r.msg
+	confirmed.Confirmed = true
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(confirmed),
+	)
+}
+
+func (r *rewindDialogCmp) question() string {
+	if r.msg.Truncate {
+		return "Restore the files edited after this message\nand remove it and every message after it?"
+	}
+	return "Restore the files edited after this message?"
+}
+
+// View renders the rewind dialog with Yes/No buttons.
+func (r *rewindDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	yesStyle := t.S().Text
+	noStyle := yesStyle
+
+	if r.selectedNo {
+		noStyle = noStyle.Foreground(t.White).Background(t.Secondary)
+		yesStyle = yesStyle.Background(t.BgSubtle)
+	} else {
+		yesStyle = yesStyle.Foreground(t.White).Background(t.Secondary)
+		noStyle = noStyle.Background(t.BgSubtle)
+	}
+
+	const horizontalPadding = 3
+	yesButton := yesStyle.PaddingLeft(horizontalPadding).Underline(true).Render("Y") +
+		yesStyle.PaddingRight(horizontalPadding).Render("es")
+	noButton := noStyle.PaddingLeft(horizontalPadding).Underline(true).Render("N") +
+		noStyle.PaddingRight(horizontalPadding).Render("o")
+
+	question := r.question()
+	buttons := baseStyle.Width(lipgloss.Width(question)).Align(lipgloss.Right).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, yesButton, "  ", noButton),
+	)
+
+	content := baseStyle.Render(
+		lipgloss.JoinVertical(
+			lipgloss.Center,
+			question,
+			"",
+			buttons,
+		),
+	)
+
+	rewindDialogStyle := baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+
+	return rewindDialogStyle.Render(content)
+}
+
+func (r *rewindDialogCmp) Position() (int, int) {
+	question := r.question()
+	row := r.wHeight / 2
+	row -= (lipgloss.Height(question) + 6) / 2
+	col := r.wWidth / 2
+	col -= (lipgloss.Width(question) + 4) / 2
+
+	return row, col
+}
+
+func (r *rewindDialogCmp) ID() dialogs.DialogID {
+	return RewindDialogID
+}
@@ -1,6 +1,8 @@
 package sessions
 
 import (
+	"strings"
+
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
@@ -8,6 +10,7 @@ import (
 	"github.com/charmbracelet/brush/internal/event"
 	"github.com/charmbracelet/brush/internal/session"
 	"github.com/charmbracelet/brush/internal/tui/components/chat"
+	"github.com/charmbracelet/brush/internal/tui/components/chat/messages"
 	"github.com/charmbracelet/brush/internal/tui/components/core"
 	"github.com/charmbracelet/brush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/brush/internal/tui/exp/list"
@@ -45,10 +48,16 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 	listKeyMap.DownOneItem = keyMap.Next
 	listKeyMap.UpOneItem = keyMap.Previous
 
+	// Forks are listed right after the session they were forked from.
+	sessions, depths := session.Lineage(sessions)
 	items := make([]list.CompletionItem[session.Session], len(sessions))
 	if len(sessions) > 0 {
 		for i, session := range sessions {
-			items[i] = list.NewCompletionItem(session.Title, session, list.WithCompletionID(session.ID))
+			title := session.Title
+			if depths[i] > 0 {
+				title = strings.Repeat("  ", depths[i]-1) + "└ " + title
+			}
+			items[i] = list.NewCompletionItem(title, session, list.WithCompletionID(session.ID))
 		}
 	}
 
@@ -108,6 +117,15 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 					),
 				)
 			}
+		case key.Matches(msg, s.keyMap.Fork):
+			selectedItem := s.sessionsList.SelectedItem()
+			if selectedItem != nil {
+				selected := *selectedItem
+				return s, tea.Sequence(
+					util.CmdHandler(dialogs.CloseDialogMsg{}),
+					util.CmdHandler(messages.ForkMsg{SessionID: selected.Value().ID}),
+				)
+			}
 		case key.Matches(msg, s.keyMap.Close):
 			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
 		default:
@@ -35,6 +35,7 @@ import (
 	"github.com/charmbracelet/brush/internal/tui/components/dialogs/hyper"
 	"github.com/charmbracelet/brush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/brush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/brush/internal/tui/components/dialogs/rewind"
 	"github.com/charmbracelet/brush/internal/tui/page"
 	"github.com/charmbracelet/brush/internal/tui/styles"
 	"github.com/charmbracelet/brush/internal/tui/util"
@@ -268,6 +269,17 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, cmd
 	case chat.SendMsg:
 		return p, p.sendMessage(msg.Text, msg.Attachments)
+	case messages.RewindMsg:
+		if !msg.Confirmed {
+			return p, util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: rewind.NewRewindDialog(msg),
+			})
+		}
+		return p, p.rewind(msg)
+	case rewoundMsg:
+		return p, p.handleRewound(msg)
+	case messages.ForkMsg:
+		return p, p.fork(msg)
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
@@ -870,6 +882,49 @@ func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// rewoundMsg is sent once a session was rewound.
+type rewoundMsg struct {
+	result   app.RewindResult
+	truncate bool
+}
+
+func (p *chatPage) rewind(msg messages.RewindMsg) tea.Cmd {
+	return func() tea.Msg {
+		result, err := p.app.Rewind(context.Background(), msg.SessionID, msg.MessageID, msg.Truncate)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return rewoundMsg{result: result, truncate: msg.Truncate}
+	}
+}
+
+func (p *chatPage) handleRewound(msg rewoundMsg) tea.Cmd {
+	files := len(msg.result.Restored) + len(msg.result.Removed)
+	cmds := []tea.Cmd{util.ReportInfo(fmt.Sprintf("Rewound %d file(s)", files))}
+	if msg.truncate {
+		// Put the prompt back in the editor so it can be edited and resent.
+		u, cmd := p.editor.Update(editor.OpenEditorMsg{Text: msg.result.Prompt})
+		p.editor = u.(editor.Editor)
+		cmds = append(cmds, cmd, p.chat.Reload())
+		if p.focusedPane == PanelTypeChat {
+			cmds = append(cmds, p.changeFocus())
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// fork copies the session up to the message into a new session and switches
+// to it.
+func (p *chatPage) fork(msg messages.ForkMsg) tea.Cmd {
+	return func() tea.Msg {
+		fork, err := p.app.Sessions.Fork(context.Background(), msg.SessionID, msg.MessageID)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return chat.SessionSelectedMsg(fork)
+	}
+}
+
 func (p *chatPage) changeFocus() tea.Cmd {
 	if p.session.ID == "" {
 		return nil
@@ -1252,6 +1307,7 @@ func (p *chatPage) Help() help.KeyMap {
 					key.WithHelp("↑↓", "scroll"),
 				),
 				messages.CopyKey,
+				messages.RewindKey,
 			)
 			fullList = append(fullList,
 				[]key.Binding{
@@ -1293,6 +1349,9 @@ func (p *chatPage) Help() help.KeyMap {
 				[]key.Binding{
 					messages.CopyKey,
 					messages.ClearSelectionKey,
+					messages.RewindKey,
+					messages.RewindChatKey,
+					messages.ForkKey,
 				},
 			)
 		case PanelTypeEditor:
@@ -12,6 +12,7 @@ import (
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/brush/internal/agent"
 	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/app"
 	"github.com/charmbracelet/brush/internal/config"
@@ -228,6 +229,13 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case page.PageChangeMsg:
 		return a, a.moveToPage(msg.ID)
 
+	case pubsub.Event[agent.RetryEvent]:
+		if msg.Payload.SessionID == a.selectedSessionID {
+			retry := msg.Payload
+			return a, util.CmdHandler(util.NewRetryMsg(retry.Delay, retry.Attempt, retry.MaxAttempts))
+		}
+		return a, nil
+
 	// Status Messages
 	case util.InfoMsg, util.ClearStatusMsg:
 		s, statusCmd := a.status.Update(msg)
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"time"
+
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/brush/internal/uiutil"
 )
@@ -39,6 +41,10 @@ func ReportWarn(warn string) tea.Cmd {
 	return uiutil.ReportWarn(warn)
 }
 
+func NewRetryMsg(delay time.Duration, attempt, maxAttempts int) InfoMsg {
+	return uiutil.NewRetryMsg(delay, attempt, maxAttempts)
+}
+
 type (
 	InfoMsg        = uiutil.InfoMsg
 	ClearStatusMsg = uiutil.ClearStatusMsg
@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"cmp"
 	"encoding/json"
 	"strings"
 
@@ -116,7 +117,7 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 	}
 
 	// Build the task tag and prompt.
-	taskTag := sty.Tool.AgentTaskTag.Render("Task")
+	taskTag := sty.Tool.AgentTaskTag.Render(cmp.Or(params.Agent, "Task"))
 	taskTagWidth := lipgloss.Width(taskTag)
 
 	// Calculate remaining width for prompt.
@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/brush/internal/agent/tools"
+	"github.com/charmbracelet/brush/internal/fsext"
+	"github.com/charmbracelet/brush/internal/message"
+	"github.com/charmbracelet/brush/internal/ui/styles"
+)
+
+// lspToolTitles are the titles of the LSP tools rendered by
+// [LSPToolRenderContext].
+var lspToolTitles = map[string]string{
+	tools.DefinitionToolName:       "Go to Definition",
+	tools.ImplementationToolName:   "Find Implementations",
+	tools.HoverToolName:            "Hover",
+	tools.DocumentSymbolsToolName:  "Document Symbols",
+	tools.WorkspaceSymbolsToolName: "Workspace Symbols",
+	tools.CallHierarchyToolName:    "Call Hierarchy",
+	tools.RenameToolName:           "Rename",
+	tools.CodeActionToolName:       "Code Action",
+}
+
+// LSPToolMessageItem is a message item that represents a call of one of the
+// LSP navigation tools.
+type LSPToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LSPToolMessageItem)(nil)
+
+// NewLSPToolMessageItem creates a new [LSPToolMessageItem].
+func NewLSPToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LSPToolRenderContext{}, canceled)
+}
+
+// LSPToolRenderContext renders LSP navigation tool messages.
+type LSPToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *LSPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	title := lspToolTitles[opts.ToolCall.Name]
+	if opts.IsPending() {
+		return pendingTool(sty, title, opts.Anim)
+	}
+
+	var params struct {
+		FilePath  string `json:"file_path"`
+		Line      int    `json:"line"`
+		Symbol    string `json:"symbol"`
+		NewName   string `json:"new_name"`
+		Title     string `json:"title"`
+		Direction string `json:"direction"`
+		Query     string `json:"query"`
+	}
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	var toolParams []string
+	switch {
+	case params.Query != "":
+		toolParams = append(toolParams, params.Query)
+	case params.Symbol != "":
+		toolParams = append(toolParams, params.Symbol)
+		if params.NewName != "" {
+			toolParams = append(toolParams, "to", params.NewName)
+		}
+		if params.Direction != "" {
+			toolParams = append(toolParams, "direction", params.Direction)
+		}
+		toolParams = append(toolParams, "file", fmt.Sprintf("%s:%d", fsext.PrettyPath(params.FilePath), params.Line))
+	case params.Title != "":
+		toolParams = append(toolParams, params.Title, "file", fmt.Sprintf("%s:%d", fsext.PrettyPath(params.FilePath), params.Line))
+	case params.FilePath != "" && params.Line > 0:
+		toolParams = append(toolParams, fmt.Sprintf("%s:%d", fsext.PrettyPath(params.FilePath), params.Line))
+	case params.FilePath != "":
+		toolParams = append(toolParams, fsext.PrettyPath(params.FilePath))
+	}
+
+	header := toolHeader(sty, opts.Status, title, cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
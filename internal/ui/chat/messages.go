@@ -72,6 +72,13 @@ type SendMsg struct {
 	Attachments []message.Attachment
 }
 
+// ForkMsg asks to fork a session into a new one, up to and including one of
+// its messages.
+type ForkMsg struct {
+	SessionID string
+	MessageID string
+}
+
 type highlightableMessageItem struct {
 	startLine   int
 	startCol    int
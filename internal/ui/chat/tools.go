@@ -251,6 +251,10 @@ func NewToolMessageItem(
 		item = NewReferencesToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSPRestartToolName:
 		item = NewLSPRestartToolMessageItem(sty, toolCall, result, canceled)
+	case tools.DefinitionToolName, tools.ImplementationToolName, tools.HoverToolName,
+		tools.DocumentSymbolsToolName, tools.WorkspaceSymbolsToolName, tools.CallHierarchyToolName,
+		tools.RenameToolName, tools.CodeActionToolName:
+		item = NewLSPToolMessageItem(sty, toolCall, result, canceled)
 	default:
 		if strings.HasPrefix(toolCall.Name, "mcp_") {
 			item = NewMCPToolMessageItem(sty, toolCall, result, canceled)
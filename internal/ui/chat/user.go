@@ -94,11 +94,29 @@ func (m *UserMessageItem) renderAttachments(width int) string {
 	return m.attachments.Render(attachments, false, width)
 }
 
+// RewindMsg asks to rewind a session to one of its user messages, which the
+// user confirms first.
+type RewindMsg struct {
+	SessionID string
+	MessageID string
+	// Truncate also removes the message and every message after it.
+	Truncate bool
+}
+
 // HandleKeyEvent implements KeyEventHandler.
 func (m *UserMessageItem) HandleKeyEvent(key tea.KeyMsg) (bool, tea.Cmd) {
-	if k := key.String(); k == "c" || k == "y" {
+	switch k := key.String(); k {
+	case "c", "y":
 		text := m.message.Content().Text
 		return true, common.CopyToClipboard(text, "Message copied to clipboard")
+	case "r", "R":
+		return true, func() tea.Msg {
+			return RewindMsg{SessionID: m.message.SessionID, MessageID: m.message.ID, Truncate: k == "R"}
+		}
+	case "F":
+		return true, func() tea.Msg {
+			return ForkMsg{SessionID: m.message.SessionID, MessageID: m.message.ID}
+		}
 	}
 	return false, nil
 }
@@ -1,12 +1,14 @@
 package completions
 
 import (
+	"cmp"
 	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/fsext"
 	"github.com/charmbracelet/brush/internal/ui/list"
 	"github.com/charmbracelet/x/ansi"
@@ -31,7 +33,8 @@ type ClosedMsg struct{}
 
 // FilesLoadedMsg is sent when files have been loaded for completions.
 type FilesLoadedMsg struct {
-	Files []string
+	Files     []string
+	Resources []ResourceCompletionValue
 }
 
 // Completions represents the completions popup component.
@@ -92,18 +95,34 @@ func (c *Completions) KeyMap() KeyMap {
 	return c.keyMap
 }
 
-// OpenWithFiles opens the completions with file items from the filesystem.
+// OpenWithFiles opens the completions with file items from the filesystem
+// and the resources of the MCP servers.
 func (c *Completions) OpenWithFiles(depth, limit int) tea.Cmd {
 	return func() tea.Msg {
 		files, _, _ := fsext.ListDirectory(".", nil, depth, limit)
 		slices.Sort(files)
-		return FilesLoadedMsg{Files: files}
+		var resources []ResourceCompletionValue
+		for name, rs := range mcp.Resources() {
+			for _, r := range rs {
+				resources = append(resources, ResourceCompletionValue{
+					MCPName:  name,
+					URI:      r.URI,
+					Name:     cmp.Or(r.Title, r.Name),
+					MIMEType: r.MIMEType,
+				})
+			}
+		}
+		slices.SortFunc(resources, func(a, b ResourceCompletionValue) int {
+			return cmp.Or(cmp.Compare(a.MCPName, b.MCPName), cmp.Compare(a.URI, b.URI))
+		})
+		return FilesLoadedMsg{Files: files, Resources: resources}
 	}
 }
 
-// SetFiles sets the file items on the completions popup.
-func (c *Completions) SetFiles(files []string) {
-	items := make([]list.FilterableItem, 0, len(files))
+// SetFiles sets the file and MCP resource items on the completions popup.
+func (c *Completions) SetFiles(files []string, resources []ResourceCompletionValue) {
+	items := make([]list.FilterableItem, 0, len(files)+len(resources))
+	texts := make([]string, 0, len(files)+len(resources))
 	for _, file := range files {
 		file = strings.TrimPrefix(file, "./")
 		item := NewCompletionItem(
@@ -114,6 +133,19 @@ func (c *Completions) SetFiles(files []string) {
 			c.matchStyle,
 		)
 		items = append(items, item)
+		texts = append(texts, file)
+	}
+	for _, resource := range resources {
+		text := resource.MCPName + ":" + resource.URI
+		item := NewCompletionItem(
+			text,
+			resource,
+			c.normalStyle,
+			c.focusedStyle,
+			c.matchStyle,
+		)
+		items = append(items, item)
+		texts = append(texts, text)
 	}
 
 	c.open = true
@@ -132,8 +164,8 @@ func (c *Completions) SetFiles(files []string) {
 	start, end := c.list.VisibleItemIndices()
 	width := 0
 	if end != 0 {
-		for _, file := range files[start : end+1] {
-			width = max(width, ansi.StringWidth(file))
+		for _, text := range texts[start : end+1] {
+			width = max(width, ansi.StringWidth(text))
 		}
 	}
 	c.width = ordered.Clamp(width+2, int(minWidth), int(maxWidth))
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/brush/internal/commands"
 	"github.com/charmbracelet/brush/internal/config"
+	"github.com/charmbracelet/brush/internal/git"
 	"github.com/charmbracelet/brush/internal/message"
 	"github.com/charmbracelet/brush/internal/oauth"
 	"github.com/charmbracelet/brush/internal/permission"
@@ -32,6 +33,22 @@ type ActionOpenDialog struct {
 // ActionSelectSession is a message indicating a session has been selected.
 type ActionSelectSession struct {
 	Session session.Session
+	// MessageID is the message to jump to, if any.
+	MessageID string
+}
+
+// ActionRewind is a message to rewind a session to one of its user
+// messages, once confirmed.
+type ActionRewind struct {
+	SessionID string
+	MessageID string
+	// Truncate also removes the message and every message after it.
+	Truncate bool
+}
+
+// ActionForkSession is a message to fork a session into a new one.
+type ActionForkSession struct {
+	SessionID string
 }
 
 // ActionSelectModel is a message indicating a model has been selected.
@@ -77,6 +94,25 @@ type (
 		Arguments   []commands.Argument
 		Args        map[string]string // Actual argument values
 	}
+	// ActionMCPLogin is a message to log in to an MCP server that requires
+	// OAuth.
+	ActionMCPLogin struct {
+		Name string
+	}
+	// ActionElicitationResponse is a message to answer an elicitation
+	// request of an MCP server.
+	ActionElicitationResponse struct {
+		RequestID string
+		Action    string
+		Content   map[string]any
+	}
+	// ActionCommit is a message to commit the changes of files, relative to
+	// the work tree of a repository, with the reviewed message.
+	ActionCommit struct {
+		Repo    *git.Repo
+		Files   []string
+		Message string
+	}
 )
 
 // Messages for API key input dialog.
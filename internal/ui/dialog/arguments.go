@@ -246,6 +246,9 @@ func (a *Arguments) HandleMsg(msg tea.Msg) Action {
 // Cursor returns the cursor position relative to the dialog.
 // we pass the description height to offset the cursor correctly.
 func (a *Arguments) Cursor(descriptionHeight int) *tea.Cursor {
+	if len(a.inputs) == 0 {
+		return nil
+	}
 	cursor := InputCursor(a.com.Styles, a.inputs[a.focused].Cursor())
 	if cursor == nil {
 		return nil
@@ -11,6 +11,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/brush/internal/agent/hyper"
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/commands"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/ui/common"
@@ -442,8 +443,17 @@ func (c *Commands) defaultCommands() []*CommandItem {
 		commands = append(commands, NewCommandItem(c.com.Styles, "open_external_editor", "Open External Editor", "ctrl+o", ActionExternalEditor{}))
 	}
 
+	for _, m := range cfg.MCP.Sorted() {
+		if mcp.NeedsLogin(m.Name) {
+			commands = append(commands, NewCommandItem(c.com.Styles, "mcp_login_"+m.Name, "Log in to "+m.Name+" MCP", "", ActionMCPLogin{Name: m.Name}))
+		}
+	}
+
 	return append(commands,
 		NewCommandItem(c.com.Styles, "toggle_yolo", "Toggle Yolo Mode", "", ActionToggleYoloMode{}),
+		NewCommandItem(c.com.Styles, "permission_grants", "Permission Grants", "", ActionOpenDialog{PermissionGrantsID}),
+		NewCommandItem(c.com.Styles, "jobs", "Background Jobs", "", ActionOpenDialog{JobsID}),
+		NewCommandItem(c.com.Styles, "commit", "Commit Session Changes", "", ActionOpenDialog{CommitID}),
 		NewCommandItem(c.com.Styles, "toggle_help", "Toggle Help", "ctrl+g", ActionToggleHelp{}),
 		NewCommandItem(c.com.Styles, "init", "Initialize Project", "", ActionInitializeProject{}),
 		NewCommandItem(c.com.Styles, "quit", "Quit", "ctrl+c", tea.QuitMsg{}),
@@ -0,0 +1,280 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/spinner"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/brush/internal/git"
+	"github.com/charmbracelet/brush/internal/ui/common"
+	"github.com/charmbracelet/brush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// CommitID is the identifier for the commit dialog.
+const CommitID = "commit"
+
+const (
+	// commitMaxFiles is the number of files listed in the dialog.
+	commitMaxFiles = 8
+	// commitMessageHeight is the height of the commit message editor.
+	commitMessageHeight = 8
+)
+
+// commitDraftedMsg carries the files to commit and the drafted message.
+type commitDraftedMsg struct {
+	dialog  *Commit
+	repo    *git.Repo
+	files   []string
+	staged  []string
+	message string
+	err     error
+}
+
+// Commit is a dialog that lets the user review and edit a commit message
+// drafted for the files modified in a session, and commits only those files.
+type Commit struct {
+	com       *common.Common
+	help      help.Model
+	spinner   spinner.Model
+	message   textarea.Model
+	sessionID string
+	intent    string
+
+	loading bool
+	repo    *git.Repo
+	files   []string
+	// staged are the other files the user had staged, which are left out
+	// of the commit.
+	staged []string
+	err    error
+
+	keyMap struct {
+		Commit key.Binding
+		Close  key.Binding
+	}
+}
+
+var (
+	_ Dialog        = (*Commit)(nil)
+	_ LoadingDialog = (*Commit)(nil)
+)
+
+// NewCommit creates a new commit dialog for the session. The intent, like
+// the session title, helps the model explain why the changes were made.
+func NewCommit(com *common.Common, sessionID, intent string) *Commit {
+	c := &Commit{com: com, sessionID: sessionID, intent: intent}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	c.help = help
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = com.Styles.Dialog.Spinner
+	c.spinner = s
+
+	ta := textarea.New()
+	ta.SetStyles(com.Styles.TextArea)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetHeight(commitMessageHeight)
+	ta.Focus()
+	c.message = ta
+
+	c.keyMap.Commit = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "commit"),
+	)
+	c.keyMap.Close = CloseKey
+
+	return c
+}
+
+// ID implements [Dialog].
+func (c *Commit) ID() string {
+	return CommitID
+}
+
+// Draft returns a command that drafts a commit message for the files
+// modified in the session, without staging them.
+func (c *Commit) Draft() tea.Cmd {
+	return func() tea.Msg {
+		msg := commitDraftedMsg{dialog: c}
+		msg.err = c.draft(context.TODO(), &msg)
+		return msg
+	}
+}
+
+func (c *Commit) draft(ctx context.Context, msg *commitDraftedMsg) error {
+	repo, err := git.Open(ctx, c.com.Config().WorkingDir())
+	if err != nil {
+		return err
+	}
+	sessionFiles, err := c.com.App.History.ListLatestSessionFiles(ctx, c.sessionID)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, file := range sessionFiles {
+		rel, err := filepath.Rel(repo.WorkTree, file.Path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		paths = append(paths, file.Path)
+	}
+	files, diff, err := repo.Changes(ctx, paths...)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no changes to commit")
+	}
+	staged, err := repo.StagedFiles(ctx)
+	if err != nil {
+		return err
+	}
+	msg.repo, msg.files = repo, files
+	for _, file := range staged {
+		if !slices.Contains(files, file) {
+			msg.staged = append(msg.staged, file)
+		}
+	}
+	// If drafting fails, the user writes the message instead.
+	msg.message, _ = c.com.App.AgentCoordinator.DraftCommitMessage(ctx, diff, c.intent)
+	return nil
+}
+
+// HandleMsg implements [Dialog].
+func (c *Commit) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if c.loading {
+			var cmd tea.Cmd
+			c.spinner, cmd = c.spinner.Update(msg)
+			return ActionCmd{cmd}
+		}
+	case commitDraftedMsg:
+		if msg.dialog != c {
+			break
+		}
+		c.StopLoading()
+		c.repo, c.files, c.staged, c.err = msg.repo, msg.files, msg.staged, msg.err
+		if c.err != nil {
+			return ActionCmd{uiutil.ReportError(c.err)}
+		}
+		c.message.SetValue(msg.message)
+		c.message.MoveToBegin()
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, c.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, c.keyMap.Commit):
+			if c.repo == nil {
+				break
+			}
+			message := strings.TrimSpace(c.message.Value())
+			if message == "" {
+				return ActionCmd{uiutil.ReportWarn("The commit message is empty.")}
+			}
+			return ActionCommit{Repo: c.repo, Files: c.files, Message: message}
+		default:
+			if c.repo == nil {
+				break
+			}
+			var cmd tea.Cmd
+			c.message, cmd = c.message.Update(msg)
+			return ActionCmd{cmd}
+		}
+	case tea.PasteMsg:
+		if c.repo == nil {
+			break
+		}
+		var cmd tea.Cmd
+		c.message, cmd = c.message.Update(msg)
+		return ActionCmd{cmd}
+	}
+	return nil
+}
+
+// Draw implements [Dialog].
+func (c *Commit) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := c.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	c.message.SetWidth(innerWidth)
+	c.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Commit Session Changes"
+	rc.Gap = 1
+	switch {
+	case c.loading:
+		rc.AddPart(c.spinner.View() + t.Subtle.Render(" Drafting a message..."))
+	case c.err != nil:
+		rc.AddPart(t.Subtle.Render(ansi.Truncate(c.err.Error(), innerWidth, "…")))
+	default:
+		rc.AddPart(c.renderFiles(innerWidth))
+		rc.AddPart(c.message.View())
+	}
+	rc.Help = c.help.View(c)
+
+	view := rc.Render()
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// renderFiles renders the list of files to commit, and warns about the other
+// staged files left out.
+func (c *Commit) renderFiles(width int) string {
+	t := c.com.Styles
+	lines := []string{t.Subtle.Render(fmt.Sprintf("Files to commit (%d):", len(c.files)))}
+	for i, file := range c.files {
+		if i == commitMaxFiles {
+			lines = append(lines, t.Muted.Render(fmt.Sprintf("  … and %d more", len(c.files)-commitMaxFiles)))
+			break
+		}
+		lines = append(lines, t.Base.Render(ansi.Truncate("  "+file, width, "…")))
+	}
+	if len(c.staged) > 0 {
+		warning := fmt.Sprintf("%d other staged file(s) stay staged and out of the commit.", len(c.staged))
+		lines = append(lines, "", t.Muted.Render(ansi.Truncate(warning, width, "…")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StartLoading implements [LoadingDialog].
+func (c *Commit) StartLoading() tea.Cmd {
+	if c.loading {
+		return nil
+	}
+	c.loading = true
+	return c.spinner.Tick
+}
+
+// StopLoading implements [LoadingDialog].
+func (c *Commit) StopLoading() {
+	c.loading = false
+}
+
+// ShortHelp implements [help.KeyMap].
+func (c *Commit) ShortHelp() []key.Binding {
+	if c.repo == nil {
+		return []key.Binding{c.keyMap.Close}
+	}
+	return []key.Binding{c.keyMap.Commit, c.keyMap.Close}
+}
+
+// FullHelp implements [help.KeyMap].
+func (c *Commit) FullHelp() [][]key.Binding {
+	return [][]key.Binding{c.ShortHelp()}
+}
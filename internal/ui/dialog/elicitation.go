@@ -0,0 +1,100 @@
+package dialog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/brush/internal/commands"
+	"github.com/charmbracelet/brush/internal/ui/common"
+	"github.com/charmbracelet/brush/internal/uiutil"
+)
+
+// ElicitationID is the identifier for the elicitation dialog.
+const ElicitationID = "elicitation"
+
+// Elicitation is a form dialog for the input requested by an MCP server.
+type Elicitation struct {
+	*Arguments
+	request mcp.ElicitationRequest
+}
+
+var _ Dialog = (*Elicitation)(nil)
+
+// NewElicitation creates a new elicitation dialog.
+func NewElicitation(com *common.Common, req mcp.ElicitationRequest) *Elicitation {
+	arguments := make([]commands.Argument, len(req.Fields))
+	for i, f := range req.Fields {
+		arguments[i] = commands.Argument{
+			ID:          f.Name,
+			Title:       f.Label(),
+			Description: elicitationPlaceholder(f),
+			Required:    f.Required,
+		}
+	}
+	e := &Elicitation{
+		Arguments: NewArguments(com, req.MCPName+" needs input", req.Message, arguments, nil),
+		request:   req,
+	}
+	for i, f := range req.Fields {
+		e.inputs[i].SetValue(f.Default)
+	}
+	return e
+}
+
+// Request returns the request the dialog answers.
+func (e *Elicitation) Request() mcp.ElicitationRequest {
+	return e.request
+}
+
+// ID implements Dialog.
+func (e *Elicitation) ID() string {
+	return ElicitationID
+}
+
+// HandleMsg implements Dialog.
+func (e *Elicitation) HandleMsg(msg tea.Msg) Action {
+	keyMsg, isKey := msg.(tea.KeyPressMsg)
+	switch {
+	case isKey && key.Matches(keyMsg, e.keyMap.Close):
+		return ActionElicitationResponse{RequestID: e.request.ID, Action: mcp.ElicitationDecline}
+	case isKey && key.Matches(keyMsg, e.keyMap.Confirm) && e.focused >= len(e.inputs)-1:
+		values := make(map[string]string, len(e.inputs))
+		for i, f := range e.request.Fields {
+			values[f.Name] = e.inputs[i].Value()
+		}
+		content, err := e.request.Values(values)
+		if err != nil {
+			return ActionCmd{Cmd: uiutil.ReportWarn(err.Error())}
+		}
+		return ActionElicitationResponse{RequestID: e.request.ID, Action: mcp.ElicitationAccept, Content: content}
+	case len(e.inputs) == 0:
+		return nil
+	}
+	return e.Arguments.HandleMsg(msg)
+}
+
+// elicitationPlaceholder describes the values a field accepts.
+func elicitationPlaceholder(f mcp.ElicitationField) string {
+	var hint string
+	switch {
+	case len(f.Enum) > 0:
+		hint = "one of " + strings.Join(f.Enum, ", ")
+	case f.Type == "boolean":
+		hint = "true or false"
+	case f.Type == "number" || f.Type == "integer":
+		hint = "a " + f.Type
+	case f.Format != "":
+		hint = "a " + f.Format
+	}
+	switch {
+	case f.Description == "":
+		return hint
+	case hint == "":
+		return f.Description
+	default:
+		return f.Description + " (" + hint + ")"
+	}
+}
@@ -0,0 +1,353 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/brush/internal/shell"
+	"github.com/charmbracelet/brush/internal/ui/common"
+	"github.com/charmbracelet/brush/internal/ui/list"
+	"github.com/charmbracelet/brush/internal/ui/styles"
+	"github.com/charmbracelet/brush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// JobsID is the identifier for the background jobs dialog.
+const JobsID = "jobs"
+
+const (
+	// jobsTailLines is the number of lines of output shown when tailing a
+	// job.
+	jobsTailLines = 12
+	// jobsTailInterval is how often the tailed output is refreshed.
+	jobsTailInterval = 500 * time.Millisecond
+)
+
+// jobsTickMsg refreshes the output of the tailed job.
+type jobsTickMsg struct {
+	dialog *Jobs
+}
+
+// Jobs is a dialog to tail, kill and restart the background jobs of a
+// session.
+type Jobs struct {
+	com       *common.Common
+	help      help.Model
+	list      *list.FilterableList
+	sessionID string
+	jobs      []shell.BackgroundShellInfo
+	tailing   bool
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Tail     key.Binding
+		Kill     key.Binding
+		Restart  key.Binding
+		Close    key.Binding
+	}
+}
+
+// JobItem wraps a [shell.BackgroundShellInfo] to implement the [ListItem]
+// interface.
+type JobItem struct {
+	shell.BackgroundShellInfo
+	t       *styles.Styles
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ Dialog   = (*Jobs)(nil)
+	_ ListItem = (*JobItem)(nil)
+)
+
+// NewJobs creates a new background jobs dialog for the session.
+func NewJobs(com *common.Common, sessionID string) *Jobs {
+	j := &Jobs{com: com, sessionID: sessionID}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	j.help = help
+
+	j.list = list.NewFilterableList()
+	j.list.Focus()
+	j.Refresh()
+
+	j.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next item"),
+	)
+	j.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous item"),
+	)
+	j.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "choose"),
+	)
+	j.keyMap.Tail = key.NewBinding(
+		key.WithKeys("enter", "ctrl+y"),
+		key.WithHelp("enter", "tail"),
+	)
+	j.keyMap.Kill = key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "kill"),
+	)
+	j.keyMap.Restart = key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "restart"),
+	)
+	j.keyMap.Close = CloseKey
+
+	return j
+}
+
+// ID implements [Dialog].
+func (j *Jobs) ID() string {
+	return JobsID
+}
+
+// Refresh reloads the jobs, keeping the selected one selected.
+func (j *Jobs) Refresh() {
+	var selectedID string
+	if item, ok := j.list.SelectedItem().(*JobItem); ok {
+		selectedID = item.ID()
+	}
+
+	j.jobs = shell.GetBackgroundShellManager().Jobs(j.sessionID)
+	items := make([]list.FilterableItem, len(j.jobs))
+	selected := 0
+	for i, job := range j.jobs {
+		items[i] = &JobItem{BackgroundShellInfo: job, t: j.com.Styles}
+		if job.ID == selectedID {
+			selected = i
+		}
+	}
+	j.list.SetItems(items...)
+	j.list.SetSelected(selected)
+	j.list.ScrollToSelected()
+	if len(j.jobs) == 0 {
+		j.tailing = false
+	}
+}
+
+// HandleMsg implements [Dialog].
+func (j *Jobs) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case jobsTickMsg:
+		if msg.dialog != j || !j.tailing {
+			break
+		}
+		return ActionCmd{j.tick()}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, j.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, j.keyMap.Previous):
+			if j.list.IsSelectedFirst() {
+				j.list.SelectLast()
+				j.list.ScrollToBottom()
+				break
+			}
+			j.list.SelectPrev()
+			j.list.ScrollToSelected()
+		case key.Matches(msg, j.keyMap.Next):
+			if j.list.IsSelectedLast() {
+				j.list.SelectFirst()
+				j.list.ScrollToTop()
+				break
+			}
+			j.list.SelectNext()
+			j.list.ScrollToSelected()
+		case key.Matches(msg, j.keyMap.Tail):
+			if len(j.jobs) == 0 {
+				break
+			}
+			j.tailing = !j.tailing
+			if j.tailing {
+				return ActionCmd{j.tick()}
+			}
+		case key.Matches(msg, j.keyMap.Kill):
+			item, ok := j.list.SelectedItem().(*JobItem)
+			if !ok {
+				break
+			}
+			return ActionCmd{killJobCmd(item.ID())}
+		case key.Matches(msg, j.keyMap.Restart):
+			item, ok := j.list.SelectedItem().(*JobItem)
+			if !ok {
+				break
+			}
+			return ActionCmd{restartJobCmd(item.ID())}
+		}
+	}
+	return nil
+}
+
+func (j *Jobs) tick() tea.Cmd {
+	return tea.Tick(jobsTailInterval, func(time.Time) tea.Msg {
+		return jobsTickMsg{dialog: j}
+	})
+}
+
+func killJobCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := shell.GetBackgroundShellManager().Kill(id); err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		return uiutil.NewInfoMsg(fmt.Sprintf("Job %s killed", id))
+	}
+}
+
+func restartJobCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := shell.GetBackgroundShellManager().Restart(id); err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		return uiutil.NewInfoMsg(fmt.Sprintf("Job %s restarted", id))
+	}
+}
+
+// tail renders the last lines of output of the selected job.
+func (j *Jobs) tail(width int) string {
+	t := j.com.Styles
+	item, ok := j.list.SelectedItem().(*JobItem)
+	if !ok {
+		return ""
+	}
+	bgShell, ok := shell.GetBackgroundShellManager().Get(item.ID())
+	if !ok {
+		return ""
+	}
+
+	const maxTailBytes = 16 * 1024
+	output, _ := bgShell.ReadOutput(max(0, bgShell.OutputSize()-maxTailBytes), maxTailBytes)
+	lines := strings.Split(strings.TrimRight(ansi.Strip(output), "\n"), "\n")
+	lines = lines[max(0, len(lines)-jobsTailLines):]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(strings.ReplaceAll(line, "\t", "    "), width, "…")
+	}
+	for len(lines) < jobsTailLines {
+		lines = append(lines, "")
+	}
+
+	title := t.Subtle.Render(fmt.Sprintf("Output of %s", item.ID()))
+	if item.LogPath != "" {
+		title += t.Muted.Render(" · " + item.LogPath)
+	}
+	return ansi.Truncate(title, width, "…") + "\n" + t.Base.Render(strings.Join(lines, "\n"))
+}
+
+// Draw implements [Dialog].
+func (j *Jobs) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := j.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	if j.tailing {
+		heightOffset += jobsTailLines + 2
+	}
+	j.list.SetSize(innerWidth, max(0, height-heightOffset))
+	j.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Background Jobs"
+	if len(j.jobs) == 0 {
+		rc.AddPart(t.Subtle.Render("No background jobs."))
+	} else {
+		rc.AddPart(t.Dialog.List.Height(j.list.Height()).Render(j.list.Render()))
+	}
+	if j.tailing {
+		rc.AddPart("\n" + j.tail(innerWidth))
+	}
+	rc.Help = j.help.View(j)
+
+	view := rc.Render()
+	DrawCenter(scr, area, view)
+	return nil
+}
+
+// ShortHelp implements [help.KeyMap].
+func (j *Jobs) ShortHelp() []key.Binding {
+	return []key.Binding{
+		j.keyMap.UpDown,
+		j.keyMap.Tail,
+		j.keyMap.Kill,
+		j.keyMap.Restart,
+		j.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (j *Jobs) FullHelp() [][]key.Binding {
+	return [][]key.Binding{j.ShortHelp()}
+}
+
+// JobStatus describes the state of a background job, e.g. "running" or
+// "exit 1".
+func JobStatus(job shell.BackgroundShellInfo) string {
+	switch {
+	case !job.Done:
+		return "running"
+	case job.ExitCode != 0:
+		return fmt.Sprintf("exit %d", job.ExitCode)
+	default:
+		return "done"
+	}
+}
+
+func (i *JobItem) title() string {
+	if i.Description != "" {
+		return fmt.Sprintf("%s %s", i.BackgroundShellInfo.ID, i.Description)
+	}
+	return fmt.Sprintf("%s %s", i.BackgroundShellInfo.ID, i.Command)
+}
+
+// Filter returns the filterable value of the job.
+func (i *JobItem) Filter() string {
+	return i.title()
+}
+
+// ID returns the ID of the job.
+func (i *JobItem) ID() string {
+	return i.BackgroundShellInfo.ID
+}
+
+// SetMatch sets the fuzzy match for the job item.
+func (i *JobItem) SetMatch(m fuzzy.Match) {
+	i.cache = nil
+	i.m = m
+}
+
+// SetFocused sets the focus state of the job item.
+func (i *JobItem) SetFocused(focused bool) {
+	if i.focused != focused {
+		i.cache = nil
+	}
+	i.focused = focused
+}
+
+// Render returns the string representation of the job item.
+func (i *JobItem) Render(width int) string {
+	info := JobStatus(i.BackgroundShellInfo) + " · " + humanize.Time(i.StartedAt)
+	styles := ListIemStyles{
+		ItemBlurred:     i.t.Dialog.NormalItem,
+		ItemFocused:     i.t.Dialog.SelectedItem,
+		InfoTextBlurred: i.t.Subtle,
+		InfoTextFocused: i.t.Base,
+	}
+	return renderItem(styles, i.title(), info, i.focused, width, i.cache, &i.m)
+}
@@ -0,0 +1,274 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/brush/internal/permission"
+	"github.com/charmbracelet/brush/internal/ui/common"
+	"github.com/charmbracelet/brush/internal/ui/list"
+	"github.com/charmbracelet/brush/internal/ui/styles"
+	"github.com/charmbracelet/brush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// PermissionGrantsID is the identifier for the permission grants dialog.
+const PermissionGrantsID = "permission_grants"
+
+// PermissionGrants is a dialog to review and revoke remembered permission
+// grants.
+type PermissionGrants struct {
+	com    *common.Common
+	help   help.Model
+	list   *list.FilterableList
+	input  textinput.Model
+	grants []permission.Grant
+
+	keyMap struct {
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Revoke   key.Binding
+		Close    key.Binding
+	}
+}
+
+// PermissionGrantItem wraps a [permission.Grant] to implement the [ListItem]
+// interface.
+type PermissionGrantItem struct {
+	permission.Grant
+	t       *styles.Styles
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var (
+	_ Dialog   = (*PermissionGrants)(nil)
+	_ ListItem = (*PermissionGrantItem)(nil)
+)
+
+// NewPermissionGrants creates a new permission grants dialog.
+func NewPermissionGrants(com *common.Common) (*PermissionGrants, error) {
+	grants, err := com.App.Permissions.ListGrants(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PermissionGrants{com: com, grants: grants}
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	p.help = help
+
+	p.list = list.NewFilterableList(permissionGrantItems(com.Styles, grants...)...)
+	p.list.Focus()
+	p.list.SetSelected(0)
+
+	p.input = textinput.New()
+	p.input.SetVirtualCursor(false)
+	p.input.Placeholder = "Type to filter"
+	p.input.SetStyles(com.Styles.TextInput)
+	p.input.Focus()
+
+	p.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next item"),
+	)
+	p.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous item"),
+	)
+	p.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "choose"),
+	)
+	p.keyMap.Revoke = key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "revoke"),
+	)
+	p.keyMap.Close = CloseKey
+
+	return p, nil
+}
+
+// ID implements [Dialog].
+func (p *PermissionGrants) ID() string {
+	return PermissionGrantsID
+}
+
+// HandleMsg implements [Dialog].
+func (p *PermissionGrants) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, p.keyMap.Previous):
+			p.list.Focus()
+			if p.list.IsSelectedFirst() {
+				p.list.SelectLast()
+				p.list.ScrollToBottom()
+				break
+			}
+			p.list.SelectPrev()
+			p.list.ScrollToSelected()
+		case key.Matches(msg, p.keyMap.Next):
+			p.list.Focus()
+			if p.list.IsSelectedLast() {
+				p.list.SelectFirst()
+				p.list.ScrollToTop()
+				break
+			}
+			p.list.SelectNext()
+			p.list.ScrollToSelected()
+		case key.Matches(msg, p.keyMap.Revoke):
+			item, ok := p.list.SelectedItem().(*PermissionGrantItem)
+			if !ok {
+				break
+			}
+			p.removeGrant(item.Grant.ID)
+			p.list.SetItems(permissionGrantItems(p.com.Styles, p.grants...)...)
+			p.list.SetFilter(p.input.Value())
+			p.list.ScrollToSelected()
+			return ActionCmd{p.revokeGrantCmd(item.Grant.ID)}
+		default:
+			var cmd tea.Cmd
+			p.input, cmd = p.input.Update(msg)
+			p.list.SetFilter(p.input.Value())
+			p.list.ScrollToTop()
+			p.list.SetSelected(0)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+func (p *PermissionGrants) removeGrant(id string) {
+	var grants []permission.Grant
+	for _, g := range p.grants {
+		if g.ID == id {
+			continue
+		}
+		grants = append(grants, g)
+	}
+	p.grants = grants
+}
+
+func (p *PermissionGrants) revokeGrantCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := p.com.App.Permissions.RevokeGrant(context.TODO(), id); err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		return uiutil.NewInfoMsg("Permission revoked")
+	}
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (p *PermissionGrants) Cursor() *tea.Cursor {
+	return InputCursor(p.com.Styles, p.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (p *PermissionGrants) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := p.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	p.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) cursor padding
+	p.list.SetSize(innerWidth, height-heightOffset)
+	p.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Permission Grants"
+	rc.AddPart(t.Dialog.InputPrompt.Render(p.input.View()))
+	if len(p.grants) == 0 {
+		rc.AddPart(t.Subtle.Render("No remembered permissions."))
+	} else {
+		rc.AddPart(t.Dialog.List.Height(p.list.Height()).Render(p.list.Render()))
+	}
+	rc.Help = p.help.View(p)
+
+	view := rc.Render()
+	cur := p.Cursor()
+	DrawCenterCursor(scr, area, view, cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (p *PermissionGrants) ShortHelp() []key.Binding {
+	return []key.Binding{
+		p.keyMap.UpDown,
+		p.keyMap.Revoke,
+		p.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (p *PermissionGrants) FullHelp() [][]key.Binding {
+	return [][]key.Binding{p.ShortHelp()}
+}
+
+// permissionGrantItems converts grants to a slice of [list.FilterableItem]s.
+func permissionGrantItems(t *styles.Styles, grants ...permission.Grant) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(grants))
+	for i, g := range grants {
+		items[i] = &PermissionGrantItem{Grant: g, t: t}
+	}
+	return items
+}
+
+func (g *PermissionGrantItem) title() string {
+	return fmt.Sprintf("%s:%s %s", g.ToolName, g.Action, g.Path)
+}
+
+// Filter returns the filterable value of the grant.
+func (g *PermissionGrantItem) Filter() string {
+	return g.title()
+}
+
+// ID returns the unique identifier of the grant.
+func (g *PermissionGrantItem) ID() string {
+	return g.Grant.ID
+}
+
+// SetMatch sets the fuzzy match for the grant item.
+func (g *PermissionGrantItem) SetMatch(m fuzzy.Match) {
+	g.cache = nil
+	g.m = m
+}
+
+// SetFocused sets the focus state of the grant item.
+func (g *PermissionGrantItem) SetFocused(focused bool) {
+	if g.focused != focused {
+		g.cache = nil
+	}
+	g.focused = focused
+}
+
+// Render returns the string representation of the grant item.
+func (g *PermissionGrantItem) Render(width int) string {
+	scope := "project"
+	if g.SessionID != "" {
+		scope = "session"
+	}
+	info := scope + " · " + humanize.Time(time.Unix(g.CreatedAt, 0))
+	styles := ListIemStyles{
+		ItemBlurred:     g.t.Dialog.NormalItem,
+		ItemFocused:     g.t.Dialog.SelectedItem,
+		InfoTextBlurred: g.t.Subtle,
+		InfoTextFocused: g.t.Base,
+	}
+	return renderItem(styles, g.title(), info, g.focused, width, g.cache, &g.m)
+}
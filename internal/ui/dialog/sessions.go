@@ -26,6 +26,7 @@ const (
 	sessionsModeNormal sessionsMode = iota
 	sessionsModeDeleting
 	sessionsModeUpdating
+	sessionsModeSearching
 )
 
 // Session is a session selector dialog.
@@ -46,6 +47,9 @@ type Session struct {
 		UpDown        key.Binding
 		Delete        key.Binding
 		Rename        key.Binding
+		Fork          key.Binding
+		Search        key.Binding
+		CancelSearch  key.Binding
 		ConfirmRename key.Binding
 		CancelRename  key.Binding
 		ConfirmDelete key.Binding
@@ -66,8 +70,9 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 		return nil, err
 	}
 
-	s.sessions = sessions
-	for i, sess := range sessions {
+	// Forks are listed right after the session they were forked from.
+	s.sessions, _ = session.Lineage(sessions)
+	for i, sess := range s.sessions {
 		if sess.ID == selectedSessionID {
 			s.selectedSessionInx = i
 			break
@@ -78,7 +83,7 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 	help.Styles = com.Styles.DialogHelpStyles()
 
 	s.help = help
-	s.list = list.NewFilterableList(sessionItems(com.Styles, sessionsModeNormal, sessions...)...)
+	s.list = list.NewFilterableList(sessionItems(com.Styles, sessionsModeNormal, s.sessions...)...)
 	s.list.Focus()
 	s.list.SetSelected(s.selectedSessionInx)
 
@@ -112,6 +117,18 @@ func NewSessions(com *common.Common, selectedSessionID string) (*Session, error)
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "rename"),
 	)
+	s.keyMap.Fork = key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "fork"),
+	)
+	s.keyMap.Search = key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "search messages"),
+	)
+	s.keyMap.CancelSearch = key.NewBinding(
+		key.WithKeys("esc", "alt+esc", "ctrl+s"),
+		key.WithHelp("esc", "back"),
+	)
 	s.keyMap.ConfirmRename = key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "confirm"),
@@ -141,8 +158,37 @@ func (s *Session) ID() string {
 // HandleMsg implements Dialog.
 func (s *Session) HandleMsg(msg tea.Msg) Action {
 	switch msg := msg.(type) {
+	case sessionSearchMsg:
+		// Ignore the results of queries typed over since.
+		if s.sessionsMode == sessionsModeSearching && msg.query == s.input.Value() {
+			s.list.SetItems(searchResultItems(s.com.Styles, msg.results...)...)
+			s.list.SetSelected(0)
+			s.list.ScrollToTop()
+		}
 	case tea.KeyPressMsg:
 		switch s.sessionsMode {
+		case sessionsModeSearching:
+			switch {
+			case key.Matches(msg, s.keyMap.CancelSearch):
+				s.stopSearch()
+			case key.Matches(msg, s.keyMap.Previous):
+				s.list.SelectPrev()
+				s.list.ScrollToSelected()
+			case key.Matches(msg, s.keyMap.Next):
+				s.list.SelectNext()
+				s.list.ScrollToSelected()
+			case key.Matches(msg, s.keyMap.Select):
+				if item, ok := s.list.SelectedItem().(*SearchResultItem); ok {
+					return ActionSelectSession{
+						Session:   s.findSession(item.SessionID, item.SessionTitle),
+						MessageID: item.MessageID,
+					}
+				}
+			default:
+				var cmd tea.Cmd
+				s.input, cmd = s.input.Update(msg)
+				return ActionCmd{tea.Batch(cmd, s.search())}
+			}
 		case sessionsModeDeleting:
 			switch {
 			case key.Matches(msg, s.keyMap.ConfirmDelete):
@@ -180,6 +226,12 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			case key.Matches(msg, s.keyMap.Rename):
 				s.sessionsMode = sessionsModeUpdating
 				s.list.SetItems(sessionItems(s.com.Styles, sessionsModeUpdating, s.sessions...)...)
+			case key.Matches(msg, s.keyMap.Search):
+				return ActionCmd{s.startSearch()}
+			case key.Matches(msg, s.keyMap.Fork):
+				if item := s.selectedSessionItem(); item != nil {
+					return ActionForkSession{SessionID: item.ID()}
+				}
 			case key.Matches(msg, s.keyMap.Delete):
 				if s.isCurrentSessionBusy() {
 					return ActionCmd{uiutil.ReportWarn("Agent is busy, please wait...")}
@@ -207,7 +259,7 @@ func (s *Session) HandleMsg(msg tea.Msg) Action {
 			case key.Matches(msg, s.keyMap.Select):
 				if item := s.list.SelectedItem(); item != nil {
 					sessionItem := item.(*SessionItem)
-					return ActionSelectSession{sessionItem.Session}
+					return ActionSelectSession{Session: sessionItem.Session}
 				}
 			default:
 				var cmd tea.Cmd
@@ -254,6 +306,10 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 	rc := NewRenderContext(t, width)
 	rc.Title = "Sessions"
 	switch s.sessionsMode {
+	case sessionsModeSearching:
+		rc.Title = "Search Sessions"
+		rc.AddPart(t.Dialog.InputPrompt.Render(s.input.View()))
+		cur = s.Cursor()
 	case sessionsModeDeleting:
 		rc.TitleStyle = t.Dialog.Sessions.DeletingTitle
 		rc.TitleGradientFromColor = t.Dialog.Sessions.DeletingTitleGradientFromColor
@@ -321,12 +377,52 @@ func (s *Session) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 }
 
 func (s *Session) selectedSessionItem() *SessionItem {
-	if item := s.list.SelectedItem(); item != nil {
-		return item.(*SessionItem)
+	if item, ok := s.list.SelectedItem().(*SessionItem); ok {
+		return item
 	}
 	return nil
 }
 
+// startSearch switches the dialog to searching the messages of all sessions,
+// starting with the title filter typed so far.
+func (s *Session) startSearch() tea.Cmd {
+	s.sessionsMode = sessionsModeSearching
+	s.input.Placeholder = "Search messages"
+	s.list.SetFilter("")
+	s.list.SetItems()
+	return s.search()
+}
+
+// stopSearch goes back to the list of sessions.
+func (s *Session) stopSearch() {
+	s.sessionsMode = sessionsModeNormal
+	s.input.Placeholder = "Enter session name"
+	s.input.SetValue("")
+	s.list.SetItems(sessionItems(s.com.Styles, sessionsModeNormal, s.sessions...)...)
+	s.list.SetFilter("")
+	s.list.SetSelected(s.selectedSessionInx)
+}
+
+// search runs the query in the input, clearing the results when it's empty.
+func (s *Session) search() tea.Cmd {
+	query := s.input.Value()
+	if strings.TrimSpace(query) == "" {
+		s.list.SetItems()
+		return nil
+	}
+	return s.searchCmd(query)
+}
+
+// findSession returns the listed session with the given ID.
+func (s *Session) findSession(id, title string) session.Session {
+	for _, sess := range s.sessions {
+		if sess.ID == id {
+			return sess
+		}
+	}
+	return session.Session{ID: id, Title: title}
+}
+
 func (s *Session) confirmDeleteSession() Action {
 	sessionItem := s.selectedSessionItem()
 	s.sessionsMode = sessionsModeNormal
@@ -421,10 +517,18 @@ func (s *Session) ShortHelp() []key.Binding {
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
 		}
+	case sessionsModeSearching:
+		return []key.Binding{
+			s.keyMap.UpDown,
+			s.keyMap.Select,
+			s.keyMap.CancelSearch,
+		}
 	default:
 		return []key.Binding{
 			s.keyMap.UpDown,
+			s.keyMap.Search,
 			s.keyMap.Rename,
+			s.keyMap.Fork,
 			s.keyMap.Delete,
 			s.keyMap.Select,
 			s.keyMap.Close,
@@ -437,7 +541,9 @@ func (s *Session) FullHelp() [][]key.Binding {
 	m := [][]key.Binding{}
 	slice := []key.Binding{
 		s.keyMap.UpDown,
+		s.keyMap.Search,
 		s.keyMap.Rename,
+		s.keyMap.Fork,
 		s.keyMap.Delete,
 		s.keyMap.Select,
 		s.keyMap.Close,
@@ -454,6 +560,12 @@ func (s *Session) FullHelp() [][]key.Binding {
 			s.keyMap.ConfirmRename,
 			s.keyMap.CancelRename,
 		}
+	case sessionsModeSearching:
+		slice = []key.Binding{
+			s.keyMap.UpDown,
+			s.keyMap.Select,
+			s.keyMap.CancelSearch,
+		}
 	}
 	for i := 0; i < len(slice); i += 4 {
 		end := min(i+4, len(slice))
@@ -32,6 +32,7 @@ type SessionItem struct {
 	session.Session
 	t                *styles.Styles
 	sessionsMode     sessionsMode
+	depth            int
 	m                fuzzy.Match
 	cache            map[int]string
 	updateTitleInput textinput.Model
@@ -98,7 +99,18 @@ func (s *SessionItem) Render(width int) string {
 		}
 	}
 
-	return renderItem(styles, s.Title, info, s.focused, width, s.cache, &s.m)
+	if s.depth == 0 {
+		return renderItem(styles, s.Title, info, s.focused, width, s.cache, &s.m)
+	}
+	// Show forks under the session they were forked from, shifting the
+	// matches of the title past the prefix.
+	prefix := strings.Repeat("  ", s.depth-1) + "└ "
+	m := s.m
+	m.MatchedIndexes = make([]int, len(s.m.MatchedIndexes))
+	for i, idx := range s.m.MatchedIndexes {
+		m.MatchedIndexes[i] = idx + len(prefix)
+	}
+	return renderItem(styles, prefix+s.Title, info, s.focused, width, s.cache, &m)
 }
 
 type ListIemStyles struct {
@@ -183,11 +195,12 @@ func (s *SessionItem) SetFocused(focused bool) {
 }
 
 // sessionItems takes a slice of [session.Session]s and convert them to a slice
-// of [ListItem]s.
+// of [ListItem]s, ordered by fork lineage.
 func sessionItems(t *styles.Styles, mode sessionsMode, sessions ...session.Session) []list.FilterableItem {
+	sessions, depths := session.Lineage(sessions)
 	items := make([]list.FilterableItem, len(sessions))
 	for i, s := range sessions {
-		item := &SessionItem{Session: s, t: t, sessionsMode: mode}
+		item := &SessionItem{Session: s, t: t, sessionsMode: mode, depth: depths[i]}
 		if mode == sessionsModeUpdating {
 			item.updateTitleInput = textinput.New()
 			item.updateTitleInput.SetVirtualCursor(false)
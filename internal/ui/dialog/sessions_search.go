@@ -0,0 +1,129 @@
+package dialog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/brush/internal/session"
+	"github.com/charmbracelet/brush/internal/ui/list"
+	"github.com/charmbracelet/brush/internal/ui/styles"
+	"github.com/charmbracelet/brush/internal/uiutil"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// sessionSearchLimit is the maximum number of search results shown.
+const sessionSearchLimit = 50
+
+// sessionSearchMsg carries the results of a session search.
+type sessionSearchMsg struct {
+	query   string
+	results []session.SearchResult
+}
+
+// searchCmd searches the sessions and messages for the query.
+func (s *Session) searchCmd(query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := s.com.App.Sessions.Search(context.TODO(), query, sessionSearchLimit)
+		if err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		return sessionSearchMsg{query: query, results: results}
+	}
+}
+
+// SearchResultItem wraps a [session.SearchResult] to implement the [ListItem]
+// interface. It shows the session title with the matching snippet below it.
+type SearchResultItem struct {
+	session.SearchResult
+	t       *styles.Styles
+	focused bool
+	cache   map[int]string
+}
+
+var _ ListItem = &SearchResultItem{}
+
+// searchResultItems converts search results to a slice of [ListItem]s.
+func searchResultItems(t *styles.Styles, results ...session.SearchResult) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(results))
+	for i, result := range results {
+		items[i] = &SearchResultItem{SearchResult: result, t: t}
+	}
+	return items
+}
+
+// Filter returns the filterable value of the search result. Results are
+// already filtered by the search, so this is only used for display.
+func (s *SearchResultItem) Filter() string {
+	return s.SessionTitle
+}
+
+// ID returns the unique identifier of the search result.
+func (s *SearchResultItem) ID() string {
+	return s.SessionID + "/" + s.MessageID
+}
+
+// SetMatch implements [list.MatchSettable]. The matches of a search result
+// come from the search itself.
+func (s *SearchResultItem) SetMatch(fuzzy.Match) {}
+
+// SetFocused sets the focus state of the search result item.
+func (s *SearchResultItem) SetFocused(focused bool) {
+	if s.focused != focused {
+		s.cache = nil
+	}
+	s.focused = focused
+}
+
+// Render returns the string representation of the search result item.
+func (s *SearchResultItem) Render(width int) string {
+	if cached, ok := s.cache[width]; ok {
+		return cached
+	}
+
+	itemStyles := ListIemStyles{
+		ItemBlurred:     s.t.Dialog.NormalItem,
+		ItemFocused:     s.t.Dialog.SelectedItem,
+		InfoTextBlurred: s.t.Subtle,
+		InfoTextFocused: s.t.Base,
+	}
+	info := humanize.Time(time.Unix(s.UpdatedAt, 0))
+	title := renderItem(itemStyles, s.SessionTitle, info, s.focused, width, nil, &fuzzy.Match{})
+
+	snippetStyle := s.t.Dialog.Sessions.SearchSnippetBlurred
+	if s.focused {
+		snippetStyle = s.t.Dialog.Sessions.SearchSnippetFocused
+	}
+	snippetWidth := max(0, width-snippetStyle.GetHorizontalFrameSize())
+	snippet := ansi.Truncate(highlightMatches(s.Snippet, s.Matches), snippetWidth, "…")
+	snippet = snippetStyle.Width(width).Render(snippet)
+
+	if s.cache == nil {
+		s.cache = make(map[int]string)
+	}
+	s.cache[width] = title + "\n" + snippet
+	return s.cache[width]
+}
+
+// highlightMatches makes the matched byte ranges of text bold and underlined.
+func highlightMatches(text string, matches [][2]int) string {
+	var b strings.Builder
+	var last int
+	for _, m := range matches {
+		if m[0] < last || m[1] > len(text) {
+			continue
+		}
+		b.WriteString(text[last:m[0]])
+		// NOTE: As in [renderItem], [ansi.Style] only toggles the attributes
+		// it sets, leaving the colors of the item style alone.
+		b.WriteString(ansi.NewStyle().Bold().Underline(true).String())
+		b.WriteString(text[m[0]:m[1]])
+		b.WriteString(ansi.NewStyle().Normal().Underline(false).String())
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
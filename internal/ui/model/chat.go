@@ -404,6 +404,18 @@ func (m *Chat) MessageItem(id string) chat.MessageItem {
 	return item
 }
 
+// SelectMessage selects the item of the message with the given ID and scrolls
+// to it. It returns false if the message isn't in the chat.
+func (m *Chat) SelectMessage(id string) bool {
+	idx, ok := m.idInxMap[id]
+	if !ok {
+		return false
+	}
+	m.SetSelected(idx)
+	m.list.ScrollToSelected()
+	return true
+}
+
 // ToggleExpandedSelectedItem expands the selected message item if it is expandable.
 func (m *Chat) ToggleExpandedSelectedItem() {
 	if expandable, ok := m.list.SelectedItem().(chat.Expandable); ok {
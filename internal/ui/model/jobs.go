@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/brush/internal/shell"
+	"github.com/charmbracelet/brush/internal/ui/common"
+	"github.com/charmbracelet/brush/internal/ui/dialog"
+	"github.com/charmbracelet/brush/internal/ui/styles"
+)
+
+// maxJobsShown is the maximum number of background jobs listed in the
+// sidebar.
+const maxJobsShown = 5
+
+// jobsInfo renders the background jobs section showing the jobs of the
+// session and their status. It's empty if the session has no jobs.
+func (m *UI) jobsInfo(width, maxItems int, isSection bool) string {
+	if m.session == nil {
+		return ""
+	}
+	jobs := shell.GetBackgroundShellManager().Jobs(m.session.ID)
+	if len(jobs) == 0 {
+		return ""
+	}
+
+	t := m.com.Styles
+	title := t.Subtle.Render("Jobs")
+	if isSection {
+		title = common.Section(t, title, width)
+	}
+	list := jobsList(t, jobs, width, maxItems)
+	return lipgloss.NewStyle().Width(width).Render(fmt.Sprintf("%s\n\n%s", title, list))
+}
+
+// jobsList renders a list of background jobs with their status, truncating
+// to maxItems if needed.
+func jobsList(t *styles.Styles, jobs []shell.BackgroundShellInfo, width, maxItems int) string {
+	if maxItems <= 0 {
+		return ""
+	}
+	var renderedJobs []string
+	for _, job := range jobs {
+		var icon string
+		switch {
+		case !job.Done:
+			icon = t.ItemBusyIcon.String()
+		case job.ExitCode != 0:
+			icon = t.ItemErrorIcon.String()
+		default:
+			icon = t.ItemOnlineIcon.String()
+		}
+		title := job.Command
+		if job.Description != "" {
+			title = job.Description
+		}
+		renderedJobs = append(renderedJobs, common.Status(t, common.StatusOpts{
+			Icon:        icon,
+			Title:       job.ID,
+			Description: t.Subtle.Render(dialog.JobStatus(job) + " " + title),
+		}, width))
+	}
+
+	if len(renderedJobs) > maxItems {
+		visibleItems := renderedJobs[:maxItems-1]
+		remaining := len(renderedJobs) - maxItems
+		visibleItems = append(visibleItems, t.Subtle.Render(fmt.Sprintf("…and %d more", remaining)))
+		return lipgloss.JoinVertical(lipgloss.Left, visibleItems...)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, renderedJobs...)
+}
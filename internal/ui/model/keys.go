@@ -41,6 +41,9 @@ type KeyMap struct {
 		Copy           key.Binding
 		ClearHighlight key.Binding
 		Expand         key.Binding
+		Rewind         key.Binding
+		RewindChat     key.Binding
+		Fork           key.Binding
 	}
 
 	Initialize struct {
@@ -225,6 +228,18 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("space"),
 		key.WithHelp("space", "expand/collapse"),
 	)
+	km.Chat.Rewind = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rewind to here"),
+	)
+	km.Chat.RewindChat = key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "rewind and edit"),
+	)
+	km.Chat.Fork = key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "fork from here"),
+	)
 	km.Initialize.Yes = key.NewBinding(
 		key.WithKeys("y", "Y"),
 		key.WithHelp("y", "yes"),
@@ -1,12 +1,16 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/brush/internal/budget"
 	"github.com/charmbracelet/brush/internal/session"
+	"github.com/charmbracelet/brush/internal/stringext"
 	"github.com/charmbracelet/brush/internal/ui/chat"
 	"github.com/charmbracelet/brush/internal/ui/styles"
 )
@@ -114,6 +118,18 @@ func todoPill(todos []session.Todo, spinnerView string, focused, panelFocused bo
 	return pillStyle(focused, panelFocused, t).Render(content)
 }
 
+// budgetPill renders the warning pill of a budget that is running out.
+func budgetPill(status budget.Status, panelFocused bool, t *styles.Styles) string {
+	iconStyle := t.Pills.BudgetWarning
+	if status.Exceeded() {
+		iconStyle = t.Pills.BudgetExceeded
+	}
+	label := t.Base.Render(stringext.Capitalize(string(status.Scope)) + " budget")
+	used := t.Muted.Render(fmt.Sprintf("%.0f%%", status.Ratio()*100))
+	content := fmt.Sprintf("%s %s %s  %s", iconStyle.Render(styles.WarningIcon), label, used, t.Subtle.Render(status.Used()))
+	return pillStyle(false, panelFocused, t).Render(content)
+}
+
 // todoList renders the expanded todo list.
 func todoList(sessionTodos []session.Todo, spinnerView string, t *styles.Styles, width int) string {
 	return chat.FormatTodosList(t, sessionTodos, spinnerView, width)
@@ -138,6 +154,45 @@ func queueList(queueItems []string, t *styles.Styles) string {
 	return strings.Join(lines, "\n")
 }
 
+// budgetStatusMsg carries the most used budget of a session when it's
+// running out.
+type budgetStatusMsg struct {
+	sessionID string
+	status    *budget.Status
+}
+
+// checkBudget loads the budgets of the session to warn when one is running
+// out.
+func (m *UI) checkBudget(sess session.Session) tea.Cmd {
+	checker := budget.NewChecker(m.com.Config(), m.com.App.Sessions)
+	if !checker.Enabled() {
+		return nil
+	}
+	return func() tea.Msg {
+		statuses, err := checker.Check(context.Background(), sess)
+		if err != nil {
+			slog.Error("Failed to check budgets", "error", err)
+			return nil
+		}
+		msg := budgetStatusMsg{sessionID: sess.ID}
+		if len(statuses) > 0 && statuses[0].Warn() {
+			msg.status = &statuses[0]
+		}
+		return msg
+	}
+}
+
+// setBudgetStatus shows or updates the budget pill.
+func (m *UI) setBudgetStatus(status *budget.Status) {
+	shown := m.budgetStatus != nil
+	m.budgetStatus = status
+	if shown != (status != nil) {
+		m.updateLayoutAndSize()
+		return
+	}
+	m.renderPills()
+}
+
 // togglePillsExpanded toggles the pills panel expansion state.
 func (m *UI) togglePillsExpanded() tea.Cmd {
 	if !m.hasSession() {
@@ -192,7 +247,7 @@ func (m *UI) pillsAreaHeight() int {
 	}
 	hasIncomplete := hasIncompleteTodos(m.session.Todos)
 	hasQueue := m.promptQueue > 0
-	hasPills := hasIncomplete || hasQueue
+	hasPills := hasIncomplete || hasQueue || m.budgetStatus != nil
 	if !hasPills {
 		return 0
 	}
@@ -226,7 +281,7 @@ func (m *UI) renderPills() {
 	hasIncomplete := hasIncompleteTodos(m.session.Todos)
 	hasQueue := m.promptQueue > 0
 
-	if !hasIncomplete && !hasQueue {
+	if !hasIncomplete && !hasQueue && m.budgetStatus == nil {
 		return
 	}
 
@@ -246,6 +301,9 @@ func (m *UI) renderPills() {
 	if hasQueue {
 		pills = append(pills, queuePill(m.promptQueue, queueFocused, m.pillsExpanded, t))
 	}
+	if m.budgetStatus != nil {
+		pills = append(pills, budgetPill(*m.budgetStatus, m.pillsExpanded, t))
+	}
 
 	var expandedList string
 	if m.pillsExpanded {
@@ -265,14 +323,17 @@ func (m *UI) renderPills() {
 
 	pillsRow := lipgloss.JoinHorizontal(lipgloss.Top, pills...)
 
-	helpDesc := "open"
-	if m.pillsExpanded {
-		helpDesc = "close"
+	// The budget pill has nothing to expand.
+	if hasIncomplete || hasQueue {
+		helpDesc := "open"
+		if m.pillsExpanded {
+			helpDesc = "close"
+		}
+		helpKey := t.Pills.HelpKey.Render("ctrl+space")
+		helpText := t.Pills.HelpText.Render(helpDesc)
+		helpHint := lipgloss.JoinHorizontal(lipgloss.Center, helpKey, " ", helpText)
+		pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, " ", helpHint)
 	}
-	helpKey := t.Pills.HelpKey.Render("ctrl+space")
-	helpText := t.Pills.HelpText.Render(helpDesc)
-	helpHint := lipgloss.JoinHorizontal(lipgloss.Center, helpKey, " ", helpText)
-	pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, " ", helpHint)
 
 	pillsArea := pillsRow
 	if expandedList != "" {
@@ -24,6 +24,8 @@ import (
 type loadSessionMsg struct {
 	session *session.Session
 	files   []SessionFile
+	// messageID is the message to jump to once the session is loaded.
+	messageID string
 }
 
 // SessionFile tracks the first and latest versions of a file in a session,
@@ -102,6 +104,19 @@ func (m *UI) loadSession(sessionID string) tea.Cmd {
 	}
 }
 
+// loadSessionAt loads a session and jumps to one of its messages.
+func (m *UI) loadSessionAt(sessionID, messageID string) tea.Cmd {
+	load := m.loadSession(sessionID)
+	return func() tea.Msg {
+		msg := load()
+		if loaded, ok := msg.(loadSessionMsg); ok {
+			loaded.messageID = messageID
+			return loaded
+		}
+		return msg
+	}
+}
+
 // handleFileEvent processes file change events and updates the session file
 // list with new or updated file information.
 func (m *UI) handleFileEvent(file history.File) tea.Cmd {
@@ -103,7 +103,8 @@ func getDynamicHeightLimits(availableHeight int) (maxFiles, maxLSPs, maxMCPs int
 }
 
 // sidebar renders the chat sidebar containing session title, working
-// directory, model info, file list, LSP status, and MCP status.
+// directory, model info, file list, LSP status, MCP status and background
+// jobs.
 func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 	if m.session == nil {
 		return
@@ -138,12 +139,28 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 
 	_, remainingHeightArea := uv.SplitVertical(m.layout.sidebar, uv.Fixed(lipgloss.Height(sidebarHeader)))
 	remainingHeight := remainingHeightArea.Dy() - 10
+	jobsSection := m.jobsInfo(width, maxJobsShown, true)
+	if jobsSection != "" {
+		remainingHeight -= lipgloss.Height(jobsSection) + 1
+	}
 	maxFiles, maxLSPs, maxMCPs := getDynamicHeightLimits(remainingHeight)
 
 	lspSection := m.lspInfo(width, maxLSPs, true)
 	mcpSection := m.mcpInfo(width, maxMCPs, true)
 	filesSection := m.filesInfo(m.com.Config().WorkingDir(), width, maxFiles, true)
 
+	sections := []string{
+		sidebarHeader,
+		filesSection,
+		"",
+		lspSection,
+		"",
+		mcpSection,
+	}
+	if jobsSection != "" {
+		sections = append(sections, "", jobsSection)
+	}
+
 	uv.NewStyledString(
 		lipgloss.NewStyle().
 			MaxWidth(width).
@@ -151,12 +168,7 @@ func (m *UI) drawSidebar(scr uv.Screen, area uv.Rectangle) {
 			Render(
 				lipgloss.JoinVertical(
 					lipgloss.Left,
-					sidebarHeader,
-					filesSection,
-					"",
-					lspSection,
-					"",
-					mcpSection,
+					sections...,
 				),
 			),
 	).Draw(scr, area)
@@ -2,6 +2,7 @@ package model
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -24,8 +25,10 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/brush/internal/agent"
 	"github.com/charmbracelet/brush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/brush/internal/app"
+	"github.com/charmbracelet/brush/internal/budget"
 	"github.com/charmbracelet/brush/internal/commands"
 	"github.com/charmbracelet/brush/internal/config"
 	"github.com/charmbracelet/brush/internal/filetracker"
@@ -36,6 +39,7 @@ import (
 	"github.com/charmbracelet/brush/internal/permission"
 	"github.com/charmbracelet/brush/internal/pubsub"
 	"github.com/charmbracelet/brush/internal/session"
+	"github.com/charmbracelet/brush/internal/shell"
 	"github.com/charmbracelet/brush/internal/ui/anim"
 	"github.com/charmbracelet/brush/internal/ui/attachments"
 	"github.com/charmbracelet/brush/internal/ui/chat"
@@ -50,6 +54,7 @@ import (
 	uv "github.com/charmbracelet/ultraviolet"
 	"github.com/charmbracelet/ultraviolet/screen"
 	"github.com/charmbracelet/x/editor"
+	"github.com/pkg/browser"
 )
 
 // Compact mode breakpoints.
@@ -204,6 +209,9 @@ type UI struct {
 	focusedPillSection pillSection
 	promptQueue        int
 	pillsView          string
+	// budgetStatus is the budget of the session that is running out, if
+	// any.
+	budgetStatus *budget.Status
 
 	// Todo spinner
 	todoSpinner    spinner.Model
@@ -366,6 +374,10 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.session = msg.session
 		m.sessionFiles = msg.files
+		m.budgetStatus = nil
+		if cmd := m.checkBudget(*m.session); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 		msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
 		if err != nil {
 			cmds = append(cmds, uiutil.ReportError(err))
@@ -374,6 +386,9 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.setSessionMessages(msgs); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		if msg.messageID != "" {
+			m.selectMessage(msgs, msg.messageID)
+		}
 		if hasInProgressTodo(m.session.Todos) {
 			// only start spinner if there is an in-progress todo
 			if m.isAgentBusy() {
@@ -422,12 +437,19 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.session != nil && msg.Payload.ID == m.session.ID {
 			prevHasInProgress := hasInProgressTodo(m.session.Todos)
 			m.session = &msg.Payload
+			if cmd := m.checkBudget(msg.Payload); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 			if !prevHasInProgress && hasInProgressTodo(m.session.Todos) {
 				m.todoIsSpinning = true
 				cmds = append(cmds, m.todoSpinner.Tick)
 				m.updateLayoutAndSize()
 			}
 		}
+	case budgetStatusMsg:
+		if m.session != nil && m.session.ID == msg.sessionID {
+			m.setBudgetStatus(msg.status)
+		}
 	case pubsub.Event[message.Message]:
 		// Check if this is a child session message for an agent tool.
 		if m.session == nil {
@@ -480,8 +502,19 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.openPermissionsDialog(msg.Payload); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case pubsub.Event[mcp.ElicitationRequest]:
+		m.handleElicitation(msg)
 	case pubsub.Event[permission.PermissionNotification]:
 		m.handlePermissionNotification(msg.Payload)
+	case pubsub.Event[shell.BackgroundShellInfo]:
+		if jobs, ok := m.dialog.Dialog(dialog.JobsID).(*dialog.Jobs); ok {
+			jobs.Refresh()
+		}
+	case pubsub.Event[agent.RetryEvent]:
+		if m.session != nil && m.session.ID == msg.Payload.SessionID {
+			retry := msg.Payload
+			cmds = append(cmds, uiutil.CmdHandler(uiutil.NewRetryMsg(retry.Delay, retry.Attempt, retry.MaxAttempts)))
+		}
 	case cancelTimerExpiredMsg:
 		m.isCanceling = false
 	case tea.TerminalVersionMsg:
@@ -649,6 +682,15 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case openEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case chat.RewindMsg:
+		m.openRewindDialog(msg)
+	case rewoundMsg:
+		cmds = append(cmds, m.handleRewound(msg))
+	case chat.ForkMsg:
+		cmds = append(cmds, m.fork(msg.SessionID, msg.MessageID))
+	case forkedMsg:
+		m.dialog.CloseDialog(dialog.SessionsID)
+		cmds = append(cmds, m.loadSession(msg.session.ID), uiutil.ReportInfo("Forked session"))
 	case uiutil.InfoMsg:
 		m.status.SetInfoMsg(msg)
 		ttl := msg.TTL
@@ -661,7 +703,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case completions.FilesLoadedMsg:
 		// Handle async file loading for completions.
 		if m.completionsOpen {
-			m.completions.SetFiles(msg.Files)
+			m.completions.SetFiles(msg.Files, msg.Resources)
 		}
 	case uv.WindowPixelSizeEvent:
 		// [timage.RequestCapabilities] requests the terminal to send a window
@@ -759,6 +801,29 @@ func (m *UI) setSessionMessages(msgs []message.Message) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// selectMessage focuses the chat on a message of the session. Messages with
+// only tool calls are shown as their tool calls, so the first of them is
+// selected instead.
+func (m *UI) selectMessage(msgs []message.Message, id string) {
+	ids := []string{id}
+	for _, msg := range msgs {
+		if msg.ID == id {
+			for _, tc := range msg.ToolCalls() {
+				ids = append(ids, tc.ID)
+			}
+			break
+		}
+	}
+	for _, id := range ids {
+		if m.chat.SelectMessage(id) {
+			m.focus = uiFocusMain
+			m.textarea.Blur()
+			m.chat.Focus()
+			return
+		}
+	}
+}
+
 // loadNestedToolCalls recursively loads nested tool calls for agent/agentic_fetch tools.
 func (m *UI) loadNestedToolCalls(items []chat.MessageItem) {
 	for _, item := range items {
@@ -1068,7 +1133,13 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	// Session dialog messages
 	case dialog.ActionSelectSession:
 		m.dialog.CloseDialog(dialog.SessionsID)
-		cmds = append(cmds, m.loadSession(msg.Session.ID))
+		if msg.MessageID != "" {
+			cmds = append(cmds, m.loadSessionAt(msg.Session.ID, msg.MessageID))
+		} else {
+			cmds = append(cmds, m.loadSession(msg.Session.ID))
+		}
+	case dialog.ActionForkSession:
+		cmds = append(cmds, m.fork(msg.SessionID, ""))
 
 	// Open dialog message
 	case dialog.ActionOpenDialog:
@@ -1148,6 +1219,19 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionQuit:
 		cmds = append(cmds, tea.Quit)
+	case dialog.ActionRewind:
+		m.dialog.CloseDialog(dialog.RewindID)
+		cmds = append(cmds, m.rewind(msg.SessionID, msg.MessageID, msg.Truncate))
+	case dialog.ActionCommit:
+		cmds = append(cmds, func() tea.Msg {
+			if err := msg.Repo.CommitPaths(context.Background(), msg.Message, msg.Files...); err != nil {
+				return uiutil.NewErrorMsg(err)
+			}
+			summary, _, _ := strings.Cut(msg.Message, "\n")
+			return uiutil.NewInfoMsg("Committed: " + summary)
+		})
+		m.dialog.CloseDialog(dialog.CommitID)
+
 	case dialog.ActionInitializeProject:
 		if m.isAgentBusy() {
 			cmds = append(cmds, uiutil.ReportWarn("Agent is busy, please wait before summarizing session..."))
@@ -1257,6 +1341,8 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.App.Permissions.Grant(msg.Permission)
 		case dialog.PermissionAllowForSession:
 			m.com.App.Permissions.GrantPersistent(msg.Permission)
+		case dialog.PermissionAllowInProject:
+			m.com.App.Permissions.GrantAlways(msg.Permission)
 		case dialog.PermissionDeny:
 			m.com.App.Permissions.Deny(msg.Permission)
 		}
@@ -1307,6 +1393,12 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			break
 		}
 		cmds = append(cmds, m.runMCPPrompt(msg.ClientID, msg.PromptID, msg.Args))
+	case dialog.ActionElicitationResponse:
+		m.dialog.CloseDialog(dialog.ElicitationID)
+		mcp.RespondElicitation(msg.RequestID, msg.Action, msg.Content)
+	case dialog.ActionMCPLogin:
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, uiutil.ReportInfo("Log in to "+msg.Name+" in your browser"), m.mcpLogin(msg.Name))
 	default:
 		cmds = append(cmds, uiutil.CmdHandler(msg))
 	}
@@ -1314,6 +1406,22 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// mcpLogin logs in to the MCP server in the browser, and rebuilds the agent
+// with its tools once connected.
+func (m *UI) mcpLogin(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := mcp.Login(ctx, m.com.Config(), name, browser.OpenURL); err != nil {
+			return uiutil.ReportError(fmt.Errorf("failed to log in to %s: %w", name, err))()
+		}
+		if err := m.com.App.UpdateAgentModel(ctx); err != nil {
+			return uiutil.ReportError(err)()
+		}
+		return uiutil.NewInfoMsg("Logged in to " + name)
+	}
+}
+
 // substituteArgs replaces $ARG_NAME placeholders in content with actual values.
 func substituteArgs(content string, args map[string]string) string {
 	for name, value := range args {
@@ -1448,8 +1556,11 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					switch msg := msg.(type) {
 					case completions.SelectionMsg:
 						// Handle file completion selection.
-						if item, ok := msg.Value.(completions.FileCompletionValue); ok {
+						switch item := msg.Value.(type) {
+						case completions.FileCompletionValue:
 							cmds = append(cmds, m.insertFileCompletion(item.Path))
+						case completions.ResourceCompletionValue:
+							cmds = append(cmds, m.insertResourceCompletion(item))
 						}
 						if !msg.Insert {
 							m.closeCompletions()
@@ -1996,6 +2107,9 @@ func (m *UI) FullHelp() [][]key.Binding {
 				[]key.Binding{
 					k.Chat.Copy,
 					k.Chat.ClearHighlight,
+					k.Chat.Rewind,
+					k.Chat.RewindChat,
+					k.Chat.Fork,
 				},
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
@@ -2419,6 +2533,56 @@ func (m *UI) insertFileCompletion(path string) tea.Cmd {
 	}
 }
 
+// insertResourceCompletion inserts the selected MCP resource and attaches its
+// contents.
+func (m *UI) insertResourceCompletion(resource completions.ResourceCompletionValue) tea.Cmd {
+	value := m.textarea.Value()
+	word := m.textareaWord()
+
+	if m.completionsStartIndex > len(value) {
+		return nil
+	}
+
+	endIdx := min(m.completionsStartIndex+len(word), len(value))
+	newValue := value[:m.completionsStartIndex] + resource.URI + value[endIdx:]
+	m.textarea.SetValue(newValue)
+	m.textarea.MoveToEnd()
+	m.textarea.InsertRune(' ')
+
+	return func() tea.Msg {
+		contents, err := mcp.ReadResource(context.Background(), resource.MCPName, resource.URI)
+		if err != nil {
+			return uiutil.InfoMsg{Type: uiutil.InfoTypeError, Msg: fmt.Sprintf("Failed to read %s: %v", resource.URI, err)}
+		}
+		return resourceAttachment(resource, contents)
+	}
+}
+
+// resourceAttachment turns the contents of an MCP resource into an
+// attachment, as text unless the resource is binary.
+func resourceAttachment(resource completions.ResourceCompletionValue, contents []*mcp.ResourceContents) tea.Msg {
+	attachment := message.Attachment{
+		FilePath: resource.URI,
+		FileName: resource.Name,
+		MimeType: "text/plain",
+	}
+	var texts []string
+	for _, content := range contents {
+		if content.Blob != nil {
+			// Attach the first binary content as is, e.g. an image.
+			attachment.MimeType = cmp.Or(content.MIMEType, resource.MIMEType, mimeOf(content.Blob))
+			attachment.Content = content.Blob
+			return attachment
+		}
+		texts = append(texts, content.Text)
+	}
+	if strings.HasPrefix(resource.MIMEType, "text/") {
+		attachment.MimeType = resource.MIMEType
+	}
+	attachment.Content = []byte(strings.Join(texts, "\n"))
+	return attachment
+}
+
 // completionsPosition returns the X and Y position for the completions popup.
 func (m *UI) completionsPosition() image.Point {
 	cur := m.textarea.Cursor()
@@ -2618,6 +2782,16 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openReasoningDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.PermissionGrantsID:
+		if cmd := m.openPermissionGrantsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.JobsID:
+		m.openJobsDialog()
+	case dialog.CommitID:
+		if cmd := m.openCommitDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case dialog.QuitID:
 		if cmd := m.openQuitDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -2700,6 +2874,54 @@ func (m *UI) openReasoningDialog() tea.Cmd {
 	return nil
 }
 
+// openPermissionGrantsDialog opens the dialog listing remembered permission
+// grants.
+func (m *UI) openPermissionGrantsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.PermissionGrantsID) {
+		m.dialog.BringToFront(dialog.PermissionGrantsID)
+		return nil
+	}
+
+	grantsDialog, err := dialog.NewPermissionGrants(m.com)
+	if err != nil {
+		return uiutil.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(grantsDialog)
+	return nil
+}
+
+// openJobsDialog opens the dialog listing the background jobs of the
+// session.
+func (m *UI) openJobsDialog() {
+	if m.dialog.ContainsDialog(dialog.JobsID) {
+		m.dialog.BringToFront(dialog.JobsID)
+		return
+	}
+
+	var sessionID string
+	if m.session != nil {
+		sessionID = m.session.ID
+	}
+	m.dialog.OpenDialog(dialog.NewJobs(m.com, sessionID))
+}
+
+// openCommitDialog opens the dialog that drafts a commit message for the
+// files modified in the session and commits them.
+func (m *UI) openCommitDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.CommitID) {
+		m.dialog.BringToFront(dialog.CommitID)
+		return nil
+	}
+	if !m.hasSession() {
+		return uiutil.ReportWarn("Start a session before committing its changes.")
+	}
+
+	commitDialog := dialog.NewCommit(m.com, m.session.ID, m.session.Title)
+	m.dialog.OpenDialog(commitDialog)
+	return tea.Batch(commitDialog.StartLoading(), commitDialog.Draft())
+}
+
 // openSessionsDialog opens the sessions dialog. If the dialog is already open,
 // it brings it to the front. Otherwise, it will list all the sessions and open
 // the dialog.
@@ -2755,6 +2977,25 @@ func (m *UI) openPermissionsDialog(perm permission.PermissionRequest) tea.Cmd {
 	return nil
 }
 
+// handleElicitation opens a form for an elicitation request, or closes it if
+// the server canceled the request.
+func (m *UI) handleElicitation(event pubsub.Event[mcp.ElicitationRequest]) {
+	req := event.Payload
+	current, _ := m.dialog.Dialog(dialog.ElicitationID).(*dialog.Elicitation)
+	switch event.Type {
+	case pubsub.CreatedEvent:
+		if current != nil {
+			m.dialog.CloseDialog(dialog.ElicitationID)
+			mcp.RespondElicitation(current.Request().ID, mcp.ElicitationCancel, nil)
+		}
+		m.dialog.OpenDialog(dialog.NewElicitation(m.com, req))
+	case pubsub.DeletedEvent:
+		if current != nil && current.Request().ID == req.ID {
+			m.dialog.CloseDialog(dialog.ElicitationID)
+		}
+	}
+}
+
 // handlePermissionNotification updates tool items when permission state changes.
 func (m *UI) handlePermissionNotification(notification permission.PermissionNotification) {
 	toolItem := m.chat.MessageItem(notification.ToolCallID)
@@ -2771,6 +3012,65 @@ func (m *UI) handlePermissionNotification(notification permission.PermissionNoti
 	}
 }
 
+// rewoundMsg is sent once a session was rewound.
+type rewoundMsg struct {
+	result   app.RewindResult
+	truncate bool
+}
+
+// openRewindDialog asks to confirm rewinding to a user message.
+func (m *UI) openRewindDialog(msg chat.RewindMsg) {
+	// Replace any pending confirmation, which may be for another message.
+	m.dialog.CloseDialog(dialog.RewindID)
+	m.dialog.OpenDialog(dialog.NewRewind(m.com, msg.SessionID, msg.MessageID, msg.Truncate))
+}
+
+// rewind restores the files edited after a user message, and removes the
+// message and the rest of the conversation if asked to.
+func (m *UI) rewind(sessionID, messageID string, truncate bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.com.App.Rewind(context.Background(), sessionID, messageID, truncate)
+		if err != nil {
+			return uiutil.InfoMsg{Type: uiutil.InfoTypeError, Msg: err.Error()}
+		}
+		return rewoundMsg{result: result, truncate: truncate}
+	}
+}
+
+func (m *UI) handleRewound(msg rewoundMsg) tea.Cmd {
+	files := len(msg.result.Restored) + len(msg.result.Removed)
+	cmds := []tea.Cmd{uiutil.ReportInfo(fmt.Sprintf("Rewound %d file(s)", files))}
+	if m.hasSession() {
+		cmds = append(cmds, m.loadSession(m.session.ID))
+	}
+	if msg.truncate {
+		// Put the prompt back in the editor so it can be edited and resent.
+		m.textarea.SetValue(msg.result.Prompt)
+		m.textarea.MoveToEnd()
+		m.focus = uiFocusEditor
+		m.chat.Blur()
+		cmds = append(cmds, m.textarea.Focus())
+	}
+	return tea.Batch(cmds...)
+}
+
+// forkedMsg is sent once a session was forked.
+type forkedMsg struct {
+	session session.Session
+}
+
+// fork copies a session up to the given message, or entirely if it's empty,
+// into a new session.
+func (m *UI) fork(sessionID, messageID string) tea.Cmd {
+	return func() tea.Msg {
+		fork, err := m.com.App.Sessions.Fork(context.Background(), sessionID, messageID)
+		if err != nil {
+			return uiutil.InfoMsg{Type: uiutil.InfoTypeError, Msg: err.Error()}
+		}
+		return forkedMsg{session: fork}
+	}
+}
+
 // newSession clears the current session state and prepares for a new session.
 // The actual session creation happens when the user sends their first message.
 func (m *UI) newSession() {
@@ -2788,6 +3088,7 @@ func (m *UI) newSession() {
 	m.pillsExpanded = false
 	m.promptQueue = 0
 	m.pillsView = ""
+	m.budgetStatus = nil
 }
 
 // handlePasteMsg handles a paste message.
@@ -386,6 +386,10 @@ type Styles struct {
 			UpdatingMessage                lipgloss.Style
 			UpdatingTitleGradientFromColor color.Color
 			UpdatingTitleGradientToColor   color.Color
+
+			// styles for the snippets of search results
+			SearchSnippetFocused lipgloss.Style
+			SearchSnippetBlurred lipgloss.Style
 		}
 	}
 
@@ -431,6 +435,8 @@ type Styles struct {
 		HelpText        lipgloss.Style // Help action text style
 		Area            lipgloss.Style // Pills area container
 		TodoSpinner     lipgloss.Style // Todo spinner style
+		BudgetWarning   lipgloss.Style // Budget running out
+		BudgetExceeded  lipgloss.Style // Budget used up
 	}
 }
 
@@ -1305,6 +1311,9 @@ func DefaultStyles() Styles {
 	s.Dialog.Sessions.UpdatingItemBlurred = s.Dialog.NormalItem.Foreground(fgSubtle)
 	s.Dialog.Sessions.UpdatingItemFocused = s.Dialog.SelectedItem.UnsetBackground().UnsetForeground()
 
+	s.Dialog.Sessions.SearchSnippetFocused = s.Dialog.SelectedItem.PaddingLeft(3)
+	s.Dialog.Sessions.SearchSnippetBlurred = s.Dialog.NormalItem.PaddingLeft(3).Foreground(fgMuted)
+
 	s.Status.Help = lipgloss.NewStyle().Padding(0, 1)
 	s.Status.SuccessIndicator = base.Foreground(bgSubtle).Background(green).Padding(0, 1).Bold(true).SetString("OKAY!")
 	s.Status.InfoIndicator = s.Status.SuccessIndicator
@@ -1338,6 +1347,8 @@ func DefaultStyles() Styles {
 	s.Pills.HelpText = s.Subtle
 	s.Pills.Area = base
 	s.Pills.TodoSpinner = base.Foreground(greenDark)
+	s.Pills.BudgetWarning = base.Foreground(warning)
+	s.Pills.BudgetExceeded = base.Foreground(error)
 
 	return s
 }
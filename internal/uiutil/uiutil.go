@@ -6,7 +6,9 @@ package uiutil
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"os/exec"
 	"time"
 
@@ -53,6 +55,16 @@ func NewWarnMsg(warn string) InfoMsg {
 	}
 }
 
+// NewRetryMsg returns the warning shown while waiting to retry a failed
+// provider request. It clears itself when the retry starts.
+func NewRetryMsg(delay time.Duration, attempt, maxAttempts int) InfoMsg {
+	return InfoMsg{
+		Type: InfoTypeWarn,
+		Msg:  fmt.Sprintf("retrying in %ds (attempt %d/%d)", int(math.Ceil(delay.Seconds())), attempt, maxAttempts),
+		TTL:  delay,
+	}
+}
+
 func NewErrorMsg(err error) InfoMsg {
 	return InfoMsg{
 		Type: InfoTypeError,
@@ -7,10 +7,17 @@ import (
 	"os"
 
 	"github.com/charmbracelet/brush/internal/cmd"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/charmbracelet/brush/internal/shell"
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	shell.RunSandboxHelper()
+
+	// Load .env only now: the sandbox helper runs in the command's directory
+	// and must not add its .env to the sandboxed command's environment.
+	_ = godotenv.Load()
+
 	if os.Getenv("CRUSH_PROFILE") != "" {
 		go func() {
 			slog.Info("Serving pprof at localhost:6060")